@@ -0,0 +1,70 @@
+package metadata_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestParseAVIF(t *testing.T) {
+	x := exif.New(100, 100)
+	x.Set(exiftag.Make, exif.Ascii("ExifMake"))
+	x.Set(exiftag.Model, exif.Ascii("ExifModel"))
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := avifFile(append([]byte("\x00\x00\x00\x06Exif\x00\x00"), p...), []byte(testXMP))
+
+	tests := []struct {
+		want      []string
+		make      string
+		model     string
+		origTaken bool
+	}{
+		{nil, "TestMake", "ExifModel", true},
+		{[]string{"exif"}, "ExifMake", "ExifModel", false},
+		{[]string{"xmp"}, "TestMake", "", true},
+	}
+	for _, tt := range tests {
+		m, err := metadata.ParseWithOptions(bytes.NewReader(src), metadata.Options{Want: tt.want})
+		if err != nil {
+			t.Errorf("want %v: %v", tt.want, err)
+			continue
+		}
+		if got := m.Get(metadata.Make); got != tt.make {
+			t.Errorf("want %v: Make is %q, want %q", tt.want, got, tt.make)
+		}
+		if got := m.Get(metadata.Model); got != tt.model {
+			t.Errorf("want %v: Model is %q, want %q", tt.want, got, tt.model)
+		}
+		if got := m.DateTimeOriginal.Prec > 0; got != tt.origTaken {
+			t.Errorf("want %v: DateTimeOriginal is %v", tt.want, m.DateTimeOriginal)
+		}
+	}
+}
+
+// avifFile creates an AVIF file with Exif and XMP items
+// stored in the idat box.
+func avifFile(exif, xmp []byte) []byte {
+	ftyp := testutil.Box("ftyp", []byte("avif\x00\x00\x00\x00mif1avif"))
+
+	iinf := testutil.Box("iinf", []byte{0, 0, 0, 0}, testutil.U16(2),
+		testutil.Box("infe", []byte{2, 0, 0, 0}, testutil.U16(1), testutil.U16(0), []byte("Exif\x00")),
+		testutil.Box("infe", []byte{2, 0, 0, 0}, testutil.U16(2), testutil.U16(0), []byte("mime\x00application/rdf+xml\x00")),
+	)
+
+	// iloc version 1 with construction method 1 (idat)
+	iloc := testutil.Box("iloc", []byte{1, 0, 0, 0}, []byte{0x44, 0x00}, testutil.U16(2),
+		testutil.U16(1), testutil.U16(1), testutil.U16(0), testutil.U16(1), testutil.U32(0), testutil.U32(len(exif)),
+		testutil.U16(2), testutil.U16(1), testutil.U16(0), testutil.U16(1), testutil.U32(len(exif)), testutil.U32(len(xmp)),
+	)
+
+	hdlr := testutil.Box("hdlr", []byte{0, 0, 0, 0}, testutil.U32(0), []byte("pict"), make([]byte, 13))
+	meta := testutil.Box("meta", []byte{0, 0, 0, 0}, hdlr, iinf, iloc, testutil.Box("idat", exif, xmp))
+	return append(ftyp, meta...)
+}
@@ -0,0 +1,93 @@
+package metadata
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// FileResult is the result of parsing a file in ParseFiles.
+type FileResult struct {
+	Path string
+	Meta *Metadata
+
+	// Err is the error returned by Parse, or the error
+	// encountered by opening the file.
+	Err error
+}
+
+// ParseFiles parses the files at paths using Parse, and streams
+// the results in the order they complete on the returned channel.
+//
+// At most concurrency files are parsed at the same time.
+// Each file is closed before sending its result.
+// Errors such as ErrUnknownFormat are reported in the result
+// of the file, and the rest of the files are still parsed.
+//
+// The channel is closed when all files have been parsed,
+// or ctx is cancelled. After ctx is cancelled no more files
+// are opened, and results of files being parsed may be dropped.
+func ParseFiles(ctx context.Context, paths []string, concurrency int) <-chan FileResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	c := make(chan FileResult)
+	work := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				m, err := parseFile(path)
+				select {
+				case c <- FileResult{Path: path, Meta: m, Err: err}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+	Loop:
+		for _, path := range paths {
+			select {
+			case work <- path:
+			case <-ctx.Done():
+				break Loop
+			}
+		}
+		close(work)
+		wg.Wait()
+		close(c)
+	}()
+
+	return c
+}
+
+// ParseFile opens the file at path, parses it using Parse and closes it.
+//
+// Errors are returned as *os.PathError with the path of the file,
+// therefore errors.Is should be used to check for errors
+// such as ErrUnknownFormat or ErrNoMeta. Like with Parse,
+// valid metadata is returned along with non-fatal errors.
+func ParseFile(path string) (*Metadata, error) {
+	m, err := parseFile(path)
+	if err != nil {
+		if _, ok := err.(*os.PathError); !ok {
+			err = &os.PathError{Op: "parse", Path: path, Err: err}
+		}
+	}
+	return m, err
+}
+
+func parseFile(path string) (*Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
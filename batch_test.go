@@ -0,0 +1,101 @@
+package metadata_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+)
+
+func TestParseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	jpeg := filepath.Join(dir, "test.jpg")
+	if err := ioutil.WriteFile(jpeg, testWantJpeg(t), 0666); err != nil {
+		t.Fatal(err)
+	}
+	unknown := filepath.Join(dir, "test.txt")
+	if err := ioutil.WriteFile(unknown, []byte("not a media file"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.jpg")
+
+	var paths []string
+	for i := 0; i < 5; i++ {
+		paths = append(paths, jpeg, unknown, missing)
+	}
+
+	n := 0
+	for r := range metadata.ParseFiles(context.Background(), paths, 3) {
+		n++
+		switch r.Path {
+		case jpeg:
+			if r.Err != nil || r.Meta == nil || r.Meta.Make != "TestMake" {
+				t.Errorf("%s: got %v, %v", r.Path, r.Meta, r.Err)
+			}
+		case unknown:
+			if r.Err != metadata.ErrUnknownFormat {
+				t.Errorf("%s: got error %v, want %v", r.Path, r.Err, metadata.ErrUnknownFormat)
+			}
+		case missing:
+			if !os.IsNotExist(r.Err) {
+				t.Errorf("%s: got error %v, want not exist", r.Path, r.Err)
+			}
+		default:
+			t.Errorf("unexpected path %q", r.Path)
+		}
+	}
+	if n != len(paths) {
+		t.Errorf("got %d results, want %d", n, len(paths))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range metadata.ParseFiles(ctx, paths, 2) {
+		// results may be produced before cancellation is noticed,
+		// but the channel must be closed
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	jpeg := filepath.Join(dir, "test.jpg")
+	if err := ioutil.WriteFile(jpeg, testWantJpeg(t), 0666); err != nil {
+		t.Fatal(err)
+	}
+	unknown := filepath.Join(dir, "test.txt")
+	if err := ioutil.WriteFile(unknown, []byte("not a media file"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := metadata.ParseFile(jpeg)
+	if err != nil || m == nil || m.Make != "TestMake" {
+		t.Errorf("%s: got %v, %v", jpeg, m, err)
+	}
+
+	_, err = metadata.ParseFile(unknown)
+	if !errors.Is(err, metadata.ErrUnknownFormat) {
+		t.Errorf("%s: got error %v, want %v", unknown, err, metadata.ErrUnknownFormat)
+	}
+	if pe, ok := err.(*os.PathError); !ok || pe.Path != unknown {
+		t.Errorf("%s: got error %#v, want *os.PathError with the path", unknown, err)
+	}
+
+	missing := filepath.Join(dir, "missing.jpg")
+	if _, err := metadata.ParseFile(missing); !os.IsNotExist(err) {
+		t.Errorf("%s: got error %v, want not exist", missing, err)
+	}
+}
@@ -0,0 +1,144 @@
+// Command metadump prints the metadata of media files.
+//
+// Each attribute is printed on a separate line
+// as the file name, attribute name and value separated by tabs,
+// with attributes sorted by name.
+//
+// With the -tracks flag the track headers (tkhd) of MP4 files
+// and their media headers (mdhd) are printed as well
+// after the attributes, with attribute names
+// prefixed by the track number, such as "Track1.Rotation".
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/mp4"
+)
+
+var tracks = flag.Bool("tracks", false, "print track headers of MP4 files")
+
+func main() {
+	flag.Parse()
+
+	status := 0
+	for _, fn := range flag.Args() {
+		if err := dumpFile(os.Stdout, fn, fn); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", fn, err)
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+// dumpFile dumps the file at path to w using name as the file name.
+// Errors that still yield metadata are printed to stderr.
+func dumpFile(w io.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	m, err := metadata.ParseAt(f)
+	if m == nil {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+	}
+	if err := dump(w, name, m); err != nil {
+		return err
+	}
+
+	if !*tracks || !isMP4(f) {
+		return nil
+	}
+	mf, err := mp4.Parse(io.NewSectionReader(f, 0, 1<<63-1))
+	if err != nil {
+		return err
+	}
+	return dumpTracks(w, name, mf.Tracks())
+}
+
+// heifBrands are the ftyp major brands of HEIF and AVIF images
+// without tracks.
+var heifBrands = map[string]bool{"heic": true, "heix": true, "heif": true, "mif1": true, "avif": true}
+
+// isMP4 reports if the file in r starts with the ftyp box
+// of a movie, that is not a HEIF image.
+func isMP4(r io.ReaderAt) bool {
+	var p [12]byte
+	if _, err := r.ReadAt(p[:], 0); err != nil {
+		return false
+	}
+	return string(p[4:8]) == "ftyp" && !heifBrands[string(p[8:])]
+}
+
+// dump writes the attributes of m in the format described
+// in the package documentation.
+func dump(w io.Writer, fn string, m *metadata.Metadata) error {
+	keys := make([]string, 0, len(m.Attr))
+	for k := range m.Attr {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%q\n", fn, k, m.Attr[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumpTracks writes the decoded track headers of tracks
+// in the format described in the package documentation.
+func dumpTracks(w io.Writer, fn string, tracks []mp4.TrackInfo) error {
+	for i, t := range tracks {
+		h := t.Header
+		dx, dy := h.FrameSize()
+		for _, a := range []struct {
+			name  string
+			value interface{}
+		}{
+			{"TrackId", h.TrackId},
+			{"Handler", t.HandlerType},
+			{"DateCreated", fmtDate(h.DateCreated)},
+			{"DateModified", fmtDate(h.DateModified)},
+			{"FrameSize", fmt.Sprintf("%dx%d", dx, dy)},
+			{"Rotation", h.Rotation()},
+			{"Language", mediaLanguage(t.Media)},
+		} {
+			_, err := fmt.Fprintf(w, "%s\tTrack%d.%s\t%q\n", fn, i+1, a.name, fmt.Sprint(a.value))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mediaLanguage returns the language of the media header m,
+// or an empty string if m is nil.
+func mediaLanguage(m *mp4.MDHD) string {
+	if m == nil {
+		return ""
+	}
+	return m.Language()
+}
+
+// fmtDate formats t in RFC 3339 format,
+// or returns an empty string for the zero time.
+func fmtDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
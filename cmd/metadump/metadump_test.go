@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/mp4"
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestDump(t *testing.T) {
+	m := new(metadata.Metadata)
+	m.Set(metadata.Make, "TestMake")
+	m.Set(metadata.Orientation, "6")
+	m.Set(metadata.GPSLatitude, "47.500000")
+
+	var buf bytes.Buffer
+	if err := dump(&buf, "a.jpg", m); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "a.jpg\tGPSLatitude\t\"47.500000\"\n" +
+		"a.jpg\tMake\t\"TestMake\"\n" +
+		"a.jpg\tOrientation\t\"6\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDumpTracks(t *testing.T) {
+	const one = 1 << 16
+	tracks := []mp4.TrackInfo{
+		{
+			Header: &mp4.TKHD{
+				DateCreated: time.Date(2018, 5, 6, 7, 8, 9, 0, time.UTC),
+				TrackId:     1,
+				Matrix:      [9]int32{0, one, 0, -one, 0, 0, 0, 0, 1 << 30},
+				Width:       640 << 16,
+				Height:      480 << 16,
+			},
+			HandlerType: "vide",
+			Media:       &mp4.MDHD{LanguageCode: 0x15c7}, // "eng"
+		},
+		{
+			Header:      &mp4.TKHD{TrackId: 2},
+			HandlerType: "soun",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := dumpTracks(&buf, "a.mp4", tracks); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "a.mp4\tTrack1.TrackId\t\"1\"\n" +
+		"a.mp4\tTrack1.Handler\t\"vide\"\n" +
+		"a.mp4\tTrack1.DateCreated\t\"2018-05-06T07:08:09Z\"\n" +
+		"a.mp4\tTrack1.DateModified\t\"\"\n" +
+		"a.mp4\tTrack1.FrameSize\t\"640x480\"\n" +
+		"a.mp4\tTrack1.Rotation\t\"90\"\n" +
+		"a.mp4\tTrack1.Language\t\"eng\"\n" +
+		"a.mp4\tTrack2.TrackId\t\"2\"\n" +
+		"a.mp4\tTrack2.Handler\t\"soun\"\n" +
+		"a.mp4\tTrack2.DateCreated\t\"\"\n" +
+		"a.mp4\tTrack2.DateModified\t\"\"\n" +
+		"a.mp4\tTrack2.FrameSize\t\"0x0\"\n" +
+		"a.mp4\tTrack2.Rotation\t\"0\"\n" +
+		"a.mp4\tTrack2.Language\t\"\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDumpErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "unknown.bin")
+	if err := ioutil.WriteFile(fn, []byte("not a media file"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := dumpFile(&buf, fn, fn); err != metadata.ErrUnknownFormat {
+		t.Errorf("got error %v, want %v", err, metadata.ErrUnknownFormat)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestDumpFileTracks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(v bool) { *tracks = v }(*tracks)
+	*tracks = true
+
+	tkhd := testutil.Box("tkhd", []byte{0, 0, 0, 3}, make([]byte, 8), testutil.U32(1),
+		make([]byte, 60), testutil.U32(640<<16), testutil.U32(480<<16))
+	hdlr := testutil.Box("hdlr", make([]byte, 8), []byte("vide"), make([]byte, 12), []byte("Video\x00"))
+	mvhd := testutil.Box("mvhd", make([]byte, 12), testutil.U32(600), testutil.U32(1200), make([]byte, 80))
+	movie := bytes.Join([][]byte{
+		testutil.Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		testutil.Box("moov", mvhd, testutil.Box("trak", tkhd, testutil.Box("mdia", hdlr))),
+		testutil.Box("mdat", []byte("data")),
+	}, nil)
+
+	// canvas of 640x480 pixels
+	image := testutil.WebP("VP8X", "\x00\x00\x00\x00\x7f\x02\x00\xdf\x01\x00")
+
+	for _, tt := range []struct {
+		name   string
+		data   []byte
+		tracks bool
+	}{
+		{"a.mp4", movie, true},
+		{"a.webp", image, false},
+	} {
+		fn := filepath.Join(dir, tt.name)
+		if err := ioutil.WriteFile(fn, tt.data, 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := dumpFile(&buf, fn, tt.name); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if got := strings.Contains(buf.String(), tt.name+"\tTrack1.Handler\t\"vide\"\n"); got != tt.tracks {
+			t.Errorf("%s: tracks printed is %v, want %v; output:\n%s", tt.name, got, tt.tracks, buf.String())
+		}
+	}
+}
+
+// TestGolden compares the output for the files in the test media set
+// with the golden files in testdata. Run with -update to
+// create or update golden files.
+func TestGolden(t *testing.T) {
+	root := testutil.MediaRoot(t)
+	if _, err := os.Stat("testdata"); os.IsNotExist(err) && !*update {
+		t.Skip("no golden files, run with -update to create them")
+	}
+	for _, e := range testutil.MediaFileInfos(t) {
+		fn, ok := e.String("SourceFile")
+		if !ok {
+			continue
+		}
+		rel, err := filepath.Rel(root, fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := dumpFile(&buf, fn, filepath.ToSlash(rel)); err != nil {
+			t.Logf("%s: %v", rel, err)
+		}
+
+		golden := filepath.Join("testdata", strings.Replace(filepath.ToSlash(rel), "/", "_", -1)+".golden")
+		if *update {
+			if err := os.MkdirAll("testdata", 0777); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(golden, buf.Bytes(), 0666); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", rel, buf.Bytes(), want)
+		}
+	}
+}
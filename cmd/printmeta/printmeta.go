@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/tajtiattila/metadata"
 )
@@ -18,18 +17,11 @@ func main() {
 }
 
 func processFile(fn string) {
-	f, err := os.Open(fn)
+	m, err := metadata.ParseFile(fn)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	defer f.Close()
-
-	m, err := metadata.ParseAt(f)
-	if err != nil {
-		log.Printf("%s: %s", fn, err)
-		return
-	}
 
 	fmt.Printf("%s:\n", fn)
 	for k, v := range m.Attr {
@@ -0,0 +1,402 @@
+package metadata
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+	"github.com/tajtiattila/metadata/xmp"
+)
+
+// Copy copies the media file from r to w,
+// with its metadata updated using the attributes in m.
+//
+// Metadata in r not present in m.Attr is kept, so Copy may be
+// used to update only select attributes. Image data and
+// other content are copied unmodified. Existing XMP is
+// copied verbatim if none of its properties are updated.
+//
+// Copy returns exif.ErrOrientation if the Orientation
+// attribute is not a valid Exif orientation.
+//
+// Currently only JPEG files are supported. ErrUnknownFormat
+// is returned for other formats.
+func Copy(w io.Writer, r io.Reader, m *Metadata) error {
+	p := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, p)
+	switch err {
+	case io.ErrUnexpectedEOF, io.EOF, nil:
+		// pass
+	default:
+		return err
+	}
+	p = p[:n]
+
+	if isjpeg(p) {
+		return copyJpeg(w, prefixReader(p, r), m)
+	}
+
+	return ErrUnknownFormat
+}
+
+// Update is a media file with its metadata to be updated.
+// It implements io.WriterTo using Copy, so that the updated file
+// may be passed to functions accepting an io.WriterTo.
+type Update struct {
+	Src  io.Reader // source media file
+	Meta *Metadata // attributes to update
+}
+
+// WriteTo writes the media file in u.Src to w with its metadata
+// updated using u.Meta, see Copy. Src is read until its end,
+// therefore WriteTo may only be called once.
+func (u *Update) WriteTo(w io.Writer) (n int64, err error) {
+	cw := &countWriter{w: w}
+	err = Copy(cw, u.Src, u.Meta)
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// DeleteAllGPS copies the media file from r to w,
+// with all GPS information removed from its metadata.
+//
+// The GPS directory is removed from Exif, and GPS properties
+// such as exif:GPSLatitude from XMP. Other metadata,
+// image data and other content are copied unmodified.
+//
+// Currently only JPEG files are supported. ErrUnknownFormat
+// is returned for other formats.
+func DeleteAllGPS(w io.Writer, r io.Reader) error {
+	p := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, p)
+	switch err {
+	case io.ErrUnexpectedEOF, io.EOF, nil:
+		// pass
+	default:
+		return err
+	}
+	p = p[:n]
+
+	if isjpeg(p) {
+		return deleteJpegGPS(w, prefixReader(p, r))
+	}
+
+	return ErrUnknownFormat
+}
+
+// deleteJpegGPS copies the JPEG in r to w, removing GPS information
+// from all of its Exif and XMP chunks, including duplicates.
+func deleteJpegGPS(w io.Writer, r io.Reader) error {
+	j, err := xjpeg.NewScanner(r)
+	if err != nil {
+		return err
+	}
+
+	var segs [][]byte
+	for j.Next() {
+		isExif := j.IsChunk(0xe1, jpegExifPfx)
+		isXMP := j.IsChunk(0xe1, jpegXMPPfx)
+
+		seg, err := j.ReadSegment()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case isExif:
+			x, err := exif.DecodeBytes(seg[4+len(jpegExifPfx):])
+			if x == nil {
+				return err
+			}
+			if x.GPS != nil {
+				x.DeleteAllGPS()
+				p, err := x.EncodeBytes()
+				if err != nil {
+					return err
+				}
+				if seg, err = jpegSegment(0xe1, jpegExifPfx, p); err != nil {
+					return err
+				}
+			}
+		case isXMP:
+			xm, err := xmp.Decode(bytes.NewReader(seg[4+len(jpegXMPPfx):]))
+			if err != nil {
+				return err
+			}
+			if xm.DeleteAllGPS(); xm.Modified() {
+				buf := new(bytes.Buffer)
+				if err := xm.Encode(buf); err != nil {
+					return err
+				}
+				if seg, err = jpegSegment(0xe1, jpegXMPPfx, buf.Bytes()); err != nil {
+					return err
+				}
+			}
+		}
+		segs = append(segs, seg)
+	}
+	if err := j.Err(); err != nil {
+		return err
+	}
+
+	for _, seg := range segs {
+		if _, err := w.Write(seg); err != nil {
+			return err
+		}
+	}
+
+	// copy bytes unread so far, such as actual image data
+	_, err = io.Copy(w, j.Reader())
+	return err
+}
+
+// copyJpeg copies the JPEG in r to w, updating its Exif and XMP
+// chunks with the attributes in m. Missing chunks are created
+// after the SOI and APP0 (JFIF) segments if m has attributes
+// stored in them.
+func copyJpeg(w io.Writer, r io.Reader, m *Metadata) error {
+	j, err := xjpeg.NewScanner(r)
+	if err != nil {
+		return err
+	}
+
+	var segs [][]byte
+	exifIdx, xmpIdx := -1, -1
+	for j.Next() {
+		isExif := exifIdx < 0 && j.IsChunk(0xe1, jpegExifPfx)
+		isXMP := xmpIdx < 0 && j.IsChunk(0xe1, jpegXMPPfx)
+
+		seg, err := j.ReadSegment()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case isExif:
+			exifIdx = len(segs)
+		case isXMP:
+			xmpIdx = len(segs)
+		}
+		segs = append(segs, seg)
+	}
+	if err := j.Err(); err != nil {
+		return err
+	}
+
+	// Exif
+	var x *exif.Exif
+	var blank []byte // new Exif without attributes from m
+	if exifIdx >= 0 {
+		x, err = exif.DecodeBytes(segs[exifIdx][4+len(jpegExifPfx):])
+		if x == nil {
+			return err
+		}
+	} else {
+		x = newExif(m)
+		if blank, err = x.EncodeBytes(); err != nil {
+			return err
+		}
+	}
+	if err := updateExif(x, m); err != nil {
+		return err
+	}
+
+	p, err := x.EncodeBytes()
+	if err != nil {
+		return err
+	}
+	exifSeg, err := jpegSegment(0xe1, jpegExifPfx, p)
+	if err != nil {
+		return err
+	}
+
+	// XMP
+	var xm *xmp.Meta
+	if xmpIdx >= 0 {
+		xm, err = xmp.Decode(bytes.NewReader(segs[xmpIdx][4+len(jpegXMPPfx):]))
+		if err != nil {
+			return err
+		}
+	} else {
+		xm = xmp.New()
+	}
+	updateXMP(xm, m)
+
+	var xmpSeg []byte
+	if xmpIdx >= 0 && !xm.Modified() {
+		// keep original formatting
+		xmpSeg = segs[xmpIdx]
+	} else {
+		buf := new(bytes.Buffer)
+		if err := xm.Encode(buf); err != nil {
+			return err
+		}
+		xmpSeg, err = jpegSegment(0xe1, jpegXMPPfx, buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	// replace or insert segments
+	if exifIdx >= 0 {
+		segs[exifIdx] = exifSeg
+	} else if !bytes.Equal(p, blank) {
+		exifIdx = jpegMetaPos(segs)
+		segs = insertSegment(segs, exifIdx, exifSeg)
+		if xmpIdx >= exifIdx {
+			xmpIdx++
+		}
+	}
+	if xmpIdx >= 0 {
+		segs[xmpIdx] = xmpSeg
+	} else if xm.Modified() {
+		i := jpegMetaPos(segs)
+		if exifIdx >= 0 {
+			i = exifIdx + 1
+		}
+		segs = insertSegment(segs, i, xmpSeg)
+	}
+
+	for _, seg := range segs {
+		if _, err := w.Write(seg); err != nil {
+			return err
+		}
+	}
+
+	// copy bytes unread so far, such as actual image data
+	_, err = io.Copy(w, j.Reader())
+	return err
+}
+
+// jpegMetaPos returns the index for new metadata segments,
+// that is after SOI and the APP0 (JFIF) segments.
+func jpegMetaPos(segs [][]byte) int {
+	i := 0
+	if i < len(segs) && bytes.Equal(segs[i], []byte{0xff, 0xd8}) {
+		i++
+	}
+	for i < len(segs) && len(segs[i]) >= 2 && segs[i][0] == 0xff && segs[i][1] == 0xe0 {
+		i++
+	}
+	return i
+}
+
+func insertSegment(segs [][]byte, i int, seg []byte) [][]byte {
+	segs = append(segs, nil)
+	copy(segs[i+1:], segs[i:])
+	segs[i] = seg
+	return segs
+}
+
+func jpegSegment(marker byte, prefix, data []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	chunk := make([]byte, 0, len(prefix)+len(data))
+	chunk = append(chunk, prefix...)
+	chunk = append(chunk, data...)
+	if err := xjpeg.WriteChunk(buf, marker, chunk); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newExif returns new Exif for m.
+func newExif(m *Metadata) *exif.Exif {
+	dx, dxerr := strconv.Atoi(m.Get(ImageWidth))
+	dy, dyerr := strconv.Atoi(m.Get(ImageHeight))
+	x := exif.New(dx, dy)
+	if dxerr != nil || dyerr != nil {
+		x.Set(exiftag.PixelXDimension, nil)
+		x.Set(exiftag.PixelYDimension, nil)
+	}
+	return x
+}
+
+// updateExif updates x with the attributes present in m.
+func updateExif(x *exif.Exif, m *Metadata) error {
+	has := func(key string) bool {
+		_, ok := m.Attr[key]
+		return ok
+	}
+
+	if has(DateTimeOriginal) && m.DateTimeOriginal.Prec > 0 {
+		x.SetTimeWithOffset(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal,
+			exiftag.OffsetTimeOriginal, m.DateTimeOriginal.Time)
+	}
+	if has(DateTimeCreated) && m.DateTimeCreated.Prec > 0 {
+		x.SetTimeWithOffset(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized,
+			exiftag.OffsetTimeDigitized, m.DateTimeCreated.Time)
+	}
+
+	if m.GPS.Valid {
+		// keep version, altitude and time unless specified in m
+		i, _ := x.GPSInfo()
+		i.Lat, i.Long = m.GPS.Latitude, m.GPS.Longitude
+		if alt, err := strconv.ParseFloat(m.Get(GPSAltitude), 64); err == nil {
+			i.Alt.Float64, i.Alt.Valid = alt, true
+		}
+		if !m.GPS.Time.IsZero() {
+			i.Time = m.GPS.Time
+		}
+		x.SetGPSInfo(i)
+	}
+
+	if d, err := strconv.ParseFloat(m.Get(GPSImgDirection), 64); err == nil && d >= 0 {
+		num, den := exif.FloatRational(d)
+		x.Set(exiftag.GPSImgDirection, exif.Rational{num, den})
+	}
+
+	if has(GPSProcessingMethod) {
+		x.SetGPSProcessingMethod(m.Get(GPSProcessingMethod))
+	}
+	if has(GPSAreaInformation) {
+		x.SetGPSAreaInformation(m.Get(GPSAreaInformation))
+	}
+
+	if has(Orientation) {
+		if err := x.SetOrientation(m.Orientation); err != nil {
+			return err
+		}
+	}
+
+	if has(Make) {
+		x.Set(exiftag.Make, exif.Ascii(m.Make))
+	}
+	if has(Model) {
+		x.Set(exiftag.Model, exif.Ascii(m.Model))
+	}
+	if has(Software) {
+		x.SetSoftware(m.Get(Software))
+	}
+	if has(ImageDescription) {
+		x.SetImageDescription(m.Get(ImageDescription))
+	}
+	if has(UserComment) {
+		x.SetUserComment(m.Get(UserComment))
+	}
+	return nil
+}
+
+// updateXMP updates x with the attributes present in m.
+func updateXMP(x *xmp.Meta, m *Metadata) {
+	for _, a := range xmpAttr {
+		if a.setf == nil {
+			continue
+		}
+		if v, ok := m.Attr[a.metaName]; ok {
+			a.setf(x, v)
+		}
+	}
+}
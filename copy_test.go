@@ -0,0 +1,358 @@
+package metadata_test
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+)
+
+// scan data used in test jpegs
+var testScanData = []byte{0xff, 0xda, 0x00, 0x02, 0x01, 0x02, 0x03, 0xff, 0xd9}
+
+func TestCopyJpeg(t *testing.T) {
+	var src bytes.Buffer
+	src.Write([]byte{0xff, 0xd8})
+	jfif := []byte("JFIF\x00\x01\x02\x00\x00\x01\x00\x01\x00\x00")
+	if err := xjpeg.WriteChunk(&src, 0xe0, jfif); err != nil {
+		t.Fatal(err)
+	}
+	xmp := append([]byte(nil), jpegXMPPfx...)
+	xmp = append(xmp, testXMP...)
+	if err := xjpeg.WriteChunk(&src, 0xe1, xmp); err != nil {
+		t.Fatal(err)
+	}
+	src.Write(testScanData)
+
+	m := new(metadata.Metadata)
+	m.Set(metadata.DateTimeOriginal, "2018-05-06T07:08:09")
+	m.Set(metadata.GPSLatitude, "47.5")
+	m.Set(metadata.GPSLongitude, "-19.25")
+	m.Set(metadata.GPSAltitude, "-12.5")
+	m.Set(metadata.GPSDateTime, "2018-05-06T05:08:09Z")
+	m.Set(metadata.GPSProcessingMethod, "NETWORK")
+	m.Set(metadata.Orientation, "6")
+	m.Set(metadata.Model, "TestModel")
+	m.Set(metadata.Title, "Title")
+	m.Set(metadata.UserComment, "Comment")
+	m.Set(metadata.ImageDescription, "Caption")
+	m.Set(metadata.Software, "Editor 1.0")
+
+	var dst bytes.Buffer
+	if err := metadata.Copy(&dst, bytes.NewReader(src.Bytes()), m); err != nil {
+		t.Fatal(err)
+	}
+
+	p := dst.Bytes()
+	if !bytes.HasSuffix(p, testScanData) {
+		t.Error("image data not preserved")
+	}
+	if !bytes.Contains(p, jfif) {
+		t.Error("JFIF segment not preserved")
+	}
+	if i, j := bytes.Index(p, jfif), bytes.Index(p, jpegExifPfx); j < i {
+		t.Error("Exif is not after JFIF")
+	}
+
+	checkCopied := func(name string, m *metadata.Metadata) {
+		checkAttr := func(key, want string) {
+			if got := m.Get(key); got != want {
+				t.Errorf("%s: %s is %q, want %q", name, key, got, want)
+			}
+		}
+		// kept from source
+		checkAttr(metadata.Make, "TestMake")
+
+		// updated
+		checkAttr(metadata.DateTimeOriginal, "2018-05-06T07:08:09")
+		checkAttr(metadata.Orientation, "6")
+		checkAttr(metadata.Model, "TestModel")
+		checkAttr(metadata.GPSDateTime, "2018-05-06T05:08:09Z")
+		checkAttr(metadata.GPSProcessingMethod, "NETWORK")
+		checkAttr(metadata.UserComment, "Comment")
+		checkAttr(metadata.ImageDescription, "Caption")
+		checkAttr(metadata.Software, "Editor 1.0")
+
+		if !m.GPS.Valid || !near(m.GPS.Latitude, 47.5) || !near(m.GPS.Longitude, -19.25) {
+			t.Errorf("%s: GPS is %+v", name, m.GPS)
+		}
+	}
+
+	x, err := metadata.FromXMPBytes(xmpPayload(t, p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCopied("XMP", x)
+	if got := x.Get(metadata.Title); got != "Title" {
+		t.Errorf("XMP: Title is %q", got)
+	}
+	if got := x.Get(metadata.GPSAltitude); got != "-12.5" {
+		t.Errorf("XMP: GPSAltitude is %q", got)
+	}
+	xmpText := string(xmpPayload(t, p))
+	for _, want := range []string{
+		"<exif:GPSTimeStamp>5,8,9</exif:GPSTimeStamp>",
+		"<exif:GPSDateStamp>2018:05:06</exif:GPSDateStamp>",
+	} {
+		if !strings.Contains(xmpText, want) {
+			t.Errorf("XMP: %s missing", want)
+		}
+	}
+
+	x, err = metadata.FromExifBytes(exifPayload(t, p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Set(metadata.Make, "TestMake") // not in source Exif
+	checkCopied("Exif", x)
+}
+
+func TestUpdate(t *testing.T) {
+	src := testWantJpeg(t)
+	m := new(metadata.Metadata)
+	m.Set(metadata.Model, "TestModel")
+
+	var want bytes.Buffer
+	if err := metadata.Copy(&want, bytes.NewReader(src), m); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	var wt io.WriterTo = &metadata.Update{Src: bytes.NewReader(src), Meta: m}
+	n, err := wt.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", n, buf.Len())
+	}
+	if !bytes.Equal(buf.Bytes(), want.Bytes()) {
+		t.Error("WriteTo output differs from Copy")
+	}
+
+	u := &metadata.Update{Src: strings.NewReader("not a media file"), Meta: m}
+	if _, err := u.WriteTo(&buf); err != metadata.ErrUnknownFormat {
+		t.Errorf("got error %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestCopyJpegInsert(t *testing.T) {
+	src := append([]byte{0xff, 0xd8}, testScanData...)
+
+	var dst bytes.Buffer
+	if err := metadata.Copy(&dst, bytes.NewReader(src), new(metadata.Metadata)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst.Bytes(), src) {
+		t.Error("segments inserted without attributes")
+	}
+
+	// Title is stored only in XMP
+	m := new(metadata.Metadata)
+	m.Set(metadata.Title, "Title")
+	dst.Reset()
+	if err := metadata.Copy(&dst, bytes.NewReader(src), m); err != nil {
+		t.Fatal(err)
+	}
+	p := dst.Bytes()
+	if bytes.Contains(p, jpegExifPfx) {
+		t.Error("Exif inserted for XMP attribute")
+	}
+	if !bytes.Contains(xmpPayload(t, p), []byte("Title")) {
+		t.Error("XMP title missing")
+	}
+}
+
+func TestCopyInvalidOrientation(t *testing.T) {
+	for _, v := range []string{"0", "9"} {
+		m := new(metadata.Metadata)
+		m.Set(metadata.Orientation, v)
+
+		var dst bytes.Buffer
+		err := metadata.Copy(&dst, bytes.NewReader(testWantJpeg(t)), m)
+		if err != exif.ErrOrientation {
+			t.Errorf("Orientation %s: got error %v, want %v", v, err, exif.ErrOrientation)
+		}
+		if dst.Len() != 0 {
+			t.Errorf("Orientation %s: wrote %d bytes", v, dst.Len())
+		}
+	}
+}
+
+func TestCopyGPSTimeAsIs(t *testing.T) {
+	// source with separate date and time stamps
+	m := new(metadata.Metadata)
+	m.Set(metadata.GPSDateTime, "2017-01-02T03:04:05Z")
+	var src bytes.Buffer
+	if err := metadata.Copy(&src, bytes.NewReader(testWantJpeg(t)), m); err != nil {
+		t.Fatal(err)
+	}
+
+	m = new(metadata.Metadata)
+	m.Set(metadata.GPSDateTime, "2018-05-06T05:08")
+
+	var dst bytes.Buffer
+	if err := metadata.Copy(&dst, bytes.NewReader(src.Bytes()), m); err != nil {
+		t.Fatal(err)
+	}
+	xmpText := string(xmpPayload(t, dst.Bytes()))
+	want := "<exif:GPSTimeStamp>2018-05-06T05:08</exif:GPSTimeStamp>"
+	if !strings.Contains(xmpText, want) {
+		t.Errorf("XMP: %s missing", want)
+	}
+	if strings.Contains(xmpText, "GPSDateStamp") {
+		t.Error("XMP: stale exif:GPSDateStamp kept")
+	}
+
+	got, err := metadata.Parse(bytes.NewReader(dst.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get(metadata.GPSDateTime); v != "2018-05-06T05:08" {
+		t.Errorf("GPSDateTime is %q, want %q", v, "2018-05-06T05:08")
+	}
+}
+
+func TestCopyJpegKeepXMP(t *testing.T) {
+	// formatting not reproduced by xmp.Meta.Encode
+	xmp := append([]byte(nil), jpegXMPPfx...)
+	xmp = append(xmp, strings.Replace(testXMP, "\n", "\n\t \t", -1)...)
+
+	var src bytes.Buffer
+	src.Write([]byte{0xff, 0xd8})
+	if err := xjpeg.WriteChunk(&src, 0xe1, xmp); err != nil {
+		t.Fatal(err)
+	}
+	src.Write(testScanData)
+
+	parsed, err := metadata.Parse(bytes.NewReader(src.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		m    *metadata.Metadata
+	}{
+		{"empty", new(metadata.Metadata)},
+		{"parsed", parsed},
+	} {
+		var dst bytes.Buffer
+		if err := metadata.Copy(&dst, bytes.NewReader(src.Bytes()), tt.m); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := xmpPayload(t, dst.Bytes()); !bytes.Equal(got, xmp[len(jpegXMPPfx):]) {
+			t.Errorf("%s: unmodified XMP not copied verbatim, got:\n%s", tt.name, got)
+		}
+	}
+}
+
+func TestDeleteAllGPS(t *testing.T) {
+	x := exif.New(100, 100)
+	x.SetLatLong(47.5, 19.25)
+	x.Set(exiftag.GPSAltitude, exif.Rational{120, 1})
+	x.Set(exiftag.Make, exif.Ascii("ExifMake"))
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xmp := strings.Replace(testXMP, "<tiff:Make>", "<exif:GPSLatitude>47,30.0N</exif:GPSLatitude>\n  "+
+		"<exif:GPSLongitude>19,15.0E</exif:GPSLongitude>\n  <tiff:Make>", 1)
+
+	var src bytes.Buffer
+	src.Write([]byte{0xff, 0xd8})
+	for _, chunk := range [][]byte{
+		append(append([]byte(nil), jpegExifPfx...), p...),
+		append(append([]byte(nil), jpegXMPPfx...), xmp...),
+	} {
+		if err := xjpeg.WriteChunk(&src, 0xe1, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src.Write(testScanData)
+
+	m, err := metadata.Parse(bytes.NewReader(src.Bytes()))
+	if err != nil || !m.GPS.Valid {
+		t.Fatalf("source has no location: %v", err)
+	}
+
+	var dst bytes.Buffer
+	if err := metadata.DeleteAllGPS(&dst, bytes.NewReader(src.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(dst.Bytes(), testScanData) {
+		t.Error("image data not preserved")
+	}
+
+	gx, err := exif.DecodeBytes(exifPayload(t, dst.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gx.GPS) != 0 {
+		t.Errorf("%d Exif GPS tags remain", len(gx.GPS))
+	}
+	if s, _ := gx.Tag(exiftag.Make).Ascii(); s != "ExifMake" {
+		t.Errorf("Exif Make is %q, want ExifMake", s)
+	}
+
+	m, err = metadata.Parse(bytes.NewReader(dst.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.GPS.Valid {
+		t.Errorf("GPS is %+v", m.GPS)
+	}
+	for k := range m.Attr {
+		if strings.HasPrefix(k, "GPS") {
+			t.Errorf("attribute %s remains", k)
+		}
+	}
+	if m.Make != "TestMake" || m.Get(metadata.DateTimeOriginal) != "2017-04-01T12:34:56" {
+		t.Errorf("XMP not preserved: %v", m.Attr)
+	}
+}
+
+func TestCopyUnknown(t *testing.T) {
+	var dst bytes.Buffer
+	err := metadata.Copy(&dst, bytes.NewReader([]byte("not a media file")), new(metadata.Metadata))
+	if err != metadata.ErrUnknownFormat {
+		t.Errorf("got error %v, want ErrUnknownFormat", err)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func exifPayload(t *testing.T, p []byte) []byte {
+	return chunkPayload(t, p, jpegExifPfx)
+}
+
+func xmpPayload(t *testing.T, p []byte) []byte {
+	return chunkPayload(t, p, jpegXMPPfx)
+}
+
+func chunkPayload(t *testing.T, p, prefix []byte) []byte {
+	j, err := xjpeg.NewScanner(bytes.NewReader(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j.NextChunk() {
+		if j.IsChunk(0xe1, prefix) {
+			_, data, err := j.ReadChunk()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data[len(prefix):]
+		}
+	}
+	t.Fatalf("chunk %q missing", prefix)
+	return nil
+}
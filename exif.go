@@ -28,19 +28,61 @@ func FromExif(x *exif.Exif) *Metadata {
 		if !i.Time.IsZero() {
 			m.Set(GPSDateTime, fmtTime(i.Time, false))
 		}
+		if i.Alt.Valid {
+			m.Set(GPSAltitude, fmt.Sprint(i.Alt.Float64))
+		}
+	}
+
+	if d, ok := x.Tag(exiftag.GPSImgDirection).Rational().Float64(0); ok {
+		m.Set(GPSImgDirection, fmt.Sprint(d))
+	}
+
+	if d, _, ok := x.GPSDestBearing(); ok {
+		m.Set(GPSDestBearing, fmt.Sprint(d))
+	}
+	if d, ok := x.GPSDestDistance(); ok {
+		m.Set(GPSDestDistance, fmt.Sprint(d))
+	}
+
+	if d, ok := x.GPSDOP(); ok {
+		m.Set(GPSDOP, fmt.Sprint(d))
+	}
+	if s, ok := x.GPSSatellites(); ok && s != "" {
+		m.Set(GPSSatellites, s)
+	}
+
+	if s, ok := x.GPSProcessingMethod(); ok && s != "" {
+		m.Set(GPSProcessingMethod, s)
+	}
+	if s, ok := x.GPSAreaInformation(); ok && s != "" {
+		m.Set(GPSAreaInformation, s)
 	}
 
-	if t, islocal, ok := x.Time(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal); ok {
+	if t, islocal, ok := x.TimeWithOffset(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal, exiftag.OffsetTimeOriginal); ok {
 		m.Set(DateTimeOriginal, fmtTime(t, islocal))
 	}
-	if t, islocal, ok := x.Time(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized); ok {
+	if t, islocal, ok := x.TimeWithOffset(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized, exiftag.OffsetTimeDigitized); ok {
 		m.Set(DateTimeCreated, fmtTime(t, islocal))
 	}
 
+	if w, ok := exifUint(x, exiftag.ImageWidth, exiftag.PixelXDimension); ok {
+		m.Set(ImageWidth, fmt.Sprint(w))
+	}
+	if h, ok := exifUint(x, exiftag.ImageLength, exiftag.PixelYDimension); ok {
+		m.Set(ImageHeight, fmt.Sprint(h))
+	}
+
 	if o := x.Tag(exiftag.Orientation).Short(); len(o) > 0 {
 		m.Set(Orientation, fmt.Sprintf("%d", o[0]))
 	}
 
+	if cs, ok := exifColorSpace(x); ok {
+		m.Set(ColorSpace, cs)
+	}
+	if s, ok := x.InteropIndex(); ok && s != "" {
+		m.Set(InteropIndex, s)
+	}
+
 	if s, ok := x.Tag(exiftag.Make).Ascii(); ok {
 		m.Set(Make, s)
 	}
@@ -48,9 +90,59 @@ func FromExif(x *exif.Exif) *Metadata {
 	if s, ok := x.Tag(exiftag.Model).Ascii(); ok {
 		m.Set(Model, s)
 	}
+
+	if s, ok := x.Software(); ok && s != "" {
+		m.Set(Software, s)
+	}
+	if s, ok := x.ImageDescription(); ok && s != "" {
+		m.Set(ImageDescription, s)
+	}
+	if s, ok := x.UserComment(); ok && s != "" {
+		m.Set(UserComment, s)
+	}
+	m.setSource("exif")
 	return m
 }
 
+// exifUint returns the value of the first tag in tags
+// that is a single Short or Long value.
+func exifUint(x *exif.Exif, tags ...uint32) (uint32, bool) {
+	for _, t := range tags {
+		tag := x.Tag(t)
+		if s := tag.Short(); len(s) == 1 {
+			return uint32(s[0]), true
+		}
+		if l := tag.Long(); len(l) == 1 {
+			return l[0], true
+		}
+	}
+	return 0, false
+}
+
+// exifColorSpace returns the color space recorded in x.
+//
+// Exif only defines sRGB explicitly. Cameras record Adobe RGB
+// as uncalibrated with the "R03" Interoperability Index
+// according to DCF, or with the nonstandard value 2.
+func exifColorSpace(x *exif.Exif) (string, bool) {
+	cs := x.Tag(exiftag.ColorSpace).Short()
+	if len(cs) != 1 {
+		return "", false
+	}
+	switch cs[0] {
+	case 1:
+		return "sRGB", true
+	case 2:
+		return "AdobeRGB", true
+	case 0xffff:
+		if s, _ := x.InteropIndex(); s == "R03" {
+			return "AdobeRGB", true
+		}
+		return "Uncalibrated", true
+	}
+	return "", false
+}
+
 func fmtTime(t time.Time, islocal bool) string {
 	x := Time{
 		Time:   t,
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"sort"
 )
 
@@ -13,9 +14,15 @@ const (
 	ifd0gpsSub     = 0x8825
 	ifd0interopSub = 0xA005
 
+	// additional IFDs
+	ifd0subIFDs = 0x014A
+
 	// other data
 	ifd1thumbOffset = 0x201
 	ifd1thumbLength = 0x202
+
+	// maxIFDs is the limit of IFDs followed by decodeBytes
+	maxIFDs = 16
 )
 
 var (
@@ -25,15 +32,71 @@ var (
 	// ErrEmpty is returned when x.Encode is used with no exif data to encode.
 	ErrEmpty = errors.New("exif: nothing to encode")
 
-	// ErrTooLong is returned if the serialized exif is too long to be written in an Exif file.
+	// ErrTooLong is returned if the serialized exif is too long
+	// to be written in the Exif segment of a JPEG file.
 	ErrTooLong = errors.New("exif: encoded length too long")
 )
 
 // DecodeBytes decodes the raw Exif data from p.
+//
+// DecodeBytes never panics, even if p holds arbitrary data,
+// so that it may be used on untrusted input.
 func DecodeBytes(p []byte) (*Exif, error) {
-	if len(p) < 4 {
+	x, _, err := decodeBytes(p, -1)
+	return x, err
+}
+
+// DecodeBytesN is like DecodeBytes, but also returns the number of
+// bytes in p covered by the decoded data. It is the maximum end offset
+// of the header, the IFDs, the values and the thumbnail referenced.
+//
+// An n less than len(p) indicates padding or other trailing data,
+// such as a second TIFF structure appended to the Exif.
+func DecodeBytesN(p []byte) (x *Exif, n int, err error) {
+	return decodeBytes(p, -1)
+}
+
+// DecodeTIFF decodes the first IFD and its sub-IFDs
+// from p that holds the contents of a TIFF file.
+//
+// Unlike with Exif, IFDs after the first one hold further images
+// of multi-page TIFF files, therefore IFD1 and Thumb of
+// the result will be always empty.
+func DecodeTIFF(p []byte) (*Exif, error) {
+	x, _, err := decodeBytes(p, 1)
+	return x, err
+}
+
+// DecodeTIFFAt is like DecodeTIFF, but it reads the header,
+// the IFDs and the values at their offsets from r, that holds
+// a TIFF file of the specified size. It may be used to decode
+// large files without reading their image data.
+//
+// If reading from r fails, the error is returned with a nil Exif.
+func DecodeTIFFAt(r io.ReaderAt, size int64) (*Exif, error) {
+	in := &readerInput{r: r, n: maxInt}
+	if size < int64(maxInt) {
+		in.n = int(size)
+	}
+	x, _, err := decode(in, 1)
+	if in.err != nil {
+		return nil, in.err
+	}
+	return x, err
+}
+
+// decodeBytes decodes p, and returns the number of bytes covered.
+// If maxdirs is not negative, then at most maxdirs IFDs are decoded.
+func decodeBytes(p []byte, maxdirs int) (*Exif, int, error) {
+	return decode(bytesInput(p), maxdirs)
+}
+
+// decode decodes in like decodeBytes.
+func decode(in input, maxdirs int) (*Exif, int, error) {
+	p, ok := in.bytes(0, 4)
+	if !ok {
 		// header too short
-		return nil, ErrCorruptHeader
+		return nil, 0, ErrCorruptHeader
 	}
 
 	var bo binary.ByteOrder
@@ -44,45 +107,55 @@ func DecodeBytes(p []byte) (*Exif, error) {
 		bo = binary.LittleEndian
 	default:
 		// invalid byte order
-		return nil, ErrCorruptHeader
+		return nil, 0, ErrCorruptHeader
 	}
 
 	if bo.Uint16(p[2:]) != 42 {
 		// invalid IFD tag
-		return nil, ErrCorruptHeader
+		return nil, 0, ErrCorruptHeader
 	}
 
 	// location of IFD0 offset
 	offset := 4
 
 	var h errh
+	h.extent(in.size(), offset+4)
 
 	var d [][]Entry
-	for {
-		if len(p) < offset+4 {
+	for maxdirs < 0 || len(d) < maxdirs {
+		pp, ok := in.bytes(offset, 4)
+		if !ok {
 			// offset points outside Exif
 			if len(d) == 0 {
 				// error in IFD0, nothing useful found
-				return nil, fmt.Errorf("Exif: no room for IFD0 offset at byte %d", offset)
+				return nil, 0, fmt.Errorf("Exif: no room for IFD0 offset at byte %d", offset)
 			}
 			h.warnf("no room for IFD%d offset at byte %d", len(d), offset)
 			break
 		}
-		ptr := int(bo.Uint32(p[offset:]))
+		ptr := int(bo.Uint32(pp))
 		if ptr == 0 {
 			break
 		}
-		if ptr < 0 || len(p) < ptr+2 {
+		if ptr < 0 || ptr > in.size()-2 {
 			// corrupt IFD offset in header
 			if len(d) == 0 {
-				return nil, fmt.Errorf("Exif: invalid IFD0 pointer %d at offset %d", ptr, offset)
+				return nil, 0, fmt.Errorf("Exif: invalid IFD0 pointer %d at offset %d", ptr, offset)
 			}
 			h.warnf("invalid IFD%d pointer %d at offset %d", len(d), ptr, offset)
 			break
 		}
+		if !h.visit(ptr) {
+			h.warnf("IFD%d pointer %d at offset %d creates a loop", len(d), ptr, offset)
+			break
+		}
+		if len(d) == maxIFDs {
+			h.warnf("too many IFDs, ignoring IFD%d and later", len(d))
+			break
+		}
 
 		var dir []Entry
-		dir, offset = h.decodeDir(bo, p, ptr)
+		dir, offset = h.decodeDir(bo, in, ptr)
 		d = append(d, dir)
 	}
 
@@ -112,28 +185,39 @@ func DecodeBytes(p []byte) (*Exif, error) {
 			h.warnf("duplicate sub-IFD Tag %x", t.Tag)
 			continue
 		}
-		if t.Type != TypeLong {
+		if t.Type != TypeLong || len(t.Value) != 4 {
 			h.warnf("invalid sub-IFD type %d in Tag %x", t.Type, t.Tag)
 			continue
 		}
 		ptr := int(bo.Uint32(t.Value))
-		if ptr < 0 || len(p) < ptr+2 {
+		if ptr < 0 || ptr > in.size()-2 {
 			// invalid pointer
 			h.warnf("invalid sub-IFD pointer %d in Tag %x", ptr, t.Tag)
 			continue
 		}
-		subdir, _ := h.decodeDir(bo, p, ptr)
+		if !h.visit(ptr) {
+			h.warnf("duplicate sub-IFD pointer %d in Tag %x", ptr, t.Tag)
+			continue
+		}
+		subdir, _ := h.decodeDir(bo, in, ptr)
 		*psub = subdir
 	}
 
+	if t := dirTag(ifd0, ifd0subIFDs); t != nil {
+		x.SubIFDs = h.decodeSubIFDs(bo, in, t)
+	}
+
 	// Preserve raw thumb data
 	tofs, tlen, ok := getOffsetLen(bo, ifd1, ifd1thumbOffset, ifd1thumbLength)
-	if ok && 0 <= tofs && tofs+tlen <= len(p) {
-		x.Thumb = make([]byte, tlen)
-		copy(x.Thumb, p[tofs:tofs+tlen])
+	if ok {
+		if thumb, ok := in.bytes(tofs, tlen); ok {
+			x.Thumb = make([]byte, tlen)
+			copy(x.Thumb, thumb)
+			h.extent(in.size(), tofs+tlen)
+		}
 	}
 
-	return x, h.Error()
+	return x, h.end, h.Error()
 }
 
 // EncodeBytes encodes Exif data as a byte slice.
@@ -145,7 +229,24 @@ func (x *Exif) EncodeBytes() ([]byte, error) {
 	return x.encodeBytes(nil)
 }
 
+// EncodeTIFF is like EncodeBytes, but the result is not limited to
+// the size of a JPEG segment. It may be used to write Exif data
+// including large thumbnails into a standalone .exif or TIFF file.
+func (x *Exif) EncodeTIFF() ([]byte, error) {
+	return x.encode(nil)
+}
+
+// encodeBytes encodes x after prefix for use in a JPEG segment.
 func (x *Exif) encodeBytes(prefix []byte) ([]byte, error) {
+	res, err := x.encode(prefix)
+	if err == nil && len(res) > 65533 {
+		err = ErrTooLong
+	}
+	return res, err
+}
+
+// encode encodes x after prefix.
+func (x *Exif) encode(prefix []byte) ([]byte, error) {
 	// prepare sub-IFDs
 	subifd := []struct {
 		idx int // within IFD0
@@ -161,7 +262,11 @@ func (x *Exif) encodeBytes(prefix []byte) ([]byte, error) {
 	var ifd0 []Entry
 
 Outer:
-	for i, t := range x.IFD0 {
+	for _, t := range x.IFD0 {
+		if t.Tag == ifd0subIFDs {
+			// recreated below using x.SubIFDs
+			continue
+		}
 		for j := range subifd {
 			sub := &subifd[j]
 			if t.Tag == sub.tag {
@@ -169,7 +274,7 @@ Outer:
 					// skip empty sub-IFD
 					continue Outer
 				}
-				sub.idx = i
+				sub.idx = len(ifd0)
 			}
 		}
 		ifd0 = append(ifd0, t)
@@ -189,6 +294,25 @@ Outer:
 		}
 	}
 
+	// add pointer array for additional sub-IFDs
+	var subIFDs *Entry
+	if n := len(x.SubIFDs); n != 0 {
+		ifd0 = append(ifd0, Entry{
+			Tag:   ifd0subIFDs,
+			Type:  TypeLong,
+			Count: uint32(n),
+			Value: make([]byte, 4*n),
+		})
+		sortDir(ifd0)
+		for j := range subifd {
+			sub := &subifd[j]
+			if sub.idx != -1 {
+				sub.idx = dirTagIndex(ifd0, sub.tag)
+			}
+		}
+		subIFDs = dirTag(ifd0, ifd0subIFDs)
+	}
+
 	bo := x.ByteOrder
 
 	// perpare thumb
@@ -239,6 +363,10 @@ Outer:
 			suboffset += encodedLen(sub.dir)
 		}
 	}
+	for i, d := range x.SubIFDs {
+		bo.PutUint32(subIFDs.Value[4*i:], uint32(suboffset))
+		suboffset += encodedLen(d)
+	}
 
 	// set thumbnail offset
 	if len(thumb) != 0 {
@@ -249,7 +377,8 @@ Outer:
 	}
 	suboffset += len(thumb)
 
-	res := make([]byte, len(prefix)+8+suboffset)
+	// suboffset includes the header
+	res := make([]byte, len(prefix)+suboffset)
 	n := copy(res, prefix)
 	p := res[n:]
 
@@ -284,19 +413,48 @@ Outer:
 			offset, _ = encodeDir(sub.dir, bo, p, offset)
 		}
 	}
+	for _, d := range x.SubIFDs {
+		offset, _ = encodeDir(d, bo, p, offset)
+	}
 
 	// write thumb
 	copy(p[offset:offset+len(thumb)], thumb)
 
-	if len(res) > 65533 {
-		return res, ErrTooLong
-	}
-
 	return res, nil
 }
 
 type errh struct {
 	msg []string
+
+	// offsets of IFDs decoded
+	dirs map[int]bool
+
+	// end of data referenced so far
+	end int
+}
+
+// extent records that data up to end has been referenced
+// in the input of length n.
+func (h *errh) extent(n, end int) {
+	if end > n {
+		end = n
+	}
+	if end > h.end {
+		h.end = end
+	}
+}
+
+// visit records the IFD offset ptr,
+// and reports if it has not been seen before.
+func (h *errh) visit(ptr int) bool {
+	if h.dirs == nil {
+		h.dirs = make(map[int]bool)
+	}
+	if h.dirs[ptr] {
+		return false
+	}
+	h.dirs[ptr] = true
+	return true
 }
 
 func (h *errh) warnf(format string, arg ...interface{}) {
@@ -310,31 +468,48 @@ func (h *errh) Error() error {
 	return FormatError(h.msg)
 }
 
-func (h *errh) decodeDir(bo binary.ByteOrder, p []byte, offset int) ([]Entry, int) {
-	ntags := int(bo.Uint16(p[offset:]))
+func (h *errh) decodeDir(bo binary.ByteOrder, in input, offset int) ([]Entry, int) {
+	p, ok := in.bytes(offset, 2)
+	if !ok {
+		h.warnf("IFD at offset %d unreadable", offset)
+		return nil, offset + 2
+	}
+	ntags := int(bo.Uint16(p))
 	offset += 2
 
 	const bytesPerTag = 12
 	end := offset + ntags*bytesPerTag
 
-	ntagsPossible := (len(p) - offset) / bytesPerTag
+	ntagsPossible := (in.size() - offset) / bytesPerTag
 	if ntags > ntagsPossible {
 		h.warnf("IFD has %d tags but input has room only for %d", ntags, ntagsPossible)
 		ntags = ntagsPossible
 	}
 
+	// tags and the offset of the next IFD
+	h.extent(in.size(), offset+ntags*bytesPerTag+4)
+
+	p, ok = in.bytes(offset, ntags*bytesPerTag)
+	if !ok {
+		h.warnf("IFD at offset %d unreadable", offset-2)
+		return nil, end
+	}
+
 	var tags []Entry
 	for i := 0; i < ntags; i++ {
 		// decode entry header
-		tag := bo.Uint16(p[offset:])
-		typ := bo.Uint16(p[offset+2:])
-		count := bo.Uint32(p[offset+4:])
-		valuebits := p[offset+8 : offset+12]
-		offset += 12
+		q := p[i*bytesPerTag:]
+		tag := bo.Uint16(q)
+		typ := bo.Uint16(q[2:])
+		count := bo.Uint32(q[4:])
+		valuebits := q[8:12]
 
 		nbytes := typeSize(typ, count)
 
 		switch {
+		case count == 0:
+			// legitimately empty value, ignore its offset
+			valuebits = nil
 		case nbytes <= 0:
 			// leave corrupt entry alone
 		case nbytes <= 4:
@@ -345,11 +520,13 @@ func (h *errh) decodeDir(bo binary.ByteOrder, p []byte, offset int) ([]Entry, in
 			// of the tiff header (EXIF 2.2 §4.6.2).
 			n := int(nbytes)
 			valueoffset := int(bo.Uint32(valuebits))
-			if valueoffset < 0 || len(p) < valueoffset+n {
+			v, ok := in.bytes(valueoffset, n)
+			if !ok {
 				h.warnf("corrupt offset %d for Tag %x", valueoffset, tag)
 				continue
 			}
-			valuebits = p[valueoffset : valueoffset+n]
+			valuebits = v
+			h.extent(in.size(), valueoffset+n)
 		}
 
 		// make a copy of the value for the tag
@@ -371,6 +548,77 @@ func (h *errh) decodeDir(bo binary.ByteOrder, p []byte, offset int) ([]Entry, in
 	return tags, end
 }
 
+// decodeSubIFDs decodes the IFDs pointed to by the SubIFDs tag t.
+func (h *errh) decodeSubIFDs(bo binary.ByteOrder, in input, t *Entry) [][]Entry {
+	if t.Type != TypeLong || uint64(len(t.Value)) != 4*uint64(t.Count) {
+		h.warnf("invalid SubIFDs type %d", t.Type)
+		return nil
+	}
+	var v [][]Entry
+	for i := 0; i < int(t.Count); i++ {
+		ptr := int(bo.Uint32(t.Value[4*i:]))
+		if ptr < 0 || ptr > in.size()-2 {
+			h.warnf("invalid SubIFDs pointer %d at index %d", ptr, i)
+			continue
+		}
+		if !h.visit(ptr) {
+			h.warnf("duplicate SubIFDs pointer %d at index %d", ptr, i)
+			continue
+		}
+		dir, _ := h.decodeDir(bo, in, ptr)
+		v = append(v, dir)
+	}
+	return v
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// input is the data decoded by decode.
+type input interface {
+	// size returns the length of the input.
+	size() int
+
+	// bytes returns n bytes at offset off. It reports false
+	// if they are outside the input or can't be read.
+	bytes(off, n int) ([]byte, bool)
+}
+
+type bytesInput []byte
+
+func (p bytesInput) size() int { return len(p) }
+
+func (p bytesInput) bytes(off, n int) ([]byte, bool) {
+	if off < 0 || n < 0 || off > len(p)-n {
+		return nil, false
+	}
+	return p[off : off+n], true
+}
+
+// readerInput reads the input from r on demand,
+// and records the first read error in err.
+type readerInput struct {
+	r   io.ReaderAt
+	n   int
+	err error
+}
+
+func (in *readerInput) size() int { return in.n }
+
+func (in *readerInput) bytes(off, n int) ([]byte, bool) {
+	if in.err != nil || off < 0 || n < 0 || off > in.n-n {
+		return nil, false
+	}
+	p := make([]byte, n)
+	if m, err := in.r.ReadAt(p, int64(off)); m < n {
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		in.err = err
+		return nil, false
+	}
+	return p, true
+}
+
 func encodedLen(d []Entry) int {
 	// number of tags, tags, next IFD pointer
 	n := 2 + len(d)*12 + 4
@@ -2,10 +2,13 @@ package exif
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/tajtiattila/metadata/exif/exiftag"
 	"github.com/tajtiattila/metadata/testutil"
@@ -42,6 +45,73 @@ func testDecodeBytes(t *testing.T, fn string) {
 	t.Log(sdump(x))
 }
 
+func TestDecodeZeroCount(t *testing.T) {
+	bo := binary.BigEndian
+	const size = 8 + 2 + 2*12 + 4
+	for _, ofs := range []uint32{0, 8, size, size + 1, 1<<32 - 1} {
+		p := make([]byte, size)
+		copy(p, "MM\x00\x2a")
+		bo.PutUint32(p[4:], 8)
+		bo.PutUint16(p[8:], 2)
+
+		// ImageDescription with zero count
+		e := p[10:]
+		bo.PutUint16(e, 0x010e)
+		bo.PutUint16(e[2:], TypeAscii)
+		bo.PutUint32(e[4:], 0)
+		bo.PutUint32(e[8:], ofs)
+
+		// Make
+		e = p[22:]
+		bo.PutUint16(e, 0x010f)
+		bo.PutUint16(e[2:], TypeAscii)
+		bo.PutUint32(e[4:], 4)
+		copy(e[8:], "Foo\x00")
+
+		x, err := DecodeBytes(p)
+		if err != nil {
+			t.Fatalf("offset %d: %v", ofs, err)
+		}
+		if len(x.IFD0) != 2 {
+			t.Fatalf("offset %d: got %d tags, want 2", ofs, len(x.IFD0))
+		}
+		if v := x.IFD0[0].Value; len(v) != 0 {
+			t.Errorf("offset %d: zero count value is %v", ofs, v)
+		}
+		if s, ok := x.Tag(exiftag.Make).Ascii(); s != "Foo" || !ok {
+			t.Errorf("offset %d: Make is %q, %v", ofs, s, ok)
+		}
+
+		if _, err := x.EncodeBytes(); err != nil {
+			t.Errorf("offset %d: EncodeBytes: %v", ofs, err)
+		}
+	}
+}
+
+// FuzzDecodeBytes checks DecodeBytes, and encoding
+// the Exif decoded does not panic on arbitrary input.
+func FuzzDecodeBytes(f *testing.F) {
+	x := New(100, 100)
+	x.SetLatLong(47.5, 19.25)
+	x.SetDateTime(time.Date(2017, 4, 1, 12, 34, 56, 0, time.UTC))
+	x.SubIFDs = [][]Entry{x.IFD0, x.IFD0}
+	p, err := x.EncodeBytes()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(p)
+	f.Add([]byte("II\x2a\x00\x08\x00\x00\x00\x01\x00"))
+
+	f.Fuzz(func(t *testing.T, p []byte) {
+		x, _ := DecodeBytes(p)
+		if x != nil {
+			x.EncodeBytes()
+			x.DateTime()
+			x.LatLong()
+		}
+	})
+}
+
 func TestEncodeBytes(t *testing.T) {
 	for _, n := range testutil.MediaFileNames(t, "image/jpeg") {
 		testEncodeBytes(t, n)
@@ -84,12 +154,202 @@ func testEncodeBytes(t *testing.T, fn string) {
 	testExifEqual(t, x, x2)
 }
 
+func TestDecodeIFDLoop(t *testing.T) {
+	bo := binary.BigEndian
+
+	// ifds returns Exif data with n IFDs having a single tag each,
+	// and the next pointer of the last one set to last.
+	const dirLen = 2 + 12 + 4
+	ifds := func(n int, last uint32) []byte {
+		p := make([]byte, 8+n*dirLen)
+		copy(p, "MM\x00\x2a")
+		bo.PutUint32(p[4:], 8)
+		for i := 0; i < n; i++ {
+			d := p[8+i*dirLen:]
+			bo.PutUint16(d, 1)
+			bo.PutUint16(d[2:], 0x0100) // ImageWidth
+			bo.PutUint16(d[4:], TypeShort)
+			bo.PutUint32(d[6:], 1)
+			bo.PutUint16(d[10:], uint16(i))
+			next := uint32(8 + (i+1)*dirLen)
+			if i == n-1 {
+				next = last
+			}
+			bo.PutUint32(d[14:], next)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name string
+		p    []byte
+	}{
+		{"IFD0 to itself", ifds(1, 8)},
+		{"IFD1 to IFD0", ifds(2, 8)},
+		{"IFD2 to IFD1", ifds(3, 8+dirLen)},
+		{"too many IFDs", ifds(maxIFDs+4, 0)},
+	}
+	for _, tt := range tests {
+		x, err := DecodeBytes(tt.p)
+		if x == nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !IsFormat(err) {
+			t.Errorf("%s: got error %v, want FormatError", tt.name, err)
+		}
+		if len(x.IFD0) != 1 {
+			t.Errorf("%s: IFD0 has %d tags, want 1", tt.name, len(x.IFD0))
+		}
+	}
+}
+
+func TestDecodeBytesN(t *testing.T) {
+	x := New(100, 100)
+	x.Set(exiftag.Make, Ascii("TestMake"))
+	x.SetLatLong(47.5, 19.25)
+	x.replaceThumb(ifd1CompressionJpeg, []byte("\xff\xd8thumbnail\xff\xd9"))
+
+	enc, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := DecodeBytes(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, junk := range []string{"", "\x00\x00\x00\x00", "MM\x00\x2a\x00\x00\x00\x08"} {
+		p := append(append([]byte(nil), enc...), junk...)
+		got, n, err := DecodeBytesN(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(enc) {
+			t.Errorf("with %d bytes of trailing data: got n=%d, want %d", len(junk), n, len(enc))
+		}
+		testExifEqual(t, want, got)
+	}
+}
+
+func TestEncodeTIFF(t *testing.T) {
+	x := New(100, 100)
+	x.Set(exiftag.ImageDescription, Ascii(strings.Repeat("description ", 2000)))
+	thumb := append([]byte("\xff\xd8"), bytes.Repeat([]byte{0x55}, 80<<10)...)
+	x.replaceThumb(ifd1CompressionJpeg, thumb)
+
+	if _, err := x.EncodeBytes(); err != ErrTooLong {
+		t.Errorf("EncodeBytes returned %v, want %v", err, ErrTooLong)
+	}
+
+	p, err := x.EncodeTIFF()
+	if err != nil {
+		t.Fatal("EncodeTIFF:", err)
+	}
+	if len(p) <= 64<<10 {
+		t.Fatalf("encoded length is only %d bytes", len(p))
+	}
+
+	y, err := DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if !bytes.Equal(y.Thumb, thumb) {
+		t.Error("thumbnail differs")
+	}
+	want, _ := x.Tag(exiftag.ImageDescription).Ascii()
+	if got, _ := y.Tag(exiftag.ImageDescription).Ascii(); got != want {
+		t.Errorf("ImageDescription has %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestDecodeTIFFAt(t *testing.T) {
+	x := New(100, 100)
+	x.Set(exiftag.ImageDescription, Ascii("description"))
+	x.SetLatLong(47.5, 19.25)
+	p, err := x.EncodeTIFF()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := DecodeTIFF(p)
+	if err != nil {
+		t.Fatal("DecodeTIFF:", err)
+	}
+	got, err := DecodeTIFFAt(bytes.NewReader(p), int64(len(p)))
+	if err != nil {
+		t.Fatal("DecodeTIFFAt:", err)
+	}
+	if sdump(got) != sdump(want) {
+		t.Errorf("DecodeTIFFAt got:\n%s\nwant:\n%s", sdump(got), sdump(want))
+	}
+
+	// size larger than the data available
+	r := io.NewSectionReader(bytes.NewReader(p), 0, int64(len(p))-4)
+	if _, err := DecodeTIFFAt(r, int64(len(p))); err != io.EOF {
+		t.Errorf("DecodeTIFFAt with short reader returned %v, want %v", err, io.EOF)
+	}
+}
+
+func TestSubIFDs(t *testing.T) {
+	for n := 1; n <= 3; n++ {
+		x := New(100, 100)
+		x.Set(exiftag.Make, Ascii("TestMake"))
+		for i := 0; i < n; i++ {
+			var d []Entry
+			ensureTag(&d, 0x100).SetValue(x.ByteOrder, Long{uint32(1000 * (i + 1))})
+			ensureTag(&d, 0x101).SetValue(x.ByteOrder, Long{uint32(500 * (i + 1))})
+			ensureTag(&d, 0x10e).SetValue(x.ByteOrder, Ascii(fmt.Sprintf("subifd %d", i)))
+			x.SubIFDs = append(x.SubIFDs, d)
+		}
+
+		enc, err := x.EncodeBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		x2, err := DecodeBytes(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := dirTag(x2.IFD0, ifd0subIFDs); e == nil || e.Count != uint32(n) {
+			t.Errorf("SubIFDs tag invalid: %+v", e)
+		}
+		if len(x2.SubIFDs) != n {
+			t.Fatalf("got %d SubIFDs, want %d", len(x2.SubIFDs), n)
+		}
+		for i := range x.SubIFDs {
+			testDirEqual(t, fmt.Sprintf("SubIFD%d", i), x.SubIFDs[i], x2.SubIFDs[i])
+		}
+
+		// encode again to check pointers are recreated
+		enc2, err := x2.EncodeBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		x3, err := DecodeBytes(enc2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testExifEqual(t, x2, x3)
+	}
+}
+
 func testExifEqual(t *testing.T, a, b *Exif) {
 	testDirEqual(t, "IFD0", a.IFD0, b.IFD0)
 	testDirEqual(t, "Exif", a.Exif, b.Exif)
 	testDirEqual(t, "GPS", a.GPS, b.GPS)
 	testDirEqual(t, "Interop", a.Interop, b.Interop)
 
+	if len(a.SubIFDs) != len(b.SubIFDs) {
+		t.Errorf("SubIFDs length differ: %d != %d\n", len(a.SubIFDs), len(b.SubIFDs))
+	} else {
+		for i := range a.SubIFDs {
+			testDirEqual(t, fmt.Sprintf("SubIFD%d", i), a.SubIFDs[i], b.SubIFDs[i])
+		}
+	}
+
 	// check thumb IFD only if there is a thumb
 	if len(a.Thumb) != 0 && len(b.Thumb) != 0 {
 		testDirEqual(t, "IFD1", a.IFD1, b.IFD1)
@@ -110,7 +370,8 @@ func testDirEqual(t *testing.T, name string, a, b []Entry) {
 		switch ta.Tag {
 		case ifd0exifSub,
 			ifd0gpsSub,
-			ifd0interopSub:
+			ifd0interopSub,
+			ifd0subIFDs:
 			continue
 		}
 		if !bytes.Equal(ta.Value, tb.Value) {
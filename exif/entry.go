@@ -87,10 +87,10 @@ func fieldOfs(bo binary.ByteOrder, e *Entry) (value int, ok bool) {
 	if e == nil {
 		return
 	}
-	switch e.Type {
-	case TypeShort:
+	switch {
+	case e.Type == TypeShort && len(e.Value) >= 2:
 		return int(bo.Uint16(e.Value)), true
-	case TypeLong:
+	case e.Type == TypeLong && len(e.Value) >= 4:
 		return int(bo.Uint32(e.Value)), true
 	}
 	return 0, false
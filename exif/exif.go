@@ -14,6 +14,8 @@ var (
 	NotFound  = errors.New("exif: exif data not found")
 	ErrDecode = errors.New("exif: jpeg decode error")
 	ErrEncode = errors.New("exif: jpeg encode error")
+
+	ErrOrientation = errors.New("exif: invalid orientation")
 )
 
 // Exif represents Exif format metadata in JPEG/Exif files.
@@ -27,6 +29,11 @@ type Exif struct {
 	// Main image sub-IFDs
 	Exif, GPS, Interop []Entry
 
+	// SubIFDs are additional IFDs listed in the SubIFDs (0x014a) tag
+	// of IFD0 in the order of appearance, such as
+	// full resolution or preview images in raw files.
+	SubIFDs [][]Entry
+
 	// thumbnail
 	IFD1  []Entry // Metadata
 	Thumb []byte  // Raw image data, typically JPEG
@@ -108,10 +115,10 @@ func Copy(w io.Writer, r io.Reader, x *Exif) error {
 
 		switch {
 		case jfifChunk == nil && cmpChunkHeader(seg, jfifChunkHeader):
-			jfifChunkHeader = seg
+			jfifChunk = seg
 			has++
 		case jfxxChunk == nil && cmpChunkHeader(seg, jfxxChunkHeader):
-			jfxxChunkHeader = seg
+			jfxxChunk = seg
 			has++
 		case !hasExif && cmpChunkHeader(seg, exifChunkHeader):
 			hasExif = true
@@ -152,13 +159,46 @@ func Copy(w io.Writer, r io.Reader, x *Exif) error {
 	return err
 }
 
+// CopyWithThumbnail is like Copy, but replaces the thumbnail in x
+// with thumb, that must be a JPEG image. It is useful to update
+// the thumbnail after the image in r has been edited.
+//
+// The offset and length tags of the thumbnail are updated,
+// x itself is not modified. ErrTooLong is returned if the
+// Exif with thumb would not fit in the APP1 segment.
+func CopyWithThumbnail(w io.Writer, r io.Reader, x *Exif, thumb []byte) error {
+	if len(thumb) < 2 || thumb[0] != 0xff || thumb[1] != 0xd8 {
+		return xjpeg.ErrNotJpeg
+	}
+
+	nx := *x
+	nx.replaceThumb(ifd1CompressionJpeg, thumb)
+	return Copy(w, r, &nx)
+}
+
+// Transplant copies the JPEG image in dstJpeg to dst,
+// replacing its Exif metadata with the one in srcJpeg.
+//
+// Exif metadata originally in dstJpeg is discarded,
+// other content is written to dst unmodified.
+// NotFound is returned if srcJpeg has no Exif metadata.
+func Transplant(dst io.Writer, dstJpeg, srcJpeg io.Reader) error {
+	x, err := Decode(srcJpeg)
+	if x == nil {
+		return err
+	}
+	return Copy(dst, dstJpeg, x)
+}
+
 type errw struct {
 	w   io.Writer
 	err error
 }
 
 func (w *errw) write(p []byte) {
-	_, w.err = w.w.Write(p)
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
 }
 
 // gets raw exif as []byte
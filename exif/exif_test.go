@@ -3,13 +3,15 @@ package exif
 import (
 	"bytes"
 	"image"
-	_ "image/jpeg"
+	"image/jpeg"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/tajtiattila/metadata/exif/exiftag"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
 	"github.com/tajtiattila/metadata/testutil"
 )
 
@@ -70,3 +72,166 @@ func testCopy(t *testing.T, fn string) {
 		t.Errorf("%s write destination error: %v", fn, err)
 	}
 }
+
+func TestTransplant(t *testing.T) {
+	src := testJpeg(t, 16, 16, "Source")
+	dst := testJpeg(t, 32, 24, "Destination")
+
+	// add JFIF segment after SOI to dst
+	jfif := new(bytes.Buffer)
+	jfif.Write(dst[:2])
+	if err := xjpeg.WriteChunk(jfif, 0xe0, []byte("JFIF\x00\x01\x02\x00\x00\x01\x00\x01\x00\x00")); err != nil {
+		t.Fatal(err)
+	}
+	jfifSeg := append([]byte(nil), jfif.Bytes()[2:]...)
+	jfif.Write(dst[2:])
+	dst = jfif.Bytes()
+
+	out := new(bytes.Buffer)
+	if err := Transplant(out, bytes.NewReader(dst), bytes.NewReader(src)); err != nil {
+		t.Fatal("Transplant:", err)
+	}
+
+	if !bytes.HasPrefix(out.Bytes()[2:], jfifSeg) {
+		t.Error("JFIF segment not kept after SOI")
+	}
+
+	x, err := Decode(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal("Decode:", err)
+	}
+	if mk, _ := x.Tag(exiftag.Make).Ascii(); mk != "Source" {
+		t.Errorf("Make is %q, want %q", mk, "Source")
+	}
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal("DecodeConfig:", err)
+	}
+	if cfg.Width != 32 || cfg.Height != 24 {
+		t.Errorf("image size is %dx%d, want 32x24", cfg.Width, cfg.Height)
+	}
+
+	noExif := new(bytes.Buffer)
+	if err := jpeg.Encode(noExif, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	err = Transplant(ioutil.Discard, bytes.NewReader(dst), bytes.NewReader(noExif.Bytes()))
+	if err != NotFound {
+		t.Errorf("Transplant from jpeg without exif returned %v, want %v", err, NotFound)
+	}
+}
+
+func TestCopyWithThumbnail(t *testing.T) {
+	src := testJpeg(t, 64, 48, "Camera")
+	x, err := Decode(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal("Decode:", err)
+	}
+	if err := x.SetThumbImage(image.NewGray(image.Rect(0, 0, 8, 6))); err != nil {
+		t.Fatal("SetThumbImage:", err)
+	}
+	oldThumb := x.Thumb
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, 16, 12)), nil); err != nil {
+		t.Fatal(err)
+	}
+	thumb := buf.Bytes()
+
+	out := new(bytes.Buffer)
+	if err := CopyWithThumbnail(out, bytes.NewReader(src), x, thumb); err != nil {
+		t.Fatal("CopyWithThumbnail:", err)
+	}
+	if !bytes.Equal(x.Thumb, oldThumb) {
+		t.Error("CopyWithThumbnail modified x")
+	}
+
+	y, err := Decode(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal("Decode:", err)
+	}
+	if !bytes.Equal(y.Thumb, thumb) {
+		t.Error("thumbnail not replaced")
+	}
+	im, _, err := y.ThumbImage()
+	if err != nil {
+		t.Fatal("ThumbImage:", err)
+	}
+	if b := im.Bounds(); b.Dx() != 16 || b.Dy() != 12 {
+		t.Errorf("thumbnail size is %v, want 16x12", b.Size())
+	}
+	if s, _ := y.Tag(exiftag.Make).Ascii(); s != "Camera" {
+		t.Errorf("Make is %q, want %q", s, "Camera")
+	}
+
+	err = CopyWithThumbnail(ioutil.Discard, bytes.NewReader(src), x, []byte("not a jpeg"))
+	if err != xjpeg.ErrNotJpeg {
+		t.Errorf("non-JPEG thumbnail: got error %v, want %v", err, xjpeg.ErrNotJpeg)
+	}
+
+	big := append([]byte{0xff, 0xd8}, make([]byte, 70<<10)...)
+	err = CopyWithThumbnail(ioutil.Discard, bytes.NewReader(src), x, big)
+	if err != ErrTooLong {
+		t.Errorf("large thumbnail: got error %v, want %v", err, ErrTooLong)
+	}
+}
+
+func TestExtractThumbnail(t *testing.T) {
+	src := testJpeg(t, 64, 48, "Camera")
+	if _, err := ExtractThumbnail(bytes.NewReader(src)); err != NotFound {
+		t.Errorf("no thumbnail: got error %v, want %v", err, NotFound)
+	}
+
+	x, err := Decode(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal("Decode:", err)
+	}
+	if err := x.SetThumbImage(image.NewGray(image.Rect(0, 0, 16, 12))); err != nil {
+		t.Fatal("SetThumbImage:", err)
+	}
+	withThumb := new(bytes.Buffer)
+	if err := Copy(withThumb, bytes.NewReader(src), x); err != nil {
+		t.Fatal("Copy:", err)
+	}
+
+	p, err := ExtractThumbnail(bytes.NewReader(withThumb.Bytes()))
+	if err != nil {
+		t.Fatal("ExtractThumbnail:", err)
+	}
+	im, err := jpeg.Decode(bytes.NewReader(p))
+	if err != nil {
+		t.Fatal("thumbnail decode:", err)
+	}
+	if b := im.Bounds(); b.Dx() != 16 || b.Dy() != 12 {
+		t.Errorf("thumbnail size is %v, want 16x12", b.Size())
+	}
+
+	// uncompressed thumbnail
+	x.replaceThumb(1, make([]byte, 16*12*3))
+	uncompressed := new(bytes.Buffer)
+	if err := Copy(uncompressed, bytes.NewReader(src), x); err != nil {
+		t.Fatal("Copy:", err)
+	}
+	if _, err := ExtractThumbnail(bytes.NewReader(uncompressed.Bytes())); err != ErrThumbnailFormat {
+		t.Errorf("uncompressed thumbnail: got error %v, want %v", err, ErrThumbnailFormat)
+	}
+}
+
+// testJpeg returns a jpeg image with dimensions dx, dy
+// with Exif having camera as the Make tag.
+func testJpeg(t *testing.T, dx, dy int, camera string) []byte {
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, dx, dy)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	x := New(dx, dy)
+	x.Set(exiftag.Make, Ascii(camera))
+
+	out := new(bytes.Buffer)
+	if err := Copy(out, buf, x); err != nil {
+		t.Fatal("Copy:", err)
+	}
+	return out.Bytes()
+}
@@ -138,6 +138,15 @@ const (
 	// DateTimeDigitized subseconds - ASCII (Any)
 	SubSecTimeDigitized = Exif | 0x9292
 
+	// Offset data of DateTime - ASCII (7)
+	OffsetTime = Exif | 0x9010
+
+	// Offset data of DateTimeOriginal - ASCII (7)
+	OffsetTimeOriginal = Exif | 0x9011
+
+	// Offset data of DateTimeDigitized - ASCII (7)
+	OffsetTimeDigitized = Exif | 0x9012
+
 	// Exposure time - RATIONAL (1)
 	ExposureTime = Exif | 0x829a
 
@@ -353,6 +362,12 @@ const (
 
 	// GPS differential correction - SHORT (1)
 	GPSDifferential = GPS | 0x001e
+
+	// Interoperability identification - ASCII (Any)
+	InteroperabilityIndex = Interop | 0x0001
+
+	// Interoperability version - UNDEFINED (4)
+	InteroperabilityVersion = Interop | 0x0002
 )
 
 var nameMap = map[uint32]name{
@@ -401,6 +416,9 @@ var nameMap = map[uint32]name{
 	SubSecTime:                  {"SubSecTime", "DateTime subseconds"},
 	SubSecTimeOriginal:          {"SubSecTimeOriginal", "DateTimeOriginal subseconds"},
 	SubSecTimeDigitized:         {"SubSecTimeDigitized", "DateTimeDigitized subseconds"},
+	OffsetTime:                  {"OffsetTime", "Offset data of DateTime"},
+	OffsetTimeOriginal:          {"OffsetTimeOriginal", "Offset data of DateTimeOriginal"},
+	OffsetTimeDigitized:         {"OffsetTimeDigitized", "Offset data of DateTimeDigitized"},
 	ExposureTime:                {"ExposureTime", "Exposure time"},
 	FNumber:                     {"FNumber", "F number"},
 	ExposureProgram:             {"ExposureProgram", "Exposure program"},
@@ -473,4 +491,6 @@ var nameMap = map[uint32]name{
 	GPSAreaInformation:          {"GPSAreaInformation", "Name of GPS area"},
 	GPSDateStamp:                {"GPSDateStamp", "GPS date"},
 	GPSDifferential:             {"GPSDifferential", "GPS differential correction"},
+	InteroperabilityIndex:       {"InteroperabilityIndex", "Interoperability identification"},
+	InteroperabilityVersion:     {"InteroperabilityVersion", "Interoperability version"},
 }
@@ -5,6 +5,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tajtiattila/metadata/exif/exiftag"
 )
 
 type Formatter struct {
@@ -153,3 +157,59 @@ func fmtType(typ uint16, count uint32) string {
 	}
 	return fmt.Sprintf("%d%s", count, n)
 }
+
+// String returns a one-line summary of x for logging, such as
+//
+//	Canon EOS 80D / 2023-05-01 12:34:56 / ISO100 f2.8 1/200 / GPS 51.5,-0.1
+//
+// Parts are the camera, the date of the image, the exposure
+// and the GPS location. Fields missing from x are omitted.
+func (x *Exif) String() string {
+	var parts []string
+
+	mk, _ := x.Tag(exiftag.Make).Ascii()
+	model, _ := x.Tag(exiftag.Model).Ascii()
+	mk, model = strings.TrimSpace(mk), strings.TrimSpace(model)
+	if strings.HasPrefix(model, mk) {
+		// model often includes the make
+		mk = ""
+	}
+	if cam := strings.TrimSpace(mk + " " + model); cam != "" {
+		parts = append(parts, cam)
+	}
+
+	if t, ok := x.DateTime(); ok {
+		parts = append(parts, t.Format("2006-01-02 15:04:05"))
+	}
+
+	var exp []string
+	if iso := x.Tag(exiftag.ISOSpeedRatings).Short(); len(iso) != 0 {
+		exp = append(exp, fmt.Sprintf("ISO%d", iso[0]))
+	}
+	if f, ok := x.Tag(exiftag.FNumber).Rational().Float64(0); ok {
+		exp = append(exp, "f"+fmtFloat(f))
+	}
+	if e := x.Tag(exiftag.ExposureTime).Rational(); len(e) == 2 && e[0] != 0 && e[1] != 0 {
+		if e[0] < e[1] {
+			exp = append(exp, "1/"+fmtFloat(float64(e[1])/float64(e[0])))
+		} else {
+			exp = append(exp, fmtFloat(float64(e[0])/float64(e[1]))+"s")
+		}
+	}
+	if len(exp) != 0 {
+		parts = append(parts, strings.Join(exp, " "))
+	}
+
+	if lat, long, ok := x.LatLong(); ok {
+		parts = append(parts, "GPS "+fmtFloat(lat)+","+fmtFloat(long))
+	}
+
+	return strings.Join(parts, " / ")
+}
+
+// fmtFloat formats f with at most 6 decimals and no exponent.
+func fmtFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 6, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,97 @@
+package exif
+
+import "encoding/binary"
+
+// Merge copies tags from other into x.
+//
+// Tags of other are added to the corresponding directory of x,
+// unless x already has the tag with a non-empty value.
+// SubIFDs are merged by position, and ones missing from x
+// are appended to it.
+//
+// The thumbnail (IFD1 and Thumb) is treated as a unit:
+// if x has no thumbnail then the one in other is used.
+//
+// Values are converted to the ByteOrder of x if necessary.
+func (x *Exif) Merge(other *Exif) {
+	if x.ByteOrder == nil {
+		x.ByteOrder = other.ByteOrder
+	}
+	m := merger{from: other.ByteOrder, to: x.ByteOrder}
+
+	m.dir(&x.IFD0, other.IFD0)
+	m.dir(&x.Exif, other.Exif)
+	m.dir(&x.GPS, other.GPS)
+	m.dir(&x.Interop, other.Interop)
+
+	for i, sub := range other.SubIFDs {
+		if i < len(x.SubIFDs) {
+			m.dir(&x.SubIFDs[i], sub)
+		} else {
+			var d []Entry
+			m.dir(&d, sub)
+			x.SubIFDs = append(x.SubIFDs, d)
+		}
+	}
+
+	switch {
+	case len(x.Thumb) != 0:
+		// keep thumbnail of x
+	case len(other.Thumb) != 0:
+		x.IFD1 = nil
+		m.dir(&x.IFD1, other.IFD1)
+		x.Thumb = append([]byte(nil), other.Thumb...)
+	default:
+		m.dir(&x.IFD1, other.IFD1)
+	}
+}
+
+type merger struct {
+	from, to binary.ByteOrder
+}
+
+// dir merges entries of src into d.
+func (m merger) dir(d *[]Entry, src []Entry) {
+	sortDir(*d)
+	for _, e := range src {
+		switch e.Tag {
+		case ifd0exifSub, ifd0gpsSub, ifd0interopSub, ifd0subIFDs:
+			// pointers are recreated by Encode
+			continue
+		}
+		if t := dirTag(*d, e.Tag); t != nil && len(t.Value) != 0 {
+			continue
+		}
+		t := ensureTag(d, e.Tag)
+		t.Type = e.Type
+		t.Count = e.Count
+		t.Value = m.value(e)
+	}
+}
+
+// value returns a copy of the value of e in the target byte order.
+func (m merger) value(e Entry) []byte {
+	p := append([]byte(nil), e.Value...)
+	if m.from == nil || m.from == m.to {
+		return p
+	}
+
+	var n int
+	switch e.Type {
+	case TypeShort, TypeSShort:
+		n = 2
+	case TypeLong, TypeSLong, TypeFloat, TypeRational, TypeSRational:
+		n = 4
+	case TypeDouble:
+		n = 8
+	default:
+		return p
+	}
+	for i := 0; i+n <= len(p); i += n {
+		v := p[i : i+n]
+		for j, k := 0, n-1; j < k; j, k = j+1, k-1 {
+			v[j], v[k] = v[k], v[j]
+		}
+	}
+	return p
+}
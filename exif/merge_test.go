@@ -0,0 +1,115 @@
+package exif_test
+
+import (
+	"encoding/binary"
+	"sort"
+	"testing"
+
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+)
+
+func TestMerge(t *testing.T) {
+	x := exif.New(100, 100)
+	x.Set(exiftag.Make, exif.Ascii("Receiver"))
+	x.IFD0 = append(x.IFD0, exif.Entry{Tag: uint16(exiftag.Artist), Type: exif.TypeAscii})
+
+	other := exif.New(200, 200)
+	other.ByteOrder = binary.LittleEndian
+	other.Set(exiftag.Make, exif.Ascii("Other"))
+	other.Set(exiftag.Model, exif.Ascii("Model"))
+	other.Set(exiftag.Artist, exif.Ascii("Artist"))
+	other.Set(exiftag.ImageWidth, exif.Long{4000})
+	other.SetLatLong(47.5, 19.25)
+
+	x.Merge(other)
+
+	ascii := []struct {
+		tag  uint32
+		want string
+	}{
+		{exiftag.Make, "Receiver"},
+		{exiftag.Model, "Model"},
+		{exiftag.Artist, "Artist"},
+	}
+	for _, tt := range ascii {
+		got, _ := x.Tag(tt.tag).Ascii()
+		if got != tt.want {
+			t.Errorf("tag %s: got %q, want %q", exiftag.Id(tt.tag), got, tt.want)
+		}
+	}
+
+	if got := x.Tag(exiftag.PixelXDimension).Long(); len(got) != 1 || got[0] != 100 {
+		t.Errorf("PixelXDimension is %v, want [100]", got)
+	}
+	if got := x.Tag(exiftag.ImageWidth).Long(); len(got) != 1 || got[0] != 4000 {
+		t.Errorf("ImageWidth is %v, want [4000]", got)
+	}
+	if lat, long, ok := x.LatLong(); !ok || lat != 47.5 || long != 19.25 {
+		t.Errorf("LatLong is %v, %v, %v; want 47.5, 19.25, true", lat, long, ok)
+	}
+
+	for _, d := range [][]exif.Entry{x.IFD0, x.Exif, x.GPS} {
+		if !sort.SliceIsSorted(d, func(i, j int) bool { return d[i].Tag < d[j].Tag }) {
+			t.Errorf("dir not sorted: %v", d)
+		}
+	}
+
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	if _, err := exif.DecodeBytes(p); err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+}
+
+func TestMergeSubIFDs(t *testing.T) {
+	bo := binary.BigEndian
+	ent := func(tag uint16, v uint32) exif.Entry {
+		e := exif.Entry{Tag: tag}
+		e.SetValue(bo, exif.Long{v})
+		return e
+	}
+
+	x := &exif.Exif{ByteOrder: bo}
+	x.SubIFDs = [][]exif.Entry{
+		{ent(0x100, 1)},
+	}
+
+	other := &exif.Exif{ByteOrder: bo}
+	other.SubIFDs = [][]exif.Entry{
+		{ent(0x100, 2), ent(0x101, 2)},
+		{ent(0x100, 3)},
+	}
+
+	x.Merge(other)
+
+	want := [][]exif.Entry{
+		{ent(0x100, 1), ent(0x101, 2)},
+		{ent(0x100, 3)},
+	}
+	if len(x.SubIFDs) != len(want) {
+		t.Fatalf("got %d sub-IFDs, want %d", len(x.SubIFDs), len(want))
+	}
+	for i := range want {
+		got := x.SubIFDs[i]
+		if len(got) != len(want[i]) {
+			t.Errorf("sub-IFD %d: got %d entries, want %d", i, len(got), len(want[i]))
+			continue
+		}
+		for j := range got {
+			g, w := got[j], want[i][j]
+			if g.Tag != w.Tag || bo.Uint32(g.Value) != bo.Uint32(w.Value) {
+				t.Errorf("sub-IFD %d entry %d: got %#x=%d, want %#x=%d",
+					i, j, g.Tag, bo.Uint32(g.Value), w.Tag, bo.Uint32(w.Value))
+			}
+		}
+	}
+
+	// values must not be shared
+	other.SubIFDs[1][0].Value[3] = 42
+	if v := bo.Uint32(x.SubIFDs[1][0].Value); v != 3 {
+		t.Errorf("merged value changed to %d", v)
+	}
+}
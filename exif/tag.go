@@ -11,6 +11,7 @@ import (
 var (
 	ErrMissingDir = errors.New("exif: missing IFD dir")
 	ErrMissingTag = errors.New("exif: tag missing from dir")
+	ErrByteOrder  = errors.New("exif: invalid byte order")
 )
 
 // Tag returns the Tag t.
@@ -45,6 +46,68 @@ func (x *Exif) Set(t uint32, v Value) {
 	}
 }
 
+// Prune removes the empty Exif, GPS and Interop directories and
+// SubIFDs from x, along with their pointer tags in IFD0, so that
+// no dangling pointers remain after removing tags using Set.
+func (x *Exif) Prune() {
+	for _, sub := range []struct {
+		tag uint16
+		dir *[]Entry
+	}{
+		{ifd0exifSub, &x.Exif},
+		{ifd0gpsSub, &x.GPS},
+		{ifd0interopSub, &x.Interop},
+	} {
+		if len(*sub.dir) == 0 {
+			*sub.dir = nil
+			removeTag(&x.IFD0, sub.tag)
+		}
+	}
+
+	var subIFDs [][]Entry
+	for _, d := range x.SubIFDs {
+		if len(d) != 0 {
+			subIFDs = append(subIFDs, d)
+		}
+	}
+	x.SubIFDs = subIFDs
+	if len(x.SubIFDs) == 0 {
+		removeTag(&x.IFD0, ifd0subIFDs)
+	}
+}
+
+// DeleteAllGPS removes the GPS directory from x along with its
+// pointer tag in IFD0, therefore all GPS tags including the
+// location, altitude and time of the GPS fix are removed.
+func (x *Exif) DeleteAllGPS() {
+	x.GPS = nil
+	removeTag(&x.IFD0, ifd0gpsSub)
+}
+
+// SetByteOrder sets the byte order of x used by EncodeBytes,
+// converting the values in x from the current byte order.
+//
+// Decoded Exif keeps the byte order of the source,
+// so SetByteOrder is only needed to change it.
+// It returns ErrByteOrder unless bo is
+// binary.BigEndian or binary.LittleEndian.
+func (x *Exif) SetByteOrder(bo binary.ByteOrder) error {
+	if bo != binary.BigEndian && bo != binary.LittleEndian {
+		return ErrByteOrder
+	}
+
+	m := merger{from: x.ByteOrder, to: bo}
+	dirs := [][]Entry{x.IFD0, x.IFD1, x.Exif, x.GPS, x.Interop}
+	dirs = append(dirs, x.SubIFDs...)
+	for _, d := range dirs {
+		for i := range d {
+			d[i].Value = m.value(d[i])
+		}
+	}
+	x.ByteOrder = bo
+	return nil
+}
+
 func (x *Exif) dirp(name uint32) *[]Entry {
 	switch name & exiftag.DirMask {
 	case exiftag.Tiff:
@@ -166,11 +229,11 @@ func (t *Tag) Rational() Rational {
 // SRational returns the value of t as an slice of signed rational
 // numerator/denominator values.
 // If t is invalid or is not TypeSRational, nil is returned.
-func (t *Tag) SRational() (numdenom []int32) {
+func (t *Tag) SRational() SRational {
 	if !t.IsType(TypeSRational) {
 		return nil
 	}
-	numdenom = make([]int32, 2*t.E.Count)
+	numdenom := make(SRational, 2*t.E.Count)
 	for i := range numdenom {
 		numdenom[i] = int32(t.ByteOrder.Uint32(t.E.Value[4*i:]))
 	}
@@ -188,10 +251,16 @@ func (t *Tag) Undef() []byte {
 
 // Ascii returns the value of t as string.
 // If t is invalid or is not TypeAscii, ok == false is returned.
+//
+// The terminating NUL is removed. Values written without
+// the terminating NUL are returned in full.
 func (t *Tag) Ascii() (s string, ok bool) {
 	if !t.IsType(TypeAscii) {
 		return
 	}
-	// strip NUL
-	return string(t.E.Value[:t.E.Count-1]), true
+	p := t.E.Value[:t.E.Count]
+	if p[len(p)-1] == 0 {
+		p = p[:len(p)-1]
+	}
+	return string(p), true
 }
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata/exif/exiftag"
 )
 
 func TestTagGetters(t *testing.T) {
@@ -32,6 +35,15 @@ func TestTagGetters(t *testing.T) {
 		}
 	})
 
+	// missing NUL terminator
+	testTagGetter(t, TypeAscii, 5, []byte("hello"), []byte("hello"), func(tag *Tag) {
+		have, ok := tag.Ascii()
+		want := "hello"
+		if !ok || have != want {
+			t.Errorf("TestTagGetters %s without NUL got %v, want %v", typeStr(TypeAscii), have, want)
+		}
+	})
+
 	testTagGetter(t, TypeShort, 1, []byte{1, 2}, []byte{2, 1}, func(tag *Tag) {
 		have := tag.Short()
 		want := []uint16{0x102}
@@ -142,3 +154,143 @@ func typeStr(typ uint16) string {
 	}
 	return "«invalid»"
 }
+
+func TestPrune(t *testing.T) {
+	x := New(100, 100)
+	x.SetLatLong(47.5, 19.25)
+	x.SubIFDs = [][]Entry{nil}
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	x, err = DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if dirTag(x.IFD0, ifd0gpsSub) == nil {
+		t.Fatal("GPS pointer missing from decoded IFD0")
+	}
+
+	for len(x.GPS) != 0 {
+		x.Set(exiftag.GPS|uint32(x.GPS[0].Tag), nil)
+	}
+	x.Prune()
+
+	if dirTag(x.IFD0, ifd0gpsSub) != nil {
+		t.Error("GPS pointer not removed")
+	}
+	if dirTag(x.IFD0, ifd0exifSub) == nil {
+		t.Error("Exif pointer removed")
+	}
+	if dirTag(x.IFD0, ifd0subIFDs) != nil || x.SubIFDs != nil {
+		t.Error("empty SubIFDs not removed")
+	}
+
+	p, err = x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	x, err = DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if x.GPS != nil || dirTag(x.IFD0, ifd0gpsSub) != nil {
+		t.Error("GPS survived encoding")
+	}
+}
+
+func TestByteOrder(t *testing.T) {
+	x := New(100, 200)
+	if err := x.SetByteOrder(binary.LittleEndian); err != nil {
+		t.Fatal("SetByteOrder:", err)
+	}
+	x.Set(exiftag.Make, Ascii("Make"))
+	x.SetLatLong(47.5, 19.25)
+
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	if string(p[:2]) != "II" {
+		t.Fatalf("encoded byte order is %q, want II", p[:2])
+	}
+
+	// re-encoding keeps the byte order of the source
+	x, err = DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	p, err = x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	if string(p[:2]) != "II" {
+		t.Fatalf("re-encoded byte order is %q, want II", p[:2])
+	}
+
+	if err := x.SetByteOrder(binary.BigEndian); err != nil {
+		t.Fatal("SetByteOrder:", err)
+	}
+	p, err = x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	if string(p[:2]) != "MM" {
+		t.Fatalf("converted byte order is %q, want MM", p[:2])
+	}
+	x, err = DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if v := x.Tag(exiftag.PixelYDimension).Long(); len(v) != 1 || v[0] != 200 {
+		t.Errorf("PixelYDimension is %v, want [200]", v)
+	}
+	if s, _ := x.Tag(exiftag.Make).Ascii(); s != "Make" {
+		t.Errorf("Make is %q, want %q", s, "Make")
+	}
+	if lat, long, ok := x.LatLong(); !ok || lat != 47.5 || long != 19.25 {
+		t.Errorf("LatLong is %v, %v, %v; want 47.5, 19.25, true", lat, long, ok)
+	}
+
+	if err := x.SetByteOrder(nil); err != ErrByteOrder {
+		t.Errorf("SetByteOrder(nil) returned %v, want %v", err, ErrByteOrder)
+	}
+}
+
+func TestDeleteAllGPS(t *testing.T) {
+	x := New(100, 100)
+	i := GPSInfo{Lat: 47.5, Long: 19.25, Time: time.Date(2018, 5, 6, 7, 8, 9, 0, time.UTC)}
+	i.Alt.Float64, i.Alt.Valid = 120, true
+	x.SetGPSInfo(i)
+	x.Set(exiftag.Make, Ascii("TestMake"))
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	x, err = DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if _, _, ok := x.LatLong(); !ok {
+		t.Fatal("decoded Exif has no location")
+	}
+
+	x.DeleteAllGPS()
+	p, err = x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	x, err = DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if len(x.GPS) != 0 || dirTag(x.IFD0, ifd0gpsSub) != nil {
+		t.Errorf("GPS tags remain: %v", x.GPS)
+	}
+	if _, ok := x.GPSInfo(); ok {
+		t.Error("GPSInfo valid after DeleteAllGPS")
+	}
+	if s, _ := x.Tag(exiftag.Make).Ascii(); s != "TestMake" {
+		t.Errorf("Make is %q, want TestMake", s)
+	}
+}
@@ -0,0 +1,99 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Character codes of Exif text values such as
+// GPSProcessingMethod or UserComment.
+var (
+	textASCII     = []byte("ASCII\x00\x00\x00")
+	textUnicode   = []byte("UNICODE\x00")
+	textJIS       = []byte("JIS\x00\x00\x00\x00\x00")
+	textUndefined = make([]byte, 8)
+)
+
+// Text is a string Value, marshaled as TypeUndef with an
+// 8-byte character code prefix, as used by tags such as
+// GPSProcessingMethod or UserComment.
+//
+// Text containing only ASCII characters is marshaled as "ASCII",
+// other text as "UNICODE" (UTF-16) using the byte order of the Exif.
+type Text string
+
+func (v Text) marshalTiff(bo binary.ByteOrder) (typ uint16, count uint32, p []byte) {
+	if isASCII(string(v)) {
+		p = append(p, textASCII...)
+		p = append(p, v...)
+	} else {
+		u := utf16.Encode([]rune(string(v)))
+		p = make([]byte, len(textUnicode)+2*len(u))
+		copy(p, textUnicode)
+		for i, c := range u {
+			bo.PutUint16(p[len(textUnicode)+2*i:], c)
+		}
+	}
+	return TypeUndef, uint32(len(p)), p
+}
+
+// Text returns the value of t decoded using its character code prefix.
+//
+// ASCII and UNICODE text is supported. JIS and undefined
+// text is returned only if it is valid UTF-8, because JIS X 0208
+// cannot be decoded with the standard library.
+// Trailing NUL and space characters are removed.
+//
+// If t is invalid, is not TypeUndef or its character code is not
+// supported, ok == false is returned.
+func (t *Tag) Text() (s string, ok bool) {
+	p := t.Undef()
+	if len(p) < 8 {
+		return "", false
+	}
+	code, p := p[:8], p[8:]
+	switch {
+	case bytes.Equal(code, textASCII):
+		s = string(p)
+	case bytes.Equal(code, textUnicode):
+		s = decodeUTF16(p, t.ByteOrder)
+	case bytes.Equal(code, textJIS), bytes.Equal(code, textUndefined):
+		if !utf8.Valid(p) {
+			return "", false
+		}
+		s = string(p)
+	default:
+		return "", false
+	}
+	return strings.TrimRight(s, "\x00 "), true
+}
+
+// decodeUTF16 decodes UTF-16 text in p that has a byte order
+// mark or uses the byte order bo.
+func decodeUTF16(p []byte, bo binary.ByteOrder) string {
+	if len(p) >= 2 {
+		switch {
+		case p[0] == 0xfe && p[1] == 0xff:
+			bo, p = binary.BigEndian, p[2:]
+		case p[0] == 0xff && p[1] == 0xfe:
+			bo, p = binary.LittleEndian, p[2:]
+		}
+	}
+	u := make([]uint16, len(p)/2)
+	for i := range u {
+		u[i] = bo.Uint16(p[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"image"
 	"image/jpeg"
+	"io"
 
 	"github.com/tajtiattila/metadata/exif/exiftag"
 )
@@ -12,6 +13,7 @@ import (
 var (
 	ErrNoThumbnail     = errors.New("exif: no thumbnail")
 	ErrThumbnailTooBig = errors.New("exif: thumbnail too big")
+	ErrThumbnailFormat = errors.New("exif: thumbnail is not a JPEG")
 )
 
 const (
@@ -33,6 +35,34 @@ func (x *Exif) ThumbImage() (image.Image, string, error) {
 	return image.Decode(bytes.NewReader(x.Thumb))
 }
 
+// ExtractThumbnail returns the thumbnail from the Exif
+// of the JPEG in r as a standalone JPEG file.
+//
+// It returns NotFound if r has no Exif or the Exif has no
+// thumbnail, and ErrThumbnailFormat if the thumbnail is not
+// JPEG compressed, such as uncompressed (Compression=1) thumbnails.
+func ExtractThumbnail(r io.Reader) ([]byte, error) {
+	x, err := Decode(r)
+	if x == nil {
+		return nil, err
+	}
+	if len(x.Thumb) == 0 {
+		return nil, NotFound
+	}
+
+	if e := dirTag(x.IFD1, uint16(exiftag.Compression)); e != nil {
+		compr := &Tag{x.ByteOrder, *e}
+		if v := compr.Short(); len(v) != 1 || v[0] != ifd1CompressionJpeg {
+			return nil, ErrThumbnailFormat
+		}
+	}
+	if len(x.Thumb) < 2 || x.Thumb[0] != 0xff || x.Thumb[1] != 0xd8 {
+		return nil, ErrThumbnailFormat
+	}
+
+	return append([]byte(nil), x.Thumb...), nil
+}
+
 // SetThumbImage sets the thumbnail of x to im.
 // It reports ErrThumbnailTooBig if the thumbnail is too
 // large for use in Exif, and any errors encountered
@@ -53,6 +83,13 @@ func (x *Exif) setThumbData(compr uint16, p []byte) error {
 	if len(p) > maxThumbSize {
 		return ErrThumbnailTooBig
 	}
+	x.replaceThumb(compr, p)
+	return nil
+}
+
+// replaceThumb sets the thumbnail data of x to p,
+// and IFD1 to the tags needed for it.
+func (x *Exif) replaceThumb(compr uint16, p []byte) {
 	x.Thumb = p
 
 	ent := entryFunc(x.ByteOrder)
@@ -68,6 +105,4 @@ func (x *Exif) setThumbData(compr uint16, p []byte) error {
 		ent(ifd1thumbLength, Long{0}),
 	}
 	sortDir(x.IFD1)
-
-	return nil
 }
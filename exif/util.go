@@ -49,38 +49,92 @@ func (x *Exif) Time(timeTag, subSecTag uint32) (t time.Time, islocal, ok bool) {
 	return timeFromTags(x.Tag(timeTag), x.Tag(subSecTag))
 }
 
+// TimeWithOffset is like Time, but uses the time zone from offsetTag
+// (such as exiftag.OffsetTimeOriginal) when it is present and valid.
+// It returns islocal == true only if there is no offset for a time
+// without a zone, in which case t is in time.Local.
+func (x *Exif) TimeWithOffset(timeTag, subSecTag, offsetTag uint32) (t time.Time, islocal, ok bool) {
+	t, islocal, ok = x.Time(timeTag, subSecTag)
+	if !ok || !islocal {
+		return
+	}
+	s, _ := x.Tag(offsetTag).Ascii()
+	loc, hasOffset := parseOffset(s)
+	if !hasOffset {
+		return
+	}
+	t = time.Date(t.Year(), t.Month(), t.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	return t, false, true
+}
+
+// SetTime sets the specified DateTime and SubSecTime tags to t.
+// The SubSecTime tag is removed if t has no fractional seconds.
+func (x *Exif) SetTime(timeTag, subSecTag uint32, t time.Time) {
+	v, subv := timeValues(t)
+	x.Set(timeTag, v)
+	x.Set(subSecTag, subv)
+}
+
+// SetTimeWithOffset is like SetTime, but also sets offsetTag
+// (such as exiftag.OffsetTimeOriginal) to the zone offset of t,
+// unless t is in time.Local. The offset tag is removed for local times.
+func (x *Exif) SetTimeWithOffset(timeTag, subSecTag, offsetTag uint32, t time.Time) {
+	x.SetTime(timeTag, subSecTag, t)
+	x.Set(offsetTag, offsetValue(t))
+}
+
 // DateTime reports the Exif datetime. The fields checked
 // in order are Exif/DateTimeOriginal, Exif/DateTimeDigitized and
 // Tiff/DateTime. If neither is available, ok == false is returned.
+//
+// The time zone is set from the corresponding OffsetTime tag, if present.
 func (x *Exif) DateTime() (t time.Time, ok bool) {
-	t, _, ok = x.Time(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal)
+	t, _, ok = x.TimeWithOffset(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal, exiftag.OffsetTimeOriginal)
 	if ok {
 		return
 	}
 
-	t, _, ok = x.Time(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized)
+	t, _, ok = x.TimeWithOffset(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized, exiftag.OffsetTimeDigitized)
 	if ok {
 		return
 	}
 
-	t, _, ok = x.Time(exiftag.DateTime, exiftag.SubSecTime)
+	t, _, ok = x.TimeWithOffset(exiftag.DateTime, exiftag.SubSecTime, exiftag.OffsetTime)
+	return
+}
+
+// DateTimeDigitized reports the time when the image was stored
+// as digital data from Exif/DateTimeDigitized and SubSecTimeDigitized,
+// such as the time of scanning for a scanned photo.
+//
+// The time zone is set from OffsetTimeDigitized, if present.
+func (x *Exif) DateTimeDigitized() (t time.Time, ok bool) {
+	t, _, ok = x.TimeWithOffset(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized, exiftag.OffsetTimeDigitized)
 	return
 }
 
 // SetDateTime sets the fields
 // Exif/DateTimeOriginal, Exif/DateTimeDigitized and
 // Tiff/DateTime to t.
+//
+// The corresponding OffsetTime tags are set to the zone offset of t,
+// unless t is in time.Local, in which case they are removed.
 func (x *Exif) SetDateTime(t time.Time) {
 	v, subv := timeValues(t)
+	ofs := offsetValue(t)
 
 	x.Set(exiftag.DateTimeOriginal, v)
 	x.Set(exiftag.SubSecTimeOriginal, subv)
+	x.Set(exiftag.OffsetTimeOriginal, ofs)
 
 	x.Set(exiftag.DateTimeDigitized, v)
 	x.Set(exiftag.SubSecTimeDigitized, subv)
+	x.Set(exiftag.OffsetTimeDigitized, ofs)
 
 	x.Set(exiftag.DateTime, v)
 	x.Set(exiftag.SubSecTime, subv)
+	x.Set(exiftag.OffsetTime, ofs)
 }
 
 // GPSInfo represents GPS information within Exif.
@@ -117,7 +171,7 @@ func (x *Exif) GPSInfo() (i GPSInfo, ok bool) {
 
 	i.Alt.Float64, i.Alt.Valid = x.altitude()
 
-	i.Time, _ = x.gpsDateTime()
+	i.Time, _ = x.GPSDateTime()
 
 	return i, true
 }
@@ -161,7 +215,7 @@ func (x *Exif) SetGPSInfo(i GPSInfo) {
 		x.Set(exiftag.GPSAltitude, nil)
 	}
 
-	x.setGPSDateTime(i.Time)
+	x.SetGPSDateTime(i.Time)
 }
 
 // SetLatLong sets GPS latitude and longitude in x.
@@ -185,6 +239,175 @@ func (x *Exif) LatLong() (lat, long float64, ok bool) {
 	return 0, 0, false
 }
 
+// GPSDestBearing reports the bearing to the destination in degrees
+// within the range [0, 360). Magnetic is true if the bearing is relative
+// to the magnetic north, and false for the true north.
+func (x *Exif) GPSDestBearing() (deg float64, magnetic, ok bool) {
+	ref, _ := x.Tag(exiftag.GPSDestBearingRef).Ascii()
+	switch ref {
+	case "", "T":
+		// pass
+	case "M":
+		magnetic = true
+	default:
+		return 0, false, false
+	}
+
+	deg, ok = rationalFloat64(x.Tag(exiftag.GPSDestBearing), 0)
+	if !ok {
+		return 0, false, false
+	}
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg, magnetic, true
+}
+
+// GPSDestDistance reports the distance to the destination in kilometers.
+// Distances recorded in miles or knots (nautical miles) are converted.
+func (x *Exif) GPSDestDistance() (km float64, ok bool) {
+	ref, _ := x.Tag(exiftag.GPSDestDistanceRef).Ascii()
+	var mul float64
+	switch ref {
+	case "", "K":
+		mul = 1
+	case "M":
+		mul = 1.609344
+	case "N":
+		mul = 1.852
+	default:
+		return 0, false
+	}
+
+	d, ok := rationalFloat64(x.Tag(exiftag.GPSDestDistance), 0)
+	if !ok {
+		return 0, false
+	}
+	return d * mul, true
+}
+
+// GPSDOP returns the dilution of precision of the GPS fix,
+// that is HDOP for 2D and PDOP for 3D measurements.
+// Lower values mean more precise fixes.
+func (x *Exif) GPSDOP() (dop float64, ok bool) {
+	return rationalFloat64(x.Tag(exiftag.GPSDOP), 0)
+}
+
+// GPSSatellites returns the description of the
+// satellites used for the GPS measurement.
+func (x *Exif) GPSSatellites() (string, bool) {
+	return x.Tag(exiftag.GPSSatellites).Ascii()
+}
+
+// GPSProcessingMethod returns the name of the method used
+// for location finding, such as "GPS" or "NETWORK".
+func (x *Exif) GPSProcessingMethod() (string, bool) {
+	return x.Tag(exiftag.GPSProcessingMethod).Text()
+}
+
+// SetGPSProcessingMethod sets the name of the method used
+// for location finding. An empty s removes the tag.
+func (x *Exif) SetGPSProcessingMethod(s string) {
+	x.setText(exiftag.GPSProcessingMethod, s)
+}
+
+// GPSAreaInformation returns the name of the GPS area.
+func (x *Exif) GPSAreaInformation() (string, bool) {
+	return x.Tag(exiftag.GPSAreaInformation).Text()
+}
+
+// SetGPSAreaInformation sets the name of the GPS area.
+// An empty s removes the tag.
+func (x *Exif) SetGPSAreaInformation(s string) {
+	x.setText(exiftag.GPSAreaInformation, s)
+}
+
+// ImageDescription returns the title or caption of the image.
+func (x *Exif) ImageDescription() (string, bool) {
+	return x.Tag(exiftag.ImageDescription).Ascii()
+}
+
+// SetImageDescription sets the title or caption of the image.
+// An empty s removes the tag.
+func (x *Exif) SetImageDescription(s string) {
+	if s == "" {
+		x.Set(exiftag.ImageDescription, nil)
+	} else {
+		x.Set(exiftag.ImageDescription, Ascii(s))
+	}
+}
+
+// Software returns the name and version of the software
+// used to create the image.
+//
+// The Software tag is read from IFD0, or from the Exif
+// directory where some writers put it.
+func (x *Exif) Software() (string, bool) {
+	if s, ok := x.Tag(exiftag.Software).Ascii(); ok {
+		return s, true
+	}
+	return x.Tag(exiftag.Exif | exiftag.Software&^exiftag.DirMask).Ascii()
+}
+
+// SetSoftware sets the Software tag in IFD0.
+// An empty s removes the tag.
+func (x *Exif) SetSoftware(s string) {
+	if s == "" {
+		x.Set(exiftag.Software, nil)
+	} else {
+		x.Set(exiftag.Software, Ascii(s))
+	}
+}
+
+// InteropIndex returns the Interoperability Index, such as
+// "R98" for DCF basic files or "THM" for DCF thumbnail files.
+func (x *Exif) InteropIndex() (string, bool) {
+	return x.Tag(exiftag.InteroperabilityIndex).Ascii()
+}
+
+// UserComment returns the user comment of the image.
+func (x *Exif) UserComment() (string, bool) {
+	return x.Tag(exiftag.UserComment).Text()
+}
+
+// SetUserComment sets the user comment of the image.
+// An empty s removes the tag.
+func (x *Exif) SetUserComment(s string) {
+	x.setText(exiftag.UserComment, s)
+}
+
+func (x *Exif) setText(tag uint32, s string) {
+	if s == "" {
+		x.Set(tag, nil)
+	} else {
+		x.Set(tag, Text(s))
+	}
+}
+
+// SetOrientation sets the Tiff/Orientation tag to o.
+//
+// Valid values are 1..8, as described in package orient.
+// ErrOrientation is returned for other values,
+// including 0 that means undefined, and must not be stored.
+func (x *Exif) SetOrientation(o int) error {
+	if o < 1 || o > 8 {
+		return ErrOrientation
+	}
+	x.Set(exiftag.Orientation, Short{uint16(o)})
+	return nil
+}
+
+// Orientation reports the Tiff/Orientation tag.
+// It returns ok == false if the tag is missing or invalid.
+func (x *Exif) Orientation() (o int, ok bool) {
+	v := x.Tag(exiftag.Orientation).Short()
+	if len(v) != 1 || v[0] < 1 || v[0] > 8 {
+		return 0, false
+	}
+	return int(v[0]), true
+}
+
 // setLatLong sets the GPS latitude and longitude.
 func (x *Exif) setLatLong(lat, lon float64) {
 
@@ -211,12 +434,15 @@ func (x *Exif) setLatLong(lat, lon float64) {
 }
 
 func (x *Exif) altitude() (alt float64, ok bool) {
-	altr := x.Tag(exiftag.GPSAltitude).Rational()
-	if len(altr) != 2 {
+	t := x.Tag(exiftag.GPSAltitude)
+	if t.Type() == 0 || t.E.Count != 1 {
 		return 0, false
 	}
 
-	alt = float64(altr[0]) / float64(altr[1])
+	alt, ok = rationalFloat64(t, 0)
+	if !ok {
+		return 0, false
+	}
 
 	// permit missing AltitudeRef, but use it for the sign if it exists.
 	if ar := x.Tag(exiftag.GPSAltitudeRef).Byte(); len(ar) == 1 && ar[0] == 1 {
@@ -226,7 +452,9 @@ func (x *Exif) altitude() (alt float64, ok bool) {
 	return alt, true
 }
 
-func (x *Exif) gpsDateTime() (t time.Time, ok bool) {
+// GPSDateTime returns the time of the GPS fix
+// recorded in the GPSDateStamp and GPSTimeStamp tags, in UTC.
+func (x *Exif) GPSDateTime() (t time.Time, ok bool) {
 	ds, ok := x.Tag(exiftag.GPSDateStamp).Ascii()
 	if !ok {
 		return time.Time{}, false
@@ -245,7 +473,10 @@ func (x *Exif) gpsDateTime() (t time.Time, ok bool) {
 	return d.Add(time.Duration(tlo) * time.Nanosecond), true
 }
 
-func (x *Exif) setGPSDateTime(t time.Time) {
+// SetGPSDateTime sets the GPSDateStamp and GPSTimeStamp tags
+// to t converted to UTC, with microsecond precision.
+// If t.IsZero() is true, the tags are removed from x.
+func (x *Exif) SetGPSDateTime(t time.Time) {
 	if t.IsZero() {
 		x.Set(exiftag.GPSDateStamp, nil)
 		x.Set(exiftag.GPSTimeStamp, nil)
@@ -257,16 +488,14 @@ func (x *Exif) setGPSDateTime(t time.Time) {
 
 	x.Set(exiftag.GPSDateStamp, Ascii(t.Format("2006:01:02")))
 
-	h, m, s := t.Clock()
-
-	sn, sd := uint32(s), uint32(1)
-
 	// use microsecond precision to avoid uint32 overflow
-	if us := t.Nanosecond() / 1000; us != 0 {
-		sn, sd = sn*1e6+uint32(us), 1e6
+	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	us := uint64(t.Sub(d) / time.Microsecond)
+	if us%1e6 == 0 {
+		x.Set(exiftag.GPSTimeStamp, Sexagesimal(us/1e6, 1))
+	} else {
+		x.Set(exiftag.GPSTimeStamp, Sexagesimal(us, 1e6))
 	}
-
-	x.Set(exiftag.GPSTimeStamp, Rational{uint32(h), 1, uint32(m), 1, sn, sd})
 }
 
 const TimeFormat = "2006:01:02 15:04:05"
@@ -298,6 +527,35 @@ func timeFromTags(t, subt *Tag) (tm time.Time, islocal, ok bool) {
 	return tm.Add(nanos * res), islocal, true
 }
 
+// parseOffset parses a time zone offset such as "+02:00".
+func parseOffset(s string) (loc *time.Location, ok bool) {
+	if len(s) != 6 || s[3] != ':' {
+		return nil, false
+	}
+	var sig int
+	switch s[0] {
+	case '+':
+		sig = 1
+	case '-':
+		sig = -1
+	default:
+		return nil, false
+	}
+	h, ok1 := atoi2(s[1:3])
+	m, ok2 := atoi2(s[4:6])
+	if !ok1 || !ok2 || h > 14 || m > 59 {
+		return nil, false
+	}
+	return time.FixedZone("", sig*(h*3600+m*60)), true
+}
+
+func atoi2(s string) (int, bool) {
+	if len(s) != 2 || s[0] < '0' || s[0] > '9' || s[1] < '0' || s[1] > '9' {
+		return 0, false
+	}
+	return int(s[0]-'0')*10 + int(s[1]-'0'), true
+}
+
 func timePart(t *Tag) (tm time.Time, islocal, ok bool) {
 	tms, ok := t.Ascii()
 	if !ok {
@@ -350,6 +608,15 @@ func timeValues(t time.Time) (v, subv Value) {
 	return v, subv
 }
 
+// offsetValue returns the OffsetTime value for t,
+// or nil if t is in time.Local.
+func offsetValue(t time.Time) Value {
+	if t.Location() == time.Local {
+		return nil
+	}
+	return Ascii(t.Format("-07:00"))
+}
+
 func locSig(t *Tag, pos, neg string) (sig float64, ok bool) {
 	s, ok := t.Ascii()
 	if !ok {
@@ -366,16 +633,26 @@ func locSig(t *Tag, pos, neg string) (sig float64, ok bool) {
 	return sig, true
 }
 
+// rationalFloat64 returns the i-th value of t as a float64,
+// if t is TypeRational or TypeSRational.
+func rationalFloat64(t *Tag, i int) (float64, bool) {
+	if t.IsType(TypeSRational) {
+		return t.SRational().Float64(i)
+	}
+	return t.Rational().Float64(i)
+}
+
 func degHourMin(t *Tag) (val float64, ok bool) {
-	r := t.Rational()
-	if len(r) != 6 {
+	if t.Type() == 0 || t.E.Count != 3 {
 		return 0, false
 	}
 	div := 1.0
 	for i := 0; i < 3; i++ {
-		num, denom := r[2*i], r[2*i+1]
-		v := float64(num) / (div * float64(denom))
-		val += v
+		v, ok := rationalFloat64(t, i)
+		if !ok {
+			return 0, false
+		}
+		val += v / div
 		div *= 60
 	}
 	return val, true
@@ -5,9 +5,13 @@ import (
 	"image"
 	"image/color"
 	"image/jpeg"
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
 )
 
 func TestNewImageLatLong(t *testing.T) {
@@ -78,3 +82,451 @@ func TestNewImageLatLong(t *testing.T) {
 		t.Errorf("exif has lon=%v, want %v", xlon, lon)
 	}
 }
+
+func TestOrientation(t *testing.T) {
+	x := exif.New(100, 100)
+	if o, ok := x.Orientation(); ok {
+		t.Errorf("new exif has orientation %v", o)
+	}
+
+	for _, o := range []int{0, 9, -1, 1 << 16} {
+		if err := x.SetOrientation(o); err != exif.ErrOrientation {
+			t.Errorf("SetOrientation(%d) error is %v, want %v", o, err, exif.ErrOrientation)
+		}
+	}
+
+	for o := 1; o <= 8; o++ {
+		if err := x.SetOrientation(o); err != nil {
+			t.Fatalf("SetOrientation(%d): %v", o, err)
+		}
+
+		p, err := x.EncodeBytes()
+		if err != nil {
+			t.Fatal("EncodeBytes:", err)
+		}
+		y, err := exif.DecodeBytes(p)
+		if err != nil {
+			t.Fatal("DecodeBytes:", err)
+		}
+
+		if got, ok := y.Orientation(); !ok || got != o {
+			t.Errorf("Orientation is %v, %v; want %v, true", got, ok, o)
+		}
+	}
+}
+
+func TestDateTime(t *testing.T) {
+	x := exif.New(100, 100)
+	if tm, ok := x.DateTime(); ok {
+		t.Errorf("new exif has datetime %v", tm)
+	}
+
+	orig := time.Date(2017, 4, 1, 12, 34, 56, 250e6, time.Local)
+	digi := time.Date(2017, 4, 2, 8, 0, 0, 0, time.Local)
+	x.SetDateTime(orig)
+	x.SetTime(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized, digi)
+
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	y, err := exif.DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+
+	if tm, ok := y.DateTime(); !ok || !tm.Equal(orig) {
+		t.Errorf("DateTime is %v, %v; want %v, true", tm, ok, orig)
+	}
+
+	tm, islocal, ok := y.Time(exiftag.DateTimeDigitized, exiftag.SubSecTimeDigitized)
+	if !ok || !islocal || !tm.Equal(digi) {
+		t.Errorf("DateTimeDigitized is %v, %v, %v; want %v, true, true", tm, islocal, ok, digi)
+	}
+	if y.Tag(exiftag.SubSecTimeDigitized).Valid() {
+		t.Error("SubSecTimeDigitized present for whole seconds")
+	}
+
+	if tm, ok := y.DateTimeDigitized(); !ok || !tm.Equal(digi) {
+		t.Errorf("DateTimeDigitized() is %v, %v; want %v, true", tm, ok, digi)
+	}
+	y.Set(exiftag.DateTimeDigitized, nil)
+	if tm, ok := y.DateTimeDigitized(); ok {
+		t.Errorf("DateTimeDigitized() is %v after removing the tag", tm)
+	}
+}
+
+func TestGPSDest(t *testing.T) {
+	x := exif.New(100, 100)
+	if _, _, ok := x.GPSDestBearing(); ok {
+		t.Error("new exif has GPSDestBearing")
+	}
+	if _, ok := x.GPSDestDistance(); ok {
+		t.Error("new exif has GPSDestDistance")
+	}
+
+	x.Set(exiftag.GPSDestBearingRef, exif.Ascii("M"))
+	x.Set(exiftag.GPSDestBearing, exif.Rational{3615, 10})
+	if deg, mag, ok := x.GPSDestBearing(); !ok || !mag || math.Abs(deg-1.5) > 1e-9 {
+		t.Errorf("GPSDestBearing is %v, %v, %v; want 1.5, true, true", deg, mag, ok)
+	}
+
+	x.Set(exiftag.GPSDestBearing, exif.Rational{1, 0})
+	if _, _, ok := x.GPSDestBearing(); ok {
+		t.Error("GPSDestBearing with zero denominator is valid")
+	}
+
+	distTests := []struct {
+		ref  string
+		dist exif.Rational
+		want float64
+	}{
+		{"K", exif.Rational{25, 10}, 2.5},
+		{"M", exif.Rational{10, 1}, 16.09344},
+		{"N", exif.Rational{1, 2}, 0.926},
+	}
+	for _, tt := range distTests {
+		x.Set(exiftag.GPSDestDistanceRef, exif.Ascii(tt.ref))
+		x.Set(exiftag.GPSDestDistance, tt.dist)
+		if km, ok := x.GPSDestDistance(); !ok || math.Abs(km-tt.want) > 1e-9 {
+			t.Errorf("GPSDestDistance with ref %q is %v, %v; want %v, true", tt.ref, km, ok, tt.want)
+		}
+	}
+
+	x.Set(exiftag.GPSDestDistance, exif.Rational{1, 0})
+	if _, ok := x.GPSDestDistance(); ok {
+		t.Error("GPSDestDistance with zero denominator is valid")
+	}
+}
+
+func TestGPSDOP(t *testing.T) {
+	x := exif.New(100, 100)
+	if _, ok := x.GPSDOP(); ok {
+		t.Error("new exif has GPSDOP")
+	}
+	if _, ok := x.GPSSatellites(); ok {
+		t.Error("new exif has GPSSatellites")
+	}
+
+	x.Set(exiftag.GPSDOP, exif.Rational{25, 10})
+	x.Set(exiftag.GPSSatellites, exif.Ascii("07"))
+	if dop, ok := x.GPSDOP(); !ok || dop != 2.5 {
+		t.Errorf("GPSDOP is %v, %v; want 2.5, true", dop, ok)
+	}
+	if s, ok := x.GPSSatellites(); !ok || s != "07" {
+		t.Errorf("GPSSatellites is %q, %v; want \"07\", true", s, ok)
+	}
+
+	x.Set(exiftag.GPSDOP, exif.Rational{1, 0})
+	if _, ok := x.GPSDOP(); ok {
+		t.Error("GPSDOP with zero denominator is valid")
+	}
+}
+
+func TestTimeWithOffset(t *testing.T) {
+	x := exif.New(100, 100)
+	x.Set(exiftag.DateTimeOriginal, exif.Ascii("2017:04:01 12:34:56"))
+	x.Set(exiftag.SubSecTimeOriginal, exif.Ascii("25"))
+
+	tests := []struct {
+		offset  string
+		want    time.Time
+		islocal bool
+	}{
+		{"", time.Date(2017, 4, 1, 12, 34, 56, 250e6, time.Local), true},
+		{"   :  ", time.Date(2017, 4, 1, 12, 34, 56, 250e6, time.Local), true},
+		{"+02:00", time.Date(2017, 4, 1, 10, 34, 56, 250e6, time.UTC), false},
+		{"-05:30", time.Date(2017, 4, 1, 18, 4, 56, 250e6, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if tt.offset != "" {
+			x.Set(exiftag.OffsetTimeOriginal, exif.Ascii(tt.offset))
+		} else {
+			x.Set(exiftag.OffsetTimeOriginal, nil)
+		}
+
+		tm, islocal, ok := x.TimeWithOffset(exiftag.DateTimeOriginal,
+			exiftag.SubSecTimeOriginal, exiftag.OffsetTimeOriginal)
+		if !ok || islocal != tt.islocal || !tm.Equal(tt.want) {
+			t.Errorf("offset %q: got %v, %v, %v; want %v, %v, true",
+				tt.offset, tm, islocal, ok, tt.want, tt.islocal)
+		}
+		if !tt.islocal {
+			if _, ofs := tm.Zone(); ofs == 0 {
+				t.Errorf("offset %q: time has no zone", tt.offset)
+			}
+		}
+
+		if dt, ok := x.DateTime(); !ok || !dt.Equal(tt.want) {
+			t.Errorf("offset %q: DateTime is %v, %v; want %v", tt.offset, dt, ok, tt.want)
+		}
+	}
+}
+
+func TestSetDateTimeOffset(t *testing.T) {
+	offsetTags := []uint32{
+		exiftag.OffsetTimeOriginal,
+		exiftag.OffsetTimeDigitized,
+		exiftag.OffsetTime,
+	}
+
+	x := exif.New(100, 100)
+	tm := time.Date(2017, 4, 1, 12, 34, 56, 0, time.FixedZone("IST", 5*3600+1800))
+	x.SetDateTime(tm)
+	for _, tag := range offsetTags {
+		if got, want := x.Tag(tag).E.Value, []byte("+05:30\x00"); !bytes.Equal(got, want) {
+			t.Errorf("offset tag %x is %q, want %q", tag, got, want)
+		}
+	}
+	if s, _ := x.Tag(exiftag.DateTimeOriginal).Ascii(); s != "2017:04:01 12:34:56" {
+		t.Errorf("DateTimeOriginal is %q", s)
+	}
+	if got, ok := x.DateTime(); !ok || !got.Equal(tm) {
+		t.Errorf("DateTime is %v, %v; want %v", got, ok, tm)
+	}
+
+	// offsets are removed for local times
+	x.SetDateTime(time.Date(2017, 4, 1, 12, 34, 56, 0, time.Local))
+	for _, tag := range offsetTags {
+		if x.Tag(tag).Valid() {
+			t.Errorf("offset tag %x present for local time", tag)
+		}
+	}
+}
+
+func TestGPSProcessingMethod(t *testing.T) {
+	x := exif.New(100, 100)
+	if _, ok := x.GPSProcessingMethod(); ok {
+		t.Error("new exif has GPSProcessingMethod")
+	}
+
+	for _, s := range []string{"NETWORK", "Kőbánya"} {
+		x.SetGPSProcessingMethod(s)
+		x.SetGPSAreaInformation(s + " area")
+		if got, ok := x.GPSProcessingMethod(); !ok || got != s {
+			t.Errorf("GPSProcessingMethod is %q, %v; want %q", got, ok, s)
+		}
+		if got, ok := x.GPSAreaInformation(); !ok || got != s+" area" {
+			t.Errorf("GPSAreaInformation is %q, %v; want %q", got, ok, s+" area")
+		}
+	}
+
+	x.SetGPSProcessingMethod("")
+	if x.Tag(exiftag.GPSProcessingMethod).Valid() {
+		t.Error("GPSProcessingMethod not removed")
+	}
+
+	tests := []struct {
+		v    exif.Undef
+		want string
+		ok   bool
+	}{
+		{exif.Undef("ASCII\x00\x00\x00GPS\x00"), "GPS", true},
+		{exif.Undef("UNICODE\x00\x00G\x00P\x00S"), "GPS", true},
+		{exif.Undef("UNICODE\x00\xff\xfeG\x00P\x00S\x00"), "GPS", true},
+		{exif.Undef("JIS\x00\x00\x00\x00\x00GPS  "), "GPS", true},
+		{exif.Undef("\x00\x00\x00\x00\x00\x00\x00\x00GPS"), "GPS", true},
+		{exif.Undef("JIS\x00\x00\x00\x00\x00\x82\xa0"), "", false},
+		{exif.Undef("UNKNOWN\x00GPS"), "", false},
+		{exif.Undef("GPS"), "", false},
+	}
+	for _, tt := range tests {
+		x.Set(exiftag.GPSProcessingMethod, tt.v)
+		if got, ok := x.GPSProcessingMethod(); got != tt.want || ok != tt.ok {
+			t.Errorf("GPSProcessingMethod of %q is %q, %v; want %q, %v", tt.v, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGPSSRational(t *testing.T) {
+	x := exif.New(100, 100)
+	x.SetLatLong(0, 0)
+	x.Set(exiftag.GPSLatitude, exif.SRational{47, 1, 30, 1, 0, 1})
+	x.Set(exiftag.GPSAltitudeRef, nil)
+	x.Set(exiftag.GPSAltitude, exif.SRational{-125, 10})
+	x.Set(exiftag.GPSDestBearing, exif.SRational{-90, 1})
+
+	i, ok := x.GPSInfo()
+	if !ok || i.Lat != 47.5 {
+		t.Errorf("GPSInfo latitude is %v, %v; want 47.5, true", i.Lat, ok)
+	}
+	if !i.Alt.Valid || i.Alt.Float64 != -12.5 {
+		t.Errorf("GPSInfo altitude is %v, %v; want -12.5, true", i.Alt.Float64, i.Alt.Valid)
+	}
+	if deg, _, ok := x.GPSDestBearing(); !ok || deg != 270 {
+		t.Errorf("GPSDestBearing is %v, %v; want 270, true", deg, ok)
+	}
+
+	if got := x.Tag(exiftag.GPSAltitude).SRational(); len(got) != 2 || got[0] != -125 {
+		t.Errorf("SRational is %v, want [-125 10]", got)
+	}
+}
+
+func TestGPSDateTime(t *testing.T) {
+	x := exif.New(100, 100)
+	if _, ok := x.GPSDateTime(); ok {
+		t.Error("new exif has GPSDateTime")
+	}
+
+	loc := time.FixedZone("UTC+2", 2*3600)
+	tests := []struct {
+		t     time.Time
+		date  string
+		stamp exif.Rational
+	}{
+		{
+			time.Date(2018, 5, 6, 7, 8, 9, 0, loc),
+			"2018:05:06", exif.Rational{5, 1, 8, 1, 9, 1},
+		},
+		{
+			time.Date(2018, 1, 1, 1, 30, 15, 250000000, loc),
+			"2017:12:31", exif.Rational{23, 1, 30, 1, 15250000, 1e6},
+		},
+	}
+	for _, tt := range tests {
+		x.SetGPSDateTime(tt.t)
+		if d, _ := x.Tag(exiftag.GPSDateStamp).Ascii(); d != tt.date {
+			t.Errorf("%v: GPSDateStamp is %q, want %q", tt.t, d, tt.date)
+		}
+		if r := x.Tag(exiftag.GPSTimeStamp).Rational(); !reflect.DeepEqual(r, tt.stamp) {
+			t.Errorf("%v: GPSTimeStamp is %v, want %v", tt.t, r, tt.stamp)
+		}
+
+		p, err := x.EncodeBytes()
+		if err != nil {
+			t.Fatal("EncodeBytes:", err)
+		}
+		y, err := exif.DecodeBytes(p)
+		if err != nil {
+			t.Fatal("DecodeBytes:", err)
+		}
+		got, ok := y.GPSDateTime()
+		if !ok || !got.Equal(tt.t) || got.Location() != time.UTC {
+			t.Errorf("GPSDateTime is %v, %v; want %v in UTC", got, ok, tt.t)
+		}
+	}
+
+	x.SetGPSDateTime(time.Time{})
+	if x.Tag(exiftag.GPSDateStamp).Valid() || x.Tag(exiftag.GPSTimeStamp).Valid() {
+		t.Error("zero time did not remove GPS time")
+	}
+}
+
+func TestUserComment(t *testing.T) {
+	x := exif.New(100, 100)
+	if _, ok := x.UserComment(); ok {
+		t.Error("new exif has UserComment")
+	}
+
+	x.SetUserComment("Tour")
+	if got := x.Tag(exiftag.UserComment).Undef(); string(got) != "ASCII\x00\x00\x00Tour" {
+		t.Errorf("ASCII UserComment stored as %q", got)
+	}
+
+	// UTF-16 in the byte order of the Exif
+	utf16 := exif.Undef("UNICODE\x00")
+	for _, r := range "Túra ✓" {
+		p := make([]byte, 2)
+		x.ByteOrder.PutUint16(p, uint16(r))
+		utf16 = append(utf16, p...)
+	}
+	x.Set(exiftag.UserComment, utf16)
+	if got, ok := x.UserComment(); !ok || got != "Túra ✓" {
+		t.Errorf("UTF-16 UserComment is %q, %v; want %q", got, ok, "Túra ✓")
+	}
+
+	for _, s := range []string{"Tour", "Túra ✓"} {
+		x.SetUserComment(s)
+		p, err := x.EncodeBytes()
+		if err != nil {
+			t.Fatal("EncodeBytes:", err)
+		}
+		y, err := exif.DecodeBytes(p)
+		if err != nil {
+			t.Fatal("DecodeBytes:", err)
+		}
+		if got, ok := y.UserComment(); !ok || got != s {
+			t.Errorf("UserComment round trip is %q, %v; want %q", got, ok, s)
+		}
+	}
+}
+
+func TestImageDescription(t *testing.T) {
+	x := exif.New(100, 100)
+	if _, ok := x.ImageDescription(); ok {
+		t.Error("new exif has ImageDescription")
+	}
+
+	x.SetImageDescription("Scanned photo")
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	y, err := exif.DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if got, ok := y.ImageDescription(); !ok || got != "Scanned photo" {
+		t.Errorf("ImageDescription is %q, %v; want %q", got, ok, "Scanned photo")
+	}
+
+	x.SetImageDescription("")
+	if _, ok := x.ImageDescription(); ok {
+		t.Error("ImageDescription not removed")
+	}
+}
+
+func TestSoftware(t *testing.T) {
+	x := exif.New(100, 100)
+	if _, ok := x.Software(); ok {
+		t.Error("new exif has Software")
+	}
+
+	// Software in the Exif directory, used only when missing from IFD0
+	x.Set(exiftag.Exif|0x0131, exif.Ascii("ExifSoftware"))
+	if got, ok := x.Software(); !ok || got != "ExifSoftware" {
+		t.Errorf("Software is %q, %v; want %q", got, ok, "ExifSoftware")
+	}
+
+	x.SetSoftware("Editor 1.0")
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal("EncodeBytes:", err)
+	}
+	y, err := exif.DecodeBytes(p)
+	if err != nil {
+		t.Fatal("DecodeBytes:", err)
+	}
+	if got, ok := y.Software(); !ok || got != "Editor 1.0" {
+		t.Errorf("Software is %q, %v; want %q", got, ok, "Editor 1.0")
+	}
+}
+
+func TestString(t *testing.T) {
+	x := exif.New(100, 100)
+	if got := x.String(); got != "" {
+		t.Errorf("empty Exif: got %q", got)
+	}
+
+	x.Set(exiftag.Make, exif.Ascii("Canon"))
+	x.Set(exiftag.Model, exif.Ascii("Canon EOS 80D"))
+	x.SetDateTime(time.Date(2023, 5, 1, 12, 34, 56, 0, time.UTC))
+	x.Set(exiftag.ISOSpeedRatings, exif.Short{100})
+	x.Set(exiftag.FNumber, exif.Rational{28, 10})
+	x.Set(exiftag.ExposureTime, exif.Rational{1, 200})
+	x.SetLatLong(51.5, -0.1)
+
+	const want = "Canon EOS 80D / 2023-05-01 12:34:56 / ISO100 f2.8 1/200 / GPS 51.5,-0.1"
+	if got := x.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	x.Set(exiftag.Model, exif.Ascii("EOS"))
+	x.Set(exiftag.ISOSpeedRatings, nil)
+	x.Set(exiftag.ExposureTime, exif.Rational{5, 2})
+	x.Set(exiftag.GPSLatitude, nil)
+	const want2 = "Canon EOS / 2023-05-01 12:34:56 / f2.8 2.5s"
+	if got := x.String(); got != want2 {
+		t.Errorf("got %q, want %q", got, want2)
+	}
+}
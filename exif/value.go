@@ -1,6 +1,9 @@
 package exif
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"math"
+)
 
 // Value can marshal itself as Entry content, for use with with Exif.Set or Entry.SetValue.
 //
@@ -48,6 +51,92 @@ func (v Rational) marshalTiff(bo binary.ByteOrder) (typ uint16, count uint32, p
 	return TypeRational, uint32(len(v) / 2), p
 }
 
+// Float64 returns the i-th numerator-denominator pair of r as a float64.
+// It returns ok == false if r has no i-th pair,
+// or its denominator is zero.
+func (r Rational) Float64(i int) (v float64, ok bool) {
+	if i < 0 || len(r) < 2*i+2 {
+		return 0, false
+	}
+	num, den := r[2*i], r[2*i+1]
+	if den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// SRational is a Value of 32-bit signed numerator-denominator pairs,
+// marshaled as TypeSRational.
+//
+// SRational is valid only if it is not empty and has an even number of elements.
+type SRational []int32
+
+func (v SRational) marshalTiff(bo binary.ByteOrder) (typ uint16, count uint32, p []byte) {
+	if len(v)%2 == 1 {
+		panic("SRational with an odd number of elements")
+	}
+	p = make([]byte, 4*len(v))
+	for i := range v {
+		bo.PutUint32(p[i*4:], uint32(v[i]))
+	}
+	return TypeSRational, uint32(len(v) / 2), p
+}
+
+// Float64 returns the i-th numerator-denominator pair of r as a float64.
+// It returns ok == false if r has no i-th pair,
+// or its denominator is zero.
+func (r SRational) Float64(i int) (v float64, ok bool) {
+	if i < 0 || len(r) < 2*i+2 {
+		return 0, false
+	}
+	num, den := r[2*i], r[2*i+1]
+	if den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// FloatRational returns the reduced fraction closest to v
+// having numerator and denominator within the uint32 range.
+//
+// It returns 0, 0 (an invalid fraction) if v is negative, or NaN.
+// Values too large to be represented are returned as math.MaxUint32, 1.
+func FloatRational(v float64) (num, den uint32) {
+	if v < 0 || math.IsNaN(v) {
+		return 0, 0
+	}
+	if v >= math.MaxUint32 {
+		return math.MaxUint32, 1
+	}
+
+	// best rational approximation using continued fractions
+	const max = math.MaxUint32
+	h0, h1 := uint64(0), uint64(1) // numerators
+	k0, k1 := uint64(1), uint64(0) // denominators
+	x := v
+	for i := 0; i < 64; i++ {
+		a := math.Floor(x)
+		if a > max {
+			break
+		}
+		ai := uint64(a)
+		h := ai*h1 + h0
+		k := ai*k1 + k0
+		if h > max || k > max {
+			break
+		}
+		h0, h1 = h1, h
+		k0, k1 = k1, k
+
+		f := x - a
+		if f == 0 || float64(h)/float64(k) == v {
+			break
+		}
+		x = 1 / f
+	}
+	return uint32(h1), uint32(k1)
+}
+
 // Byte is a Value of 8-bit unsigned integers marshaled as TypeByte.
 type Byte []byte
 
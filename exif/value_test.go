@@ -2,6 +2,7 @@ package exif
 
 import (
 	"encoding/binary"
+	"math"
 	"math/big"
 	"testing"
 	"time"
@@ -93,7 +94,7 @@ func testSexagesimalV(t *testing.T, r Rational, res uint32, val uint64) {
 func testSexagesimalImpl(t *testing.T, r Rational, res uint32, val uint64, tval bool) {
 	hi, lo, ok := r.Sexagesimal(res)
 	if !ok {
-		t.Error("Rational.Sexagesimal %v invalid", r)
+		t.Errorf("Rational.Sexagesimal %v invalid", r)
 		return
 	}
 
@@ -160,3 +161,55 @@ func bigSexagesimal(r Rational, res uint32) *big.Int {
 	}
 	return n
 }
+
+func TestFloatRational(t *testing.T) {
+	const maxuint32 = 1<<32 - 1
+	tests := []struct {
+		v        float64
+		num, den uint32
+	}{
+		{0, 0, 1},
+		{1, 1, 1},
+		{0.5, 1, 2},
+		{1.0 / 3, 1, 3},
+		{2.0 / 3, 2, 3},
+		{22.0 / 7, 22, 7},
+		{123.456, 15432, 125},
+		{0.1, 1, 10},
+		{1e10, maxuint32, 1},
+		{-1, 0, 0},
+	}
+	for _, tt := range tests {
+		num, den := FloatRational(tt.v)
+		if num != tt.num || den != tt.den {
+			t.Errorf("FloatRational(%v) is %v/%v, want %v/%v", tt.v, num, den, tt.num, tt.den)
+		}
+	}
+
+	// irrational numbers should be approximated closely
+	num, den := FloatRational(math.Pi)
+	if d := math.Abs(float64(num)/float64(den) - math.Pi); d > 1e-15 {
+		t.Errorf("FloatRational(π) is %v/%v, off by %v", num, den, d)
+	}
+}
+
+func TestRationalFloat64(t *testing.T) {
+	r := Rational{1, 3, 5, 0, 3, 2}
+	tests := []struct {
+		i  int
+		v  float64
+		ok bool
+	}{
+		{0, 1.0 / 3, true},
+		{1, 0, false},
+		{2, 1.5, true},
+		{3, 0, false},
+		{-1, 0, false},
+	}
+	for _, tt := range tests {
+		v, ok := r.Float64(tt.i)
+		if v != tt.v || ok != tt.ok {
+			t.Errorf("Rational.Float64(%d) is %v, %v; want %v, %v", tt.i, v, ok, tt.v, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package geo implements reading GPS tracks,
+// that may be used to geotag images using their timestamps.
+package geo
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+var ErrFormat = errors.New("geo: invalid gpx data")
+
+// TrackPoint is a location recorded at a point in time.
+type TrackPoint struct {
+	// Lat and Long are the geographical location.
+	// Positive latitude means north, positive longitude means east.
+	Lat, Long float64
+
+	// Ele is the elevation in meters, valid if HasEle is true.
+	Ele    float64
+	HasEle bool
+
+	Time time.Time
+}
+
+// gpxNS lists the namespaces of supported GPX versions.
+var gpxNS = map[string]bool{
+	"":                                  true,
+	"http://www.topografix.com/GPX/1/0": true,
+	"http://www.topografix.com/GPX/1/1": true,
+}
+
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  *float64 `xml:"ele"`
+	Time string   `xml:"time"`
+}
+
+// ParseGPX parses track points (trkpt) and waypoints (wpt)
+// from the GPX 1.0 or 1.1 data in r.
+//
+// Points without a valid time are skipped.
+// The result is sorted by time.
+func ParseGPX(r io.Reader) ([]TrackPoint, error) {
+	d := xml.NewDecoder(r)
+
+	root := true
+	var v []TrackPoint
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if root {
+			if se.Name.Local != "gpx" || !gpxNS[se.Name.Space] {
+				return nil, ErrFormat
+			}
+			root = false
+			continue
+		}
+
+		switch se.Name.Local {
+		case "trkpt", "wpt":
+			// pass
+		default:
+			continue
+		}
+
+		var p gpxPoint
+		if err := d.DecodeElement(&p, &se); err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, p.Time)
+		if err != nil {
+			continue
+		}
+		tp := TrackPoint{
+			Lat:  p.Lat,
+			Long: p.Lon,
+			Time: t,
+		}
+		if p.Ele != nil {
+			tp.Ele, tp.HasEle = *p.Ele, true
+		}
+		v = append(v, tp)
+	}
+
+	if root {
+		return nil, ErrFormat
+	}
+
+	sort.SliceStable(v, func(i, j int) bool {
+		return v[i].Time.Before(v[j].Time)
+	})
+	return v, nil
+}
@@ -0,0 +1,73 @@
+package geo_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata/geo"
+)
+
+func TestParseGPX(t *testing.T) {
+	for _, ns := range []string{
+		"http://www.topografix.com/GPX/1/0",
+		"http://www.topografix.com/GPX/1/1",
+	} {
+		src := `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="` + ns + `">
+ <wpt lat="47.5" lon="19.25">
+  <ele>110.5</ele>
+  <time>2017-04-01T12:00:00Z</time>
+  <name>start</name>
+ </wpt>
+ <trk>
+  <trkseg>
+   <trkpt lat="47.52" lon="19.27">
+    <time>2017-04-01T12:02:00Z</time>
+   </trkpt>
+   <trkpt lat="47.6" lon="19.3">
+    <ele>120</ele>
+   </trkpt>
+   <trkpt lat="47.51" lon="19.26">
+    <ele>112</ele>
+    <time>2017-04-01T12:01:00Z</time>
+   </trkpt>
+  </trkseg>
+ </trk>
+</gpx>`
+
+		v, err := geo.ParseGPX(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t0 := time.Date(2017, 4, 1, 12, 0, 0, 0, time.UTC)
+		want := []geo.TrackPoint{
+			{Lat: 47.5, Long: 19.25, Ele: 110.5, HasEle: true, Time: t0},
+			{Lat: 47.51, Long: 19.26, Ele: 112, HasEle: true, Time: t0.Add(time.Minute)},
+			{Lat: 47.52, Long: 19.27, Time: t0.Add(2 * time.Minute)},
+		}
+		if len(v) != len(want) {
+			t.Fatalf("%s: got %d points, want %d", ns, len(v), len(want))
+		}
+		for i := range want {
+			g, w := v[i], want[i]
+			if g.Lat != w.Lat || g.Long != w.Long || g.Ele != w.Ele ||
+				g.HasEle != w.HasEle || !g.Time.Equal(w.Time) {
+				t.Errorf("%s: point %d is %+v, want %+v", ns, i, g, w)
+			}
+		}
+	}
+}
+
+func TestParseGPXInvalid(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"<kml/>",
+		"<gpx xmlns='http://example.com/other'/>",
+	} {
+		if _, err := geo.ParseGPX(strings.NewReader(src)); err != geo.ErrFormat {
+			t.Errorf("%q: got error %v, want %v", src, err, geo.ErrFormat)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package geo
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tajtiattila/metadata"
+)
+
+// DefaultMaxGap is a reasonable maximum time between two track points
+// used by Interpolate and Locate, for tracks recorded continuously.
+const DefaultMaxGap = 5 * time.Minute
+
+// Interpolate returns the position at time t using linear
+// interpolation between the nearest track points before and after t.
+// The track must be sorted by time, such as the result of ParseGPX.
+//
+// It returns ok == false if t is outside of the track,
+// or the track points around t are more than maxGap apart.
+func Interpolate(track []TrackPoint, t time.Time, maxGap time.Duration) (lat, lon float64, ok bool) {
+	i := sort.Search(len(track), func(i int) bool {
+		return !track[i].Time.Before(t)
+	})
+	if i == len(track) {
+		return 0, 0, false
+	}
+	b := track[i]
+	if b.Time.Equal(t) {
+		return b.Lat, b.Long, true
+	}
+	if i == 0 {
+		return 0, 0, false
+	}
+	a := track[i-1]
+
+	gap := b.Time.Sub(a.Time)
+	if gap > maxGap {
+		return 0, 0, false
+	}
+
+	f := float64(t.Sub(a.Time)) / float64(gap)
+
+	// take the short way across the 180th meridian
+	dlon := b.Long - a.Long
+	switch {
+	case dlon > 180:
+		dlon -= 360
+	case dlon < -180:
+		dlon += 360
+	}
+
+	lat = a.Lat + f*(b.Lat-a.Lat)
+	lon = a.Long + f*dlon
+	switch {
+	case lon > 180:
+		lon -= 360
+	case lon < -180:
+		lon += 360
+	}
+	return lat, lon, true
+}
+
+// Locate returns the position in track at the DateTimeOriginal of m,
+// interpolated between track points at most maxGap apart.
+//
+// The result may be stored using the GPSLatitude and GPSLongitude
+// attributes of m, and written into the file with metadata.Copy.
+//
+// Track times are UTC, whereas DateTimeOriginal values without a
+// time zone are in time.Local, according to the conventions of the
+// metadata package. Such values are matched correctly only if images
+// were taken in the time zone of the machine running Locate.
+func Locate(track []TrackPoint, m *metadata.Metadata, maxGap time.Duration) (lat, lon float64, ok bool) {
+	if m.DateTimeOriginal.Prec < 6 {
+		// time without seconds is useless
+		return 0, 0, false
+	}
+	return Interpolate(track, m.DateTimeOriginal.Time, maxGap)
+}
@@ -0,0 +1,72 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/geo"
+)
+
+func TestInterpolate(t *testing.T) {
+	t0 := time.Date(2017, 4, 1, 12, 0, 0, 0, time.UTC)
+	track := []geo.TrackPoint{
+		{Lat: 47.5, Long: 19.25, Time: t0},
+		{Lat: 47.6, Long: 19.35, Time: t0.Add(time.Minute)},
+		{Lat: 47.7, Long: 19.45, Time: t0.Add(time.Hour)},
+		{Lat: 10, Long: 179.5, Time: t0.Add(61 * time.Minute)},
+		{Lat: 10, Long: -179.5, Time: t0.Add(62 * time.Minute)},
+	}
+
+	tests := []struct {
+		t        time.Time
+		lat, lon float64
+		ok       bool
+	}{
+		{t0, 47.5, 19.25, true},
+		{t0.Add(30 * time.Second), 47.55, 19.3, true},
+		{t0.Add(time.Minute), 47.6, 19.35, true},
+
+		// across the 180th meridian
+		{t0.Add(61*time.Minute + 15*time.Second), 10, 179.75, true},
+		{t0.Add(61*time.Minute + 45*time.Second), 10, -179.75, true},
+
+		// gap too large
+		{t0.Add(30 * time.Minute), 0, 0, false},
+
+		// outside of track
+		{t0.Add(-time.Second), 0, 0, false},
+		{t0.Add(63 * time.Minute), 0, 0, false},
+	}
+	for _, tt := range tests {
+		lat, lon, ok := geo.Interpolate(track, tt.t, geo.DefaultMaxGap)
+		if ok != tt.ok || math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lon-tt.lon) > 1e-9 {
+			t.Errorf("Interpolate at %v: got %v, %v, %v; want %v, %v, %v",
+				tt.t, lat, lon, ok, tt.lat, tt.lon, tt.ok)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	t0 := time.Date(2017, 4, 1, 12, 0, 0, 0, time.UTC)
+	track := []geo.TrackPoint{
+		{Lat: 47.5, Long: 19.25, Time: t0},
+		{Lat: 47.6, Long: 19.35, Time: t0.Add(time.Minute)},
+	}
+
+	m := new(metadata.Metadata)
+	if _, _, ok := geo.Locate(track, m, geo.DefaultMaxGap); ok {
+		t.Error("Locate succeeded without DateTimeOriginal")
+	}
+
+	m.Set(metadata.DateTimeOriginal, "2017-04-01T14:00:30+02:00")
+	lat, lon, ok := geo.Locate(track, m, geo.DefaultMaxGap)
+	if !ok || math.Abs(lat-47.55) > 1e-9 || math.Abs(lon-19.3) > 1e-9 {
+		t.Errorf("Locate: got %v, %v, %v; want 47.55, 19.3, true", lat, lon, ok)
+	}
+
+	if _, _, ok := geo.Locate(track, m, 30*time.Second); ok {
+		t.Error("Locate succeeded with track points farther apart than maxGap")
+	}
+}
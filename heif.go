@@ -0,0 +1,136 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/tajtiattila/metadata/mp4"
+)
+
+// heifBrands are the ftyp brands recognised as HEIF.
+// AVIF files use the same item structure for metadata.
+var heifBrands = setOf("heic", "heix", "heif", "mif1", "avif", "avis")
+
+func isheif(p []byte) bool {
+	if !ismp4(p) {
+		return false
+	}
+
+	boxSize := int(binary.BigEndian.Uint32(p[:4]))
+	if boxSize > len(p) {
+		boxSize = len(p)
+	}
+
+	// major brand
+	if _, ok := heifBrands[string(p[8:12])]; ok {
+		return true
+	}
+
+	// compatible brands after minor version
+	for i := 16; i+4 <= boxSize; i += 4 {
+		if _, ok := heifBrands[string(p[i:i+4])]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+var errHEIFExif = errors.New("metadata: invalid HEIF Exif item")
+
+// xmpContentType is the content type of HEIF "mime" items holding XMP.
+const xmpContentType = "application/rdf+xml"
+
+// parseHEIF parses Exif and XMP metadata from the items
+// of the HEIF or AVIF file in r.
+func (s *parseState) parseHEIF(r io.Reader) (*Metadata, error) {
+	if !s.want("exif") && !s.want("xmp") {
+		return nil, ErrNoMeta
+	}
+
+	h, err := mp4.ParseHEIF(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta []*Metadata
+	var firstErr error
+	setErr := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if s.want("exif") {
+		for _, it := range h.ItemsOfType("Exif") {
+			if err := s.use(len(it.Data)); err != nil {
+				return nil, err
+			}
+			p, ok := heifExifPayload(it.Data)
+			if !ok {
+				setErr(errHEIFExif)
+				continue
+			}
+			m, err := FromExifBytes(p)
+			if err != nil {
+				setErr(err)
+			}
+			if m != nil {
+				meta = append(meta, m)
+				break
+			}
+		}
+	}
+
+	if s.want("xmp") {
+		for _, it := range h.ItemsOfType("mime") {
+			if it.ContentType != xmpContentType {
+				continue
+			}
+			if err := s.use(len(it.Data)); err != nil {
+				return nil, err
+			}
+			m, err := FromXMPBytes(it.Data)
+			if err != nil {
+				setErr(err)
+			}
+			if m != nil {
+				meta = append(meta, m)
+				break
+			}
+		}
+	}
+
+	if len(meta) == 0 {
+		if firstErr == nil {
+			firstErr = ErrNoMeta
+		}
+		return nil, firstErr
+	}
+
+	return Merge(meta...), firstErr
+}
+
+// heifExifPayload returns the TIFF header and IFDs from
+// the data of a HEIF Exif item.
+//
+// The Exif data is prefixed with a 32-bit offset to the TIFF header,
+// that is usually 6 for the "Exif\0\0" JPEG APP1 prefix.
+func heifExifPayload(p []byte) ([]byte, bool) {
+	if len(p) < 4 {
+		return nil, false
+	}
+	ofs := int64(binary.BigEndian.Uint32(p))
+	p = p[4:]
+	if int64(len(p)) < ofs {
+		return nil, false
+	}
+	p = p[ofs:]
+
+	// some writers omit the offset before "Exif\0\0"
+	if ofs == 0 && bytes.HasPrefix(p, jpegExifPfx) {
+		p = p[len(jpegExifPfx):]
+	}
+	return p, true
+}
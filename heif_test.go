@@ -0,0 +1,46 @@
+package metadata
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIsHEIF(t *testing.T) {
+	tests := []struct {
+		ftyp string
+		want bool
+	}{
+		{"\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic", true},
+		{"\x00\x00\x00\x18ftypmif1\x00\x00\x00\x00mif1heic", true},
+		{"\x00\x00\x00\x18ftypisom\x00\x00\x00\x00mif1isom", true},
+		{"\x00\x00\x00\x18ftypisom\x00\x00\x00\x00isomavc1", false},
+		{"\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom", false},
+		{"\x00\x00\x00\x18ftypavif\x00\x00\x00\x00mif1miaf", true},
+		{"\x00\x00\x00\x14ftypavis\x00\x00\x00\x00avis", true},
+	}
+	for _, tt := range tests {
+		if got := isheif([]byte(tt.ftyp)); got != tt.want {
+			t.Errorf("isheif(%q) = %v, want %v", tt.ftyp, got, tt.want)
+		}
+	}
+}
+
+func TestHEIFExifPayload(t *testing.T) {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tests := []struct {
+		data string
+		ok   bool
+	}{
+		{"\x00\x00\x00\x06Exif\x00\x00" + string(tiff), true},
+		{"\x00\x00\x00\x00" + string(tiff), true},
+		{"\x00\x00\x00\x00Exif\x00\x00" + string(tiff), true},
+		{"\x00\x00\x01\x00Exif\x00\x00" + string(tiff), false},
+		{"\x00\x00", false},
+	}
+	for _, tt := range tests {
+		got, ok := heifExifPayload([]byte(tt.data))
+		if ok != tt.ok || (ok && !bytes.Equal(got, tiff)) {
+			t.Errorf("heifExifPayload(%q) = %q, %v", tt.data, got, ok)
+		}
+	}
+}
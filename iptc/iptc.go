@@ -0,0 +1,223 @@
+// Package iptc implements a decoder for IPTC-IIM metadata
+// stored in the Photoshop (APP13) segment of JPEG files.
+package iptc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+)
+
+var (
+	NotFound  = errors.New("iptc: iptc data not found")
+	ErrFormat = errors.New("iptc: invalid data")
+)
+
+// Tag returns the key of the IIM dataset record:dataset
+// used in maps returned by Decode.
+func Tag(record, dataset int) int {
+	return record<<8 | dataset
+}
+
+// Common IIM datasets.
+var (
+	CodedCharacterSet = Tag(1, 90)
+
+	RecordVersion   = Tag(2, 0)
+	ObjectName      = Tag(2, 5)
+	Keywords        = Tag(2, 25)
+	DateCreated     = Tag(2, 55)
+	TimeCreated     = Tag(2, 60)
+	Byline          = Tag(2, 80)
+	City            = Tag(2, 90)
+	CountryName     = Tag(2, 101)
+	Headline        = Tag(2, 105)
+	Credit          = Tag(2, 110)
+	Source          = Tag(2, 115)
+	CopyrightNotice = Tag(2, 116)
+	Caption         = Tag(2, 120)
+)
+
+// utf8Escape is the value of CodedCharacterSet for UTF-8 text.
+var utf8Escape = []byte("\x1b%G")
+
+// Decode decodes IPTC-IIM data in p.
+//
+// The returned map has keys created using Tag,
+// and holds the values of repeated datasets (such as Keywords)
+// in the order of appearance.
+//
+// Text is decoded using the character set specified in the
+// CodedCharacterSet dataset, if it is UTF-8. Otherwise text is
+// assumed to be ISO-8859-1. Binary datasets (RecordVersion and
+// CodedCharacterSet) are returned unmodified.
+func Decode(p []byte) (map[int][]string, error) {
+	type dataset struct {
+		tag  int
+		data []byte
+	}
+	var v []dataset
+
+	isUTF8 := false
+	for len(p) != 0 {
+		if p[0] != 0x1c {
+			if len(v) != 0 && allZero(p) {
+				// padding after data
+				break
+			}
+			return nil, ErrFormat
+		}
+		if len(p) < 5 {
+			return nil, ErrFormat
+		}
+		tag := Tag(int(p[1]), int(p[2]))
+		n := int(binary.BigEndian.Uint16(p[3:]))
+		p = p[5:]
+
+		if n&0x8000 != 0 {
+			// extended dataset: n is the size of the length field
+			nl := n &^ 0x8000
+			if nl > 4 || len(p) < nl {
+				return nil, ErrFormat
+			}
+			n = 0
+			for _, b := range p[:nl] {
+				n = n<<8 | int(b)
+			}
+			p = p[nl:]
+		}
+
+		if n < 0 || len(p) < n {
+			return nil, ErrFormat
+		}
+		data := p[:n]
+		p = p[n:]
+
+		if tag == CodedCharacterSet {
+			isUTF8 = bytes.Equal(data, utf8Escape)
+		}
+		v = append(v, dataset{tag, data})
+	}
+
+	if len(v) == 0 {
+		return nil, NotFound
+	}
+
+	m := make(map[int][]string)
+	for _, d := range v {
+		var s string
+		switch {
+		case d.tag == RecordVersion, d.tag == CodedCharacterSet,
+			isUTF8:
+			s = string(d.data)
+		default:
+			s = latin1(d.data)
+		}
+		m[d.tag] = append(m[d.tag], s)
+	}
+	return m, nil
+}
+
+// DecodeJPEG decodes IPTC-IIM data from the JPEG in r.
+func DecodeJPEG(r io.Reader) (map[int][]string, error) {
+	p, err := FromJPEG(r)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(p)
+}
+
+// photoshopPfx is the prefix of APP13 Photoshop segments.
+var photoshopPfx = []byte("Photoshop 3.0\x00")
+
+// iimResource is the ID of the IPTC-IIM image resource.
+const iimResource = 0x0404
+
+// FromJPEG returns the raw IPTC-IIM data from the
+// Photoshop (APP13) segments in the JPEG in r.
+func FromJPEG(r io.Reader) ([]byte, error) {
+	j, err := xjpeg.NewScanner(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Photoshop data may span multiple segments
+	var ps []byte
+	for j.NextChunk() {
+		if !j.IsChunk(0xed, photoshopPfx) {
+			continue
+		}
+		_, p, err := j.ReadChunk()
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p[len(photoshopPfx):]...)
+	}
+	if err := j.Err(); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if ps == nil {
+		return nil, NotFound
+	}
+	return photoshopResource(ps, iimResource)
+}
+
+// photoshopResource returns the resource with the specified id
+// from the Photoshop image resource blocks in p.
+func photoshopResource(p []byte, id uint16) ([]byte, error) {
+	for len(p) != 0 {
+		if len(p) < 7 || string(p[:4]) != "8BIM" {
+			return nil, ErrFormat
+		}
+		rid := binary.BigEndian.Uint16(p[4:])
+
+		// name is a Pascal string padded to even length
+		nlen := 1 + int(p[6])
+		nlen += nlen & 1
+		p = p[6:]
+		if len(p) < nlen+4 {
+			return nil, ErrFormat
+		}
+		p = p[nlen:]
+
+		size := int64(binary.BigEndian.Uint32(p))
+		p = p[4:]
+		if int64(len(p)) < size {
+			return nil, ErrFormat
+		}
+		data := p[:size]
+
+		if rid == id {
+			return data, nil
+		}
+
+		// data is padded to even length
+		size += size & 1
+		if int64(len(p)) < size {
+			break
+		}
+		p = p[size:]
+	}
+	return nil, NotFound
+}
+
+func latin1(p []byte) string {
+	r := make([]rune, len(p))
+	for i, b := range p {
+		r[i] = rune(b)
+	}
+	return string(r)
+}
+
+func allZero(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
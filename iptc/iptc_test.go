@@ -0,0 +1,139 @@
+package iptc_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/tajtiattila/metadata/iptc"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+)
+
+func TestDecode(t *testing.T) {
+	p := iim(
+		ds(2, 0, "\x00\x04"),
+		ds(2, 5, "Object"),
+		ds(2, 25, "one"),
+		ds(2, 25, "k\xe9t"), // ISO-8859-1
+		ds(2, 120, "Caption"),
+	)
+	m, err := iptc.Decode(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int][]string{
+		iptc.RecordVersion: {"\x00\x04"},
+		iptc.ObjectName:    {"Object"},
+		iptc.Keywords:      {"one", "két"},
+		iptc.Caption:       {"Caption"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+}
+
+func TestDecodeUTF8(t *testing.T) {
+	p := iim(
+		ds(1, 90, "\x1b%G"),
+		ds(2, 25, "két"),
+		ds(2, 120, "Árvíztűrő tükörfúrógép"),
+	)
+	m, err := iptc.Decode(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m[iptc.Keywords]; len(got) != 1 || got[0] != "két" {
+		t.Errorf("got keywords %q", got)
+	}
+	if got := m[iptc.Caption]; len(got) != 1 || got[0] != "Árvíztűrő tükörfúrógép" {
+		t.Errorf("got caption %q", got)
+	}
+}
+
+func TestDecodeExtended(t *testing.T) {
+	long := string(bytes.Repeat([]byte("x"), 40000))
+	p := []byte{0x1c, 2, 120, 0x80, 0x04}
+	p = append(p, u32(len(long))...)
+	p = append(p, long...)
+
+	m, err := iptc.Decode(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m[iptc.Caption]; len(got) != 1 || got[0] != long {
+		t.Error("extended dataset mismatch")
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, p := range [][]byte{
+		[]byte("garbage"),
+		{0x1c, 2, 120, 0, 10, 'a'},
+		{0x1c, 2},
+	} {
+		if _, err := iptc.Decode(p); err == nil {
+			t.Errorf("%q decoded without error", p)
+		}
+	}
+}
+
+func TestDecodeJPEG(t *testing.T) {
+	data := iim(ds(2, 25, "a"), ds(2, 25, "b"), ds(2, 80, "Byline"))
+
+	var ps []byte
+	ps = append(ps, "Photoshop 3.0\x00"...)
+	ps = append(ps, resource(0x03ed, "", []byte("resolution"))...)
+	ps = append(ps, resource(0x0404, "IIM", data)...)
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	if err := xjpeg.WriteChunk(&buf, 0xed, ps); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0xff, 0xd9})
+
+	m, err := iptc.DecodeJPEG(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int][]string{
+		iptc.Keywords: {"a", "b"},
+		iptc.Byline:   {"Byline"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+}
+
+func iim(v ...[]byte) []byte {
+	return bytes.Join(v, nil)
+}
+
+func ds(record, dataset int, value string) []byte {
+	p := []byte{0x1c, byte(record), byte(dataset), 0, 0}
+	binary.BigEndian.PutUint16(p[3:], uint16(len(value)))
+	return append(p, value...)
+}
+
+func resource(id uint16, name string, data []byte) []byte {
+	p := []byte("8BIM")
+	p = append(p, byte(id>>8), byte(id))
+	p = append(p, byte(len(name)))
+	p = append(p, name...)
+	if len(p)%2 != 0 {
+		p = append(p, 0)
+	}
+	p = append(p, u32(len(data))...)
+	p = append(p, data...)
+	if len(data)%2 != 0 {
+		p = append(p, 0)
+	}
+	return p
+}
+
+func u32(v int) []byte {
+	p := make([]byte, 4)
+	binary.BigEndian.PutUint32(p, uint32(v))
+	return p
+}
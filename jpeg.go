@@ -2,86 +2,108 @@ package metadata
 
 import (
 	"io"
+	"strconv"
 
 	xjpeg "github.com/tajtiattila/metadata/jpeg"
 )
 
 var jpegExifPfx = []byte("Exif\x00\x00")
 var jpegXMPPfx = []byte("http://ns.adobe.com/xap/1.0/\x00")
+var jpegAdobePfx = []byte("Adobe")
 
-func parseJpeg(r io.Reader) (*Metadata, error) {
+// parseJpeg parses Exif and XMP metadata from the APP1 chunks in r,
+// and the color transform from the Adobe (APP14) chunk.
+//
+// Only the first valid Exif and XMP chunks are used, later duplicates
+// are skipped rather than merged. Chunks that fail to decode are
+// skipped as well, so that metadata from a later chunk are still used.
+// The first error encountered is returned along with the metadata
+// decoded successfully.
+//
+// Parsing stops once the wanted chunks are found. The Adobe chunk is
+// wanted with the "jpeg" format, in which case all chunks up to the
+// image data are scanned in files without an Adobe chunk.
+func (s *parseState) parseJpeg(r io.Reader) (*Metadata, error) {
 	j, err := xjpeg.NewScanner(r)
 	if err != nil {
 		return nil, err
 	}
 
-	var ex, xmp []byte
-	for (ex == nil || xmp == nil) && j.NextChunk() {
-		p := j.Bytes()
-		if len(p) < 4 || p[0] != 0xff || p[1] != 0xe1 {
+	var meta []*Metadata
+	var firstErr error
+	setErr := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	// skip unwanted formats
+	haveExif, haveXMP := !s.want("exif"), !s.want("xmp")
+	haveAdobe := !s.want("jpeg")
+	for (!haveExif || !haveXMP || !haveAdobe) && j.NextChunk() {
+		if !haveAdobe && j.IsChunk(0xee, jpegAdobePfx) {
+			haveAdobe = true
+			_, p, err := j.ReadChunk()
+			if err != nil {
+				setErr(err)
+				break
+			}
+			if transform, ok := xjpeg.ParseAdobeAPP14(p); ok {
+				m := new(Metadata)
+				m.Set(AdobeTransform, strconv.Itoa(int(transform)))
+				m.setSource("jpeg")
+				meta = append(meta, m)
+			}
 			continue
 		}
 
-		var pdata *[]byte
+		if j.Marker() != 0xe1 {
+			continue
+		}
+
+		var have *bool
 		var trim int
+		var decode func(p []byte) (*Metadata, error)
 		switch {
-		case ex == nil && j.IsChunk(0xe1, jpegExifPfx):
-			pdata, trim = &ex, len(jpegExifPfx)
-		case xmp == nil && j.IsChunk(0xe1, jpegXMPPfx):
-			pdata, trim = &xmp, len(jpegXMPPfx)
+		case !haveExif && j.IsChunk(0xe1, jpegExifPfx):
+			have, trim, decode = &haveExif, len(jpegExifPfx), FromExifBytes
+		case !haveXMP && j.IsChunk(0xe1, jpegXMPPfx):
+			have, trim, decode = &haveXMP, len(jpegXMPPfx), FromXMPBytes
 		}
 
-		if pdata == nil {
+		if have == nil {
 			continue
 		}
 
 		_, p, err := j.ReadChunk()
+		if err == nil {
+			err = s.use(len(p))
+		}
 		if err != nil {
-			return nil, err
+			setErr(err)
+			break
 		}
 
-		*pdata = p[trim:]
-	}
-
-	if ex == nil && xmp == nil {
-		if err = j.Err(); err != nil {
-			return nil, err
+		m, err := decode(p[trim:])
+		if err != nil {
+			setErr(err)
 		}
-		return nil, ErrNoMeta
-	}
-
-	var meta []*Metadata
-	var firstErr error
-
-	if ex != nil {
-		m, err := FromExifBytes(ex)
 		if m != nil {
 			meta = append(meta, m)
-		}
-		if err != nil {
-			firstErr = err
+			*have = true
 		}
 	}
 
-	if xmp != nil {
-		m, err := FromXMPBytes(xmp)
-		if m != nil {
-			meta = append(meta, m)
-		}
-		if err != nil {
-			if firstErr == nil {
-				firstErr = err
-			}
-		}
+	if err := j.Err(); err != nil && (err != io.EOF || len(meta) == 0) {
+		setErr(err)
 	}
 
 	if len(meta) == 0 {
-		err := firstErr
-		if err == nil {
-			err = ErrNoMeta
+		if firstErr == nil {
+			firstErr = ErrNoMeta
 		}
-		return nil, err
+		return nil, firstErr
 	}
 
-	return Merge(meta...), nil
+	return Merge(meta...), firstErr
 }
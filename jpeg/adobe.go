@@ -0,0 +1,47 @@
+package jpeg
+
+import "io"
+
+// Color transform values of the Adobe (APP14) segment.
+const (
+	AdobeTransformNone  = 0 // RGB or CMYK
+	AdobeTransformYCbCr = 1 // YCbCr
+	AdobeTransformYCCK  = 2 // YCCK
+)
+
+// adobeLen is the length of the Adobe segment payload:
+// "Adobe", version (2 bytes), flags0 (2 bytes),
+// flags1 (2 bytes) and transform (1 byte).
+const adobeLen = 12
+
+// ReadAdobeAPP14 returns the color transform
+// from the Adobe (APP14) segment of the JPEG in r.
+// It reports ok == false if r has no valid Adobe segment.
+func ReadAdobeAPP14(r io.Reader) (transform byte, ok bool) {
+	j, err := NewScanner(r)
+	if err != nil {
+		return 0, false
+	}
+
+	for j.NextChunk() {
+		if !j.IsChunk(0xee, adobePfx) {
+			continue
+		}
+		_, p, err := j.ReadChunk()
+		if err != nil {
+			return 0, false
+		}
+		return ParseAdobeAPP14(p)
+	}
+	return 0, false
+}
+
+// ParseAdobeAPP14 returns the color transform from p,
+// the payload of an Adobe (APP14) segment
+// as returned by Scanner.ReadChunk.
+func ParseAdobeAPP14(p []byte) (transform byte, ok bool) {
+	if len(p) < adobeLen || string(p[:len(adobePfx)]) != string(adobePfx) {
+		return 0, false
+	}
+	return p[adobeLen-1], true
+}
@@ -0,0 +1,42 @@
+package jpeg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadAdobeAPP14(t *testing.T) {
+	jpeg := func(app14 string) []byte {
+		var buf bytes.Buffer
+		buf.Write([]byte{0xff, 0xd8})
+		if err := WriteChunk(&buf, 0xe0, []byte("JFIF\x00")); err != nil {
+			t.Fatal(err)
+		}
+		if app14 != "" {
+			if err := WriteChunk(&buf, 0xee, []byte(app14)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0x55, 0xff, 0xd9})
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		app14     string
+		transform byte
+		ok        bool
+	}{
+		{"Adobe\x00\x64\x00\x00\x00\x00\x02", AdobeTransformYCCK, true},
+		{"Adobe\x00\x64\x80\x00\x00\x00\x01", AdobeTransformYCbCr, true},
+		{"Adobe\x00\x65\x00\x00\x00\x00\x00", AdobeTransformNone, true},
+		{"Adobe\x00\x64\x00\x00\x00\x00", 0, false}, // short
+		{"Other\x00\x64\x00\x00\x00\x00\x02", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		transform, ok := ReadAdobeAPP14(bytes.NewReader(jpeg(tt.app14)))
+		if transform != tt.transform || ok != tt.ok {
+			t.Errorf("%q: got %v, %v; want %v, %v", tt.app14, transform, ok, tt.transform, tt.ok)
+		}
+	}
+}
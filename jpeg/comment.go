@@ -0,0 +1,108 @@
+package jpeg
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// comMarker is the marker of COM (comment) segments.
+const comMarker = 0xfe
+
+// ReadComments returns the text of the COM (comment) segments
+// found before the start of scan in the JPEG in r,
+// in the order of appearance.
+//
+// Comments are decoded as UTF-8 if they are valid UTF-8,
+// otherwise they are assumed to be ISO-8859-1.
+// Trailing NUL bytes are removed.
+func ReadComments(r io.Reader) ([]string, error) {
+	j, err := NewScanner(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var v []string
+	for j.NextChunk() {
+		if j.Marker() != comMarker {
+			continue
+		}
+		_, p, err := j.ReadChunk()
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, commentText(p))
+	}
+	if err := j.Err(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return v, nil
+}
+
+// WriteComment writes a COM segment with comment to w.
+func WriteComment(w io.Writer, comment string) error {
+	return WriteChunk(w, comMarker, []byte(comment))
+}
+
+// InsertComment copies the JPEG in r to w with a COM segment
+// holding comment inserted after the existing comments and
+// APPn segments such as JFIF, Exif or XMP.
+//
+// Other content is copied unmodified.
+func InsertComment(w io.Writer, r io.Reader, comment string) error {
+	j, err := NewScanner(r)
+	if err != nil {
+		return err
+	}
+
+	done := false
+	for j.Next() {
+		m := j.Marker()
+		if !done && j.StartChunk() && !isHeaderMarker(m) {
+			if err := WriteComment(w, comment); err != nil {
+				return err
+			}
+			done = true
+		}
+
+		seg, err := j.ReadSegment()
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(seg); err != nil {
+			return err
+		}
+	}
+	if err := j.Err(); err != nil {
+		return err
+	}
+
+	if !done {
+		if err := WriteComment(w, comment); err != nil {
+			return err
+		}
+	}
+
+	// copy bytes unread so far, such as actual image data
+	_, err = io.Copy(w, j.Reader())
+	return err
+}
+
+// isHeaderMarker reports whether m is an APPn or COM marker.
+func isHeaderMarker(m byte) bool {
+	return (0xe0 <= m && m <= 0xef) || m == comMarker
+}
+
+func commentText(p []byte) string {
+	p = bytes.TrimRight(p, "\x00")
+	if utf8.Valid(p) {
+		return string(p)
+	}
+
+	// ISO-8859-1
+	r := make([]rune, len(p))
+	for i, b := range p {
+		r[i] = rune(b)
+	}
+	return string(r)
+}
@@ -0,0 +1,64 @@
+package jpeg
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	for _, c := range []struct {
+		marker byte
+		data   string
+	}{
+		{0xe0, "JFIF\x00"},
+		{0xfe, "first"},
+		{0xe1, "Exif\x00\x00"},
+		{0xfe, "\xe1rv\xedzt\xfbr\xf5"}, // Latin-1
+		{0xfe, "árvíztűrő\x00"},
+		{0xdb, "quant"},
+	} {
+		if err := WriteChunk(&buf, c.marker, []byte(c.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0x55, 0xff, 0xd9})
+	src := buf.Bytes()
+
+	got, err := ReadComments(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal("ReadComments:", err)
+	}
+	want := []string{"first", "árvíztûrõ", "árvíztűrő"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadComments got %q, want %q", got, want)
+	}
+
+	var out bytes.Buffer
+	if err := InsertComment(&out, bytes.NewReader(src), "new"); err != nil {
+		t.Fatal("InsertComment:", err)
+	}
+
+	got, err = ReadComments(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal("ReadComments:", err)
+	}
+	want = append(want, "new")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadComments after InsertComment got %q, want %q", got, want)
+	}
+
+	// comment should be right before the quantization table
+	com := []byte("\xff\xfe\x00\x05new\xff\xdb")
+	if !bytes.Contains(out.Bytes(), com) {
+		t.Error("inserted comment not found before DQT")
+	}
+	if !bytes.HasSuffix(out.Bytes(), src[len(src)-7:]) {
+		t.Error("image data not copied")
+	}
+	if out.Len() != len(src)+7 {
+		t.Errorf("got %d bytes, want %d", out.Len(), len(src)+7)
+	}
+}
@@ -1,6 +1,7 @@
 package jpeg_test
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -75,3 +76,65 @@ func ExampleScanner_copy() {
 		fmt.Fprintf(os.Stderr, "write error: %v", werr)
 	}
 }
+
+// Use NextChunk to list the chunks in a JPEG file.
+func ExampleScanner_NextChunk() {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8}) // start of image
+	jpeg.WriteChunk(&buf, 0xe1, []byte("Exif\x00\x00"))
+	buf.Write([]byte{0x00, 0x00}) // padding
+	jpeg.WriteChunk(&buf, 0xfe, []byte("comment"))
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02}) // start of scan
+
+	scanner, err := jpeg.NewScanner(&buf)
+	if err != nil {
+		fmt.Printf("jpeg error: %v", err)
+		return
+	}
+
+	for scanner.NextChunk() {
+		isExif := scanner.IsChunk(0xe1, []byte("Exif\x00\x00"))
+		fmt.Printf("marker %#02x, %d bytes, exif: %v\n",
+			scanner.Marker(), scanner.Len(), isExif)
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("jpeg error: %v", err)
+	}
+
+	// Output:
+	// marker 0xe1, 10 bytes, exif: true
+	// marker 0xfe, 11 bytes, exif: false
+}
+
+// Use ReadSegment to copy the JPEG header segments.
+func ExampleScanner_ReadSegment() {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8}) // start of image
+	buf.Write([]byte{0x00, 0x00}) // padding
+	jpeg.WriteChunk(&buf, 0xfe, []byte("comment"))
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02}) // start of scan
+
+	scanner, err := jpeg.NewScanner(&buf)
+	if err != nil {
+		fmt.Printf("jpeg error: %v", err)
+		return
+	}
+
+	for scanner.Next() {
+		seg, err := scanner.ReadSegment()
+		if err != nil {
+			break
+		}
+		fmt.Printf("% x\n", seg)
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("jpeg error: %v", err)
+	}
+
+	// Output:
+	// ff d8
+	// 00 00
+	// ff fe 00 09 63 6f 6d 6d 65 6e 74
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 )
 
 var (
@@ -25,7 +26,8 @@ type Scanner struct {
 	r, w int // read and write position
 
 	startChunk bool
-	chunkLen   int // chunk bytes left
+	marker     byte // marker of the current chunk
+	chunkLen   int  // chunk bytes left
 
 	p []byte
 
@@ -92,6 +94,7 @@ func (j *Scanner) Next() bool {
 
 	j.p = nil
 	j.startChunk = false
+	j.marker = 0
 
 	// process remaining chunk data
 	if j.chunkLen > 0 {
@@ -164,7 +167,7 @@ func (j *Scanner) Next() bool {
 		if l == -1 {
 			// invalid chunk length: skip marker and size
 			j.formatError++
-			s := j.r + 4
+			s := j.r
 			j.r += 4
 			j.p = j.buf[s:j.r]
 			if j.r == j.w {
@@ -173,6 +176,7 @@ func (j *Scanner) Next() bool {
 			return true
 		}
 		j.startChunk = true
+		j.marker = j.buf[j.r+1]
 		if j.r+l <= j.w {
 			j.p = j.buf[j.r : j.r+l]
 			j.r += l
@@ -192,8 +196,24 @@ func (j *Scanner) StartChunk() bool {
 	return j.startChunk
 }
 
-// NextChunk scans for the next chunk in the stream.
+// Marker returns the marker of the current chunk,
+// such as 0xe0 for APP0, 0xe1 for APP1 or 0xfe for COM.
+// It returns 0 if the last call to Next()
+// found padding or data that is not a chunk.
+func (j *Scanner) Marker() byte {
+	return j.marker
+}
+
+// NextChunk advances the Scanner to the next chunk in the stream,
+// skipping padding and other data that is not a chunk.
+//
+// The unread part of the current chunk is skipped using Seek
+// if the underlying reader is an io.Seeker and the chunk is large.
+//
+// Like Next, it returns false at the start of scan
+// or when an error has been encountered.
 func (j *Scanner) NextChunk() bool {
+	j.skipChunk()
 	for j.Next() {
 		if j.StartChunk() {
 			return true
@@ -202,6 +222,28 @@ func (j *Scanner) NextChunk() bool {
 	return false
 }
 
+// skipChunk seeks past the unread part of the current chunk
+// if it is larger than the buffer, and the underlying reader
+// is an io.Seeker. Smaller chunks are left to be read by Next.
+func (j *Scanner) skipChunk() {
+	if j.err != nil || j.chunkLen <= len(j.buf) {
+		return
+	}
+	s, ok := j.rr.(io.Seeker)
+	if !ok {
+		return
+	}
+
+	// when j.chunkLen > 0 the buffer is empty,
+	// and the rest of the chunk is unread in j.rr
+	if _, err := s.Seek(int64(j.chunkLen), io.SeekCurrent); err != nil {
+		// not seekable, such as a pipe: read the chunk instead
+		return
+	}
+	j.p = nil
+	j.chunkLen = 0
+}
+
 // ReadChunk reads the current chunk in a new byte slice,
 // or returns ErrNoChunk if the data at the current position
 // is not a segment with a (possibly empty) payload.
@@ -220,8 +262,11 @@ func (j *Scanner) ReadChunk() (marker byte, payload []byte, err error) {
 	return marker, segment[4:], nil
 }
 
-// IsChunk checks if the Scanner is at the start of a chunk
-// having marker and prefix.
+// IsChunk reports whether the Scanner is at the start of a chunk
+// having marker with its payload starting with prefix.
+//
+// IsChunk must be called before the current chunk is read
+// using ReadChunk or ReadSegment, otherwise it returns false.
 // IsChunk panics if prefix is longer than MaxPrefixLen.
 func (j *Scanner) IsChunk(marker byte, prefix []byte) bool {
 	if len(prefix) > MaxPrefixLen {
@@ -236,13 +281,16 @@ func (j *Scanner) IsChunk(marker byte, prefix []byte) bool {
 	return bytes.HasPrefix(j.p[4:], prefix)
 }
 
-// ReadSegment reads the current section the into
+// ReadSegment reads the current segment into
 // a new byte slice after calling Next.
-// The returned slice will always have a prefix of Bytes().
-// ReadSegment returns valid sections as a single byte slice.
 //
-// ReadSegment returns a copy of Bytes()
-// if the Next() found padding or data without payload.
+// For chunks the returned slice holds the raw segment
+// including the marker, the length and the payload.
+// If Next found padding or data without payload,
+// ReadSegment returns a copy of Bytes() unchanged.
+//
+// Writing the result of ReadSegment after each call of Next
+// therefore reproduces the input up to the start of scan.
 func (j *Scanner) ReadSegment() ([]byte, error) {
 	if j.err != nil {
 		return nil, j.err
@@ -298,6 +346,36 @@ func (j *Scanner) Reader() io.Reader {
 	return io.MultiReader(bytes.NewReader(j.buf[j.r:j.w]), j.rr)
 }
 
+// DiscardRest discards the data remaining in the underlying reader
+// after the start of scan, and returns the number of bytes discarded.
+// If the underlying reader is an io.Seeker, it is used to seek to
+// the end instead of reading the data.
+//
+// DiscardRest does nothing if the start of scan has not been reached.
+func (j *Scanner) DiscardRest() (int64, error) {
+	if j.scanState != scanStateScan {
+		return 0, nil
+	}
+
+	n := int64(j.w - j.r)
+	j.r, j.w = 0, 0
+
+	if s, ok := j.rr.(io.Seeker); ok {
+		cur, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return n, err
+		}
+		end, err := s.Seek(0, io.SeekEnd)
+		if err != nil {
+			return n, err
+		}
+		return n + end - cur, nil
+	}
+
+	m, err := io.CopyBuffer(ioutil.Discard, j.rr, j.buf)
+	return n + m, err
+}
+
 // nextMarker scans for the next marker.
 // It returns either the marker position or an
 // index near p
@@ -102,12 +102,12 @@ func testScannerSegments(t *testing.T, p []byte) {
 		if s.StartChunk() {
 			if len(seg) < 4 ||
 				seg[0] != 0xff || seg[1] == 0 || seg[1] == 0xff {
-				t.Error("error: testScannerSegments invalid segment %x:", seg)
+				t.Errorf("error: testScannerSegments invalid segment %x:", seg)
 				return
 			}
 			l := int(seg[2])<<8 + int(seg[3])
 			if l+2 != len(seg) {
-				t.Error("error: testScannerSegments segment len: want %v got %v", l+2, len(seg))
+				t.Errorf("error: testScannerSegments segment len: want %v got %v", l+2, len(seg))
 			}
 		}
 		t.Logf("%-5v %4d %.32x", s.StartChunk(), len(seg), seg)
@@ -131,6 +131,131 @@ func testScannerSegments(t *testing.T, p []byte) {
 	}
 }
 
+func TestScannerMarker(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	for _, m := range []byte{0xe0, 0xe1, 0xfe} {
+		if err := WriteChunk(&buf, m, []byte("chunk data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0xff, 0xd9})
+
+	s, err := NewScanner(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	for s.Next() {
+		m := s.Marker()
+		if s.StartChunk() != (m != 0) {
+			t.Errorf("Marker %#02x with StartChunk %v", m, s.StartChunk())
+		}
+		if _, err := s.ReadSegment(); err != nil {
+			t.Fatal(err)
+		}
+		if s.Marker() != m {
+			t.Error("Marker changed by ReadSegment")
+		}
+		got = append(got, m)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{0, 0xe0, 0xe1, 0xfe}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got markers %x, want %x", got, want)
+	}
+}
+
+func TestReadSegmentRaw(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	if err := WriteChunk(&buf, 0xe0, []byte("JFIF\x00")); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write([]byte{0x00, 0x01, 0x02, 0xff, 0xff}) // padding
+	if err := WriteChunk(&buf, 0xe1, []byte("Exif\x00\x00")); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write([]byte{0xff, 0xe2, 0x00, 0x01}) // invalid length
+	if err := WriteChunk(&buf, 0xfe, []byte("comment")); err != nil {
+		t.Fatal(err)
+	}
+	head := append([]byte(nil), buf.Bytes()...)
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0xff, 0xd9})
+
+	s, err := NewScanner(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	var chunks int
+	for s.Next() {
+		if s.StartChunk() {
+			chunks++
+		}
+		p, err := s.ReadSegment()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p...)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, head) {
+		t.Errorf("ReadSegment data differ from source:\ngot  % x\nwant % x", got, head)
+	}
+	if chunks != 3 {
+		t.Errorf("got %d chunks, want 3", chunks)
+	}
+}
+
+func TestScannerDiscardRest(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	if err := WriteChunk(&buf, 0xe1, []byte("chunk data")); err != nil {
+		t.Fatal(err)
+	}
+	scan := append([]byte{0xff, 0xda, 0x00, 0x02}, bytes.Repeat([]byte{0x55}, 10000)...)
+	scan = append(scan, 0xff, 0xd9)
+	buf.Write(scan)
+
+	readers := map[string]func() io.Reader{
+		"seeker": func() io.Reader { return bytes.NewReader(buf.Bytes()) },
+		"reader": func() io.Reader { return struct{ io.Reader }{bytes.NewReader(buf.Bytes())} },
+	}
+	for name, rf := range readers {
+		s, err := NewScanner(rf())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n, err := s.DiscardRest(); n != 0 || err != nil {
+			t.Errorf("%s: DiscardRest before scan returned %d, %v", name, n, err)
+		}
+
+		for s.Next() {
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		n, err := s.DiscardRest()
+		if err != nil {
+			t.Errorf("%s: DiscardRest error: %v", name, err)
+		}
+		if n != int64(len(scan)) {
+			t.Errorf("%s: DiscardRest returned %d, want %d", name, n, len(scan))
+		}
+	}
+}
+
 func dumpBytes(w io.Writer, p []byte) {
 	for i := 0; i < len(p); i += 32 {
 		fmt.Fprintf(w, "% .32x\n", p[i:])
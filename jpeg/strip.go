@@ -0,0 +1,61 @@
+package jpeg
+
+import "io"
+
+var (
+	jfifPfx  = []byte("JFIF\x00")
+	iccPfx   = []byte("ICC_PROFILE\x00")
+	adobePfx = []byte("Adobe")
+)
+
+// StripOptions specifies options for StripMetadataWithOptions.
+type StripOptions struct {
+	// KeepICC keeps the ICC color profile in APP2 segments.
+	KeepICC bool
+}
+
+// StripMetadata copies the JPEG in r to w with all APPn segments
+// removed, except the JFIF (APP0) and Adobe (APP14) segments that
+// may be needed to decode the image correctly.
+//
+// It removes Exif, XMP, IPTC, ICC profiles and thumbnails.
+// Comments and other content are copied unmodified.
+func StripMetadata(w io.Writer, r io.Reader) error {
+	return StripMetadataWithOptions(w, r, StripOptions{})
+}
+
+// StripMetadataWithOptions is like StripMetadata
+// but uses the specified options.
+func StripMetadataWithOptions(w io.Writer, r io.Reader, opt StripOptions) error {
+	j, err := NewScanner(r)
+	if err != nil {
+		return err
+	}
+
+	for j.Next() {
+		keep := true
+		if m := j.Marker(); j.StartChunk() && 0xe0 <= m && m <= 0xef {
+			keep = j.IsChunk(0xe0, jfifPfx) ||
+				j.IsChunk(0xee, adobePfx) ||
+				(opt.KeepICC && j.IsChunk(0xe2, iccPfx))
+		}
+
+		seg, err := j.ReadSegment()
+		if err != nil {
+			return err
+		}
+		if !keep {
+			continue
+		}
+		if _, err := w.Write(seg); err != nil {
+			return err
+		}
+	}
+	if err := j.Err(); err != nil {
+		return err
+	}
+
+	// copy bytes unread so far, such as actual image data
+	_, err = io.Copy(w, j.Reader())
+	return err
+}
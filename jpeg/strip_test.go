@@ -0,0 +1,79 @@
+package jpeg
+
+import (
+	"bytes"
+	"image"
+	stdjpeg "image/jpeg"
+	"testing"
+)
+
+func TestStripMetadata(t *testing.T) {
+	var img bytes.Buffer
+	if err := stdjpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 16, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	p := img.Bytes()
+
+	// insert metadata after SOI
+	var buf bytes.Buffer
+	buf.Write(p[:2])
+	for _, c := range []struct {
+		marker byte
+		data   string
+	}{
+		{0xe0, "JFIF\x00\x01\x02\x00\x00\x01\x00\x01\x00\x00"},
+		{0xe1, "Exif\x00\x00exif"},
+		{0xe1, "http://ns.adobe.com/xap/1.0/\x00xmp"},
+		{0xe2, "ICC_PROFILE\x00\x01\x01icc"},
+		{0xed, "Photoshop 3.0\x00iptc"},
+		{0xfe, "comment"},
+	} {
+		if err := WriteChunk(&buf, c.marker, []byte(c.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.Write(p[2:])
+	src := buf.Bytes()
+
+	markers := func(p []byte) map[byte]int {
+		j, err := NewScanner(bytes.NewReader(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[byte]int)
+		for j.NextChunk() {
+			m[j.Marker()]++
+		}
+		return m
+	}
+
+	for _, keepICC := range []bool{false, true} {
+		var out bytes.Buffer
+		err := StripMetadataWithOptions(&out, bytes.NewReader(src), StripOptions{KeepICC: keepICC})
+		if err != nil {
+			t.Fatal("StripMetadata:", err)
+		}
+
+		if _, err := stdjpeg.Decode(bytes.NewReader(out.Bytes())); err != nil {
+			t.Errorf("KeepICC=%v: decode stripped image: %v", keepICC, err)
+		}
+
+		m := markers(out.Bytes())
+		if m[0xe0] != 1 {
+			t.Errorf("KeepICC=%v: got %d APP0 segments, want 1", keepICC, m[0xe0])
+		}
+		if m[0xe1] != 0 || m[0xed] != 0 {
+			t.Errorf("KeepICC=%v: APP1 or APP13 not stripped", keepICC)
+		}
+		wantICC := 0
+		if keepICC {
+			wantICC = 1
+		}
+		if m[0xe2] != wantICC {
+			t.Errorf("KeepICC=%v: got %d APP2 segments, want %d", keepICC, m[0xe2], wantICC)
+		}
+		if m[0xfe] != 1 {
+			t.Errorf("KeepICC=%v: comment not kept", keepICC)
+		}
+	}
+}
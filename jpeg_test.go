@@ -0,0 +1,322 @@
+package metadata_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+)
+
+func TestParseJpegCorruptExif(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+
+	x := append([]byte(nil), jpegExifPfx...)
+	x = append(x, "MM\x00\x2a\xff\xff\xff\xffcorrupt"...)
+	if err := xjpeg.WriteChunk(&buf, 0xe1, x); err != nil {
+		t.Fatal(err)
+	}
+
+	xmp := append([]byte(nil), jpegXMPPfx...)
+	xmp = append(xmp, testXMP...)
+	if err := xjpeg.WriteChunk(&buf, 0xe1, xmp); err != nil {
+		t.Fatal(err)
+	}
+
+	// start of scan
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0x00, 0xff, 0xd9})
+
+	m, err := metadata.Parse(bytes.NewReader(buf.Bytes()))
+	if m == nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err == nil {
+		t.Error("corrupt Exif error missing")
+	}
+
+	if m.Make != "TestMake" {
+		t.Errorf("got Make %q, want %q", m.Make, "TestMake")
+	}
+	if m.Get(metadata.DateTimeOriginal) != "2017-04-01T12:34:56" {
+		t.Errorf("got DateTimeOriginal %q", m.Get(metadata.DateTimeOriginal))
+	}
+}
+
+func TestParseJpegMaxMetaBytes(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+
+	// XMP padded to near the maximum chunk size
+	xmp := append([]byte(nil), jpegXMPPfx...)
+	xmp = append(xmp, testXMP...)
+	xmp = append(xmp, bytes.Repeat([]byte(" "), 65000-len(xmp))...)
+	if err := xjpeg.WriteChunk(&buf, 0xe1, xmp); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0x00, 0xff, 0xd9})
+
+	m, err := metadata.ParseWithOptions(bytes.NewReader(buf.Bytes()),
+		metadata.Options{MaxMetaBytes: 1024})
+	if err != metadata.ErrMetaTooLarge {
+		t.Errorf("got error %v, want %v", err, metadata.ErrMetaTooLarge)
+	}
+	if m != nil {
+		t.Errorf("got metadata %v", m.Attr)
+	}
+
+	for _, max := range []int{0, 1 << 20} {
+		m, err := metadata.ParseWithOptions(bytes.NewReader(buf.Bytes()),
+			metadata.Options{MaxMetaBytes: max})
+		if err != nil {
+			t.Fatalf("MaxMetaBytes %d: %v", max, err)
+		}
+		if m.Make != "TestMake" {
+			t.Errorf("MaxMetaBytes %d: got Make %q, want %q", max, m.Make, "TestMake")
+		}
+	}
+}
+
+func TestParseJpegWant(t *testing.T) {
+	p := testWantJpeg(t)
+
+	tests := []struct {
+		want    []string
+		make    string
+		hasTime bool
+	}{
+		{nil, "TestMake", true},
+		{[]string{"exif"}, "ExifMake", false},
+		{[]string{"xmp"}, "TestMake", true},
+	}
+	for _, tt := range tests {
+		m, err := metadata.ParseWithOptions(bytes.NewReader(p), metadata.Options{Want: tt.want})
+		if err != nil {
+			t.Fatalf("Want %v: %v", tt.want, err)
+		}
+		if m.Make != tt.make {
+			t.Errorf("Want %v: got Make %q, want %q", tt.want, m.Make, tt.make)
+		}
+		if _, ok := m.Attr[metadata.DateTimeOriginal]; ok != tt.hasTime {
+			t.Errorf("Want %v: DateTimeOriginal present is %v", tt.want, ok)
+		}
+	}
+
+	_, err := metadata.ParseWithOptions(bytes.NewReader(p), metadata.Options{Want: []string{}})
+	if err != metadata.ErrNoMeta {
+		t.Errorf("Want none: got error %v, want %v", err, metadata.ErrNoMeta)
+	}
+}
+
+func TestParseJpegLargeChunks(t *testing.T) {
+	p := testLargeJpeg(t, 3, 0)
+
+	for _, r := range []io.Reader{
+		bytes.NewReader(p),
+		struct{ io.Reader }{bytes.NewReader(p)}, // not seekable
+	} {
+		m, err := metadata.Parse(r)
+		if err != nil {
+			t.Fatalf("%T: %v", r, err)
+		}
+		if m.Make != "TestMake" {
+			t.Errorf("%T: got Make %q, want %q", r, m.Make, "TestMake")
+		}
+	}
+}
+
+func BenchmarkParseJpegFile(b *testing.B) {
+	f, err := ioutil.TempFile("", "metadata-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := f.Write(testLargeJpeg(b, 3, 16<<20)); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bb := range []struct {
+		name string
+		r    func() io.Reader
+	}{
+		{"seeker", func() io.Reader { return f }},
+		{"reader", func() io.Reader { return struct{ io.Reader }{f} }},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Seek(0, io.SeekStart); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := metadata.Parse(bb.r()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseJpeg(b *testing.B) {
+	p := testWantJpeg(b)
+	for _, bb := range []struct {
+		name string
+		want []string
+	}{
+		{"all", nil},
+		{"exif", []string{"exif"}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			opt := metadata.Options{Want: bb.want}
+			for i := 0; i < b.N; i++ {
+				if _, err := metadata.ParseWithOptions(bytes.NewReader(p), opt); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// testWantJpeg returns a JPEG with Exif and XMP having different Make values.
+func testWantJpeg(tb testing.TB) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+
+	x := exif.New(100, 100)
+	x.Set(exiftag.Make, exif.Ascii("ExifMake"))
+	p, err := x.EncodeBytes()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := xjpeg.WriteChunk(&buf, 0xe1, append(append([]byte(nil), jpegExifPfx...), p...)); err != nil {
+		tb.Fatal(err)
+	}
+
+	xmp := append([]byte(nil), jpegXMPPfx...)
+	xmp = append(xmp, testXMP...)
+	if err := xjpeg.WriteChunk(&buf, 0xe1, xmp); err != nil {
+		tb.Fatal(err)
+	}
+
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02, 0x00, 0xff, 0xd9})
+	return buf.Bytes()
+}
+
+// testLargeJpeg returns a JPEG with its metadata after
+// napp2 large APP2 segments, followed by n bytes of image data.
+func testLargeJpeg(tb testing.TB, napp2, n int) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+
+	app2 := make([]byte, 60000)
+	for i := 0; i < napp2; i++ {
+		if err := xjpeg.WriteChunk(&buf, 0xe2, app2); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	xmp := append([]byte(nil), jpegXMPPfx...)
+	xmp = append(xmp, testXMP...)
+	if err := xjpeg.WriteChunk(&buf, 0xe1, xmp); err != nil {
+		tb.Fatal(err)
+	}
+
+	buf.Write([]byte{0xff, 0xda, 0x00, 0x02})
+	buf.Write(make([]byte, n))
+	buf.Write([]byte{0xff, 0xd9})
+	return buf.Bytes()
+}
+
+const testXMP = `<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about=''
+  xmlns:exif='http://ns.adobe.com/exif/1.0/'
+  xmlns:tiff='http://ns.adobe.com/tiff/1.0/'>
+  <exif:DateTimeOriginal>2017-04-01T12:34:56</exif:DateTimeOriginal>
+  <tiff:Make>TestMake</tiff:Make>
+ </rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>`
+
+func TestParseJpegAdobeTransform(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+
+	xmp := append([]byte(nil), jpegXMPPfx...)
+	xmp = append(xmp, testXMP...)
+	if err := xjpeg.WriteChunk(&buf, 0xe1, xmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := xjpeg.WriteChunk(&buf, 0xee, []byte("Adobe\x00\x64\x00\x00\x00\x00\x02")); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(testScanData)
+
+	m, err := metadata.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Get(metadata.AdobeTransform); got != "2" {
+		t.Errorf("got AdobeTransform %q, want %q", got, "2")
+	}
+	if m.Make != "TestMake" {
+		t.Errorf("got Make %q, want %q", m.Make, "TestMake")
+	}
+
+	m, err = metadata.ParseWithOptions(bytes.NewReader(buf.Bytes()), metadata.Options{Want: []string{"xmp"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := m.Attr[metadata.AdobeTransform]; ok {
+		t.Errorf("got AdobeTransform %q without jpeg in Want", got)
+	}
+}
+
+func TestParseJpegDuplicateAPP1(t *testing.T) {
+	exifChunk := func(model, software string) []byte {
+		x := exif.New(100, 100)
+		x.Set(exiftag.Model, exif.Ascii(model))
+		if software != "" {
+			x.SetSoftware(software)
+		}
+		p, err := x.EncodeBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(append([]byte(nil), jpegExifPfx...), p...)
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0xff, 0xd8})
+	for _, chunk := range [][]byte{
+		[]byte("Other\x00"),
+		append(append([]byte(nil), jpegXMPPfx...), testXMP...),
+		exifChunk("First", ""),
+		exifChunk("Second", "Duplicate"),
+		append(append([]byte(nil), jpegXMPPfx...), "<x:xmpmeta/>"...),
+	} {
+		if err := xjpeg.WriteChunk(&buf, 0xe1, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf.Write(testScanData)
+
+	m, err := metadata.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		metadata.Make:     "TestMake",
+		metadata.Model:    "First",
+		metadata.Software: "",
+	} {
+		if got := m.Get(key); got != want {
+			t.Errorf("%s is %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package metadata
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// MarshalJSON encodes the attributes of m as a JSON object.
+//
+// Object keys are the attribute names sorted alphabetically,
+// and values are the attribute strings, so that the output
+// is stable. Floating point values are formatted with no exponent,
+// and date/time values use the format of the Time type.
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	attr := make(map[string]string, len(m.Attr))
+	for k, v := range m.Attr {
+		attr[k] = v
+	}
+	for _, k := range floatAttr {
+		if f, err := strconv.ParseFloat(attr[k], 64); err == nil {
+			attr[k] = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return json.Marshal(attr)
+}
+
+// UnmarshalJSON decodes a JSON object produced by MarshalJSON into m.
+// The fields of m are set from the attributes like with Set,
+// and previous contents of m are discarded.
+func (m *Metadata) UnmarshalJSON(p []byte) error {
+	var attr map[string]string
+	if err := json.Unmarshal(p, &attr); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(attr))
+	for k := range attr {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	*m = Metadata{}
+	for _, k := range keys {
+		m.Set(k, attr[k])
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package metadata_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+)
+
+func TestJSON(t *testing.T) {
+	m := new(metadata.Metadata)
+	m.Set(metadata.DateTimeOriginal, "2018-05-06T07:08:09.5+02:00")
+	m.Set(metadata.GPSDateTime, "2018-05-06T05:08:09Z")
+	m.Set(metadata.GPSLatitude, "47.5")
+	m.Set(metadata.GPSLongitude, "-0.0000125")
+	m.Set(metadata.GPSAltitude, "1.25e3")
+	m.Set(metadata.Orientation, "6")
+	m.Set(metadata.Make, "TestMake")
+	m.Set(metadata.Title, "Title \"quoted\"")
+
+	p, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"DateTimeOriginal":"2018-05-06T07:08:09.5+02:00",` +
+		`"GPSAltitude":"1250","GPSDateTime":"2018-05-06T05:08:09Z",` +
+		`"GPSLatitude":"47.5","GPSLongitude":"-0.0000125",` +
+		`"Make":"TestMake","Orientation":"6","Title":"Title \"quoted\""}`
+	if string(p) != want {
+		t.Errorf("got %s\nwant %s", p, want)
+	}
+
+	// values marshal the same as pointers
+	if p, err := json.Marshal(struct{ M metadata.Metadata }{*m}); err != nil || string(p) != `{"M":`+want+`}` {
+		t.Errorf("marshal value: got %s, %v", p, err)
+	}
+
+	got := new(metadata.Metadata)
+	got.Set(metadata.Model, "discarded")
+	if err := json.Unmarshal(p, got); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Set(metadata.GPSAltitude, "1250")
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("got %+v\nwant %+v", got, m)
+	}
+}
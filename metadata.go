@@ -1,6 +1,9 @@
 // Package metadata parses metadata in media files.
 //
-// Currently metadata in JPEG (Exif and XMP) and MP4 (XMP) formats are supported.
+// Currently metadata in JPEG, HEIF, AVIF and WebP (Exif and XMP),
+// TIFF and TIFF based camera raw (Exif) and
+// MP4 (XMP, duration and frame rate) formats are supported.
+// Metadata may be updated in JPEG files using Copy.
 package metadata
 
 import (
@@ -10,6 +13,8 @@ import (
 	"io"
 	"strconv"
 	"time"
+
+	"github.com/tajtiattila/metadata/orient"
 )
 
 // Metadata records file metadata.
@@ -53,6 +58,9 @@ type Metadata struct {
 
 	// Attr holds metadata attributes as strings.
 	Attr map[string]string
+
+	// source records the format attributes were read from, see Source.
+	source map[string]string
 }
 
 // GPSInfo records GPS information.
@@ -85,6 +93,29 @@ const (
 	GPSLatitude  = "GPSLatitude"  // +north, -south
 	GPSLongitude = "GPSLongitude" // +east, -west
 
+	// GPS altitude in meters (floating point), negative below sea level
+	GPSAltitude = "GPSAltitude"
+
+	// GPS image direction in degrees (floating point)
+	GPSImgDirection = "GPSImgDirection"
+
+	// GPS bearing (degrees) and distance (kilometers) of the
+	// destination (floating point), the bearing is relative to either
+	// true or magnetic north as recorded
+	GPSDestBearing  = "GPSDestBearing"
+	GPSDestDistance = "GPSDestDistance"
+
+	// GPS dilution of precision (floating point), lower is better,
+	// and description of the satellites used for the measurement;
+	// read-only
+	GPSDOP        = "GPSDOP"
+	GPSSatellites = "GPSSatellites"
+
+	// GPS processing method (such as "GPS" or "NETWORK")
+	// and name of the GPS area
+	GPSProcessingMethod = "GPSProcessingMethod"
+	GPSAreaInformation  = "GPSAreaInformation"
+
 	// Orientation (integer) 1..8, values are like exif
 	Orientation = "Orientation"
 
@@ -94,14 +125,70 @@ const (
 	// recording equipment manufacturer and model name/number name
 	Make  = "Make"
 	Model = "Model"
+
+	// color space from Exif: "sRGB", "AdobeRGB" or "Uncalibrated"
+	ColorSpace = "ColorSpace"
+
+	// Exif Interoperability Index (such as "R98" or "THM"),
+	// indicating DCF conformance; read-only
+	InteropIndex = "InteropIndex"
+
+	// color transform (integer) of JPEG files from the Adobe (APP14) segment,
+	// 0: none (RGB or CMYK), 1: YCbCr, 2: YCCK
+	AdobeTransform = "AdobeTransform"
+
+	// image dimensions in pixels (integer) as stored in the file,
+	// without taking Orientation into account
+	ImageWidth  = "ImageWidth"
+	ImageHeight = "ImageHeight"
+
+	// title and description (XMP dc:title and dc:description),
+	// using the default (x-default) language alternative
+	Title       = "Title"
+	Description = "Description"
+
+	// software used to create the image
+	// (Exif Software or XMP xmp:CreatorTool)
+	Software = "Software"
+
+	// image title or caption
+	// (Exif ImageDescription or XMP tiff:ImageDescription)
+	ImageDescription = "ImageDescription"
+
+	// user comment (Exif UserComment or XMP exif:UserComment)
+	UserComment = "UserComment"
+
+	// offset of the video of a motion photo in bytes (integer),
+	// counted from the end of the file; read-only
+	MotionPhotoOffset = "MotionPhotoOffset"
+
+	// duration in seconds and average frame rate in frames per second
+	// (floating point) of videos; only set for container formats
+	// that record them, such as MP4
+	Duration  = "Duration"
+	FrameRate = "FrameRate"
 )
 
+// floatAttr lists the attributes above holding floating point values.
+var floatAttr = []string{
+	GPSLatitude,
+	GPSLongitude,
+	GPSAltitude,
+	GPSImgDirection,
+	GPSDestBearing,
+	GPSDestDistance,
+	GPSDOP,
+	Duration,
+	FrameRate,
+}
+
 // Set sets a metadata attribute.
 func (m *Metadata) Set(key, value string) {
 	if m.Attr == nil {
 		m.Attr = make(map[string]string)
 	}
 	m.Attr[key] = value
+	delete(m.source, key)
 
 	if f, ok := updateValue[key]; ok {
 		f(m, value)
@@ -113,6 +200,122 @@ func (m *Metadata) Get(key string) string {
 	return m.Attr[key]
 }
 
+// Source returns the name of the metadata format the attribute key
+// was read from by Parse, such as "exif" or "xmp", or the name of
+// the file format such as "jpeg", "mp4" or "webp" for attributes
+// read from the file structure.
+//
+// The source is recorded on a best effort basis. It is kept by
+// Merge and Clone, but ok is false for attributes updated using Set.
+func (m *Metadata) Source(key string) (source string, ok bool) {
+	source, ok = m.source[key]
+	return source, ok
+}
+
+// setSource records source as the format of all attributes in m.
+func (m *Metadata) setSource(source string) {
+	if len(m.Attr) == 0 {
+		return
+	}
+	m.source = make(map[string]string, len(m.Attr))
+	for k := range m.Attr {
+		m.source[k] = source
+	}
+}
+
+// Clone returns a copy of m that may be modified
+// without affecting m.
+//
+// Attr values are strings, and the fields are value types,
+// so copying the Attr and source maps and the struct suffices.
+func (m *Metadata) Clone() *Metadata {
+	c := *m
+	if m.Attr != nil {
+		c.Attr = make(map[string]string, len(m.Attr))
+		for k, v := range m.Attr {
+			c.Attr[k] = v
+		}
+	}
+	if m.source != nil {
+		c.source = make(map[string]string, len(m.source))
+		for k, v := range m.source {
+			c.source[k] = v
+		}
+	}
+	return &c
+}
+
+// GPSCoords returns the GPS latitude and longitude.
+// It returns ok == false if they are not valid.
+func (m *Metadata) GPSCoords() (lat, lon float64, ok bool) {
+	if !m.GPS.Valid {
+		return 0, 0, false
+	}
+	return m.GPS.Latitude, m.GPS.Longitude, true
+}
+
+// Altitude returns the GPSAltitude attribute.
+func (m *Metadata) Altitude() (alt float64, ok bool) {
+	return m.float64Attr(GPSAltitude)
+}
+
+// Created returns the time the image was taken,
+// that is DateTimeOriginal if it is valid,
+// and the DateTimeCreated otherwise.
+func (m *Metadata) Created() (t time.Time, ok bool) {
+	if m.DateTimeOriginal.Prec > 0 {
+		return m.DateTimeOriginal.Time, true
+	}
+	if m.DateTimeCreated.Prec > 0 {
+		return m.DateTimeCreated.Time, true
+	}
+	return time.Time{}, false
+}
+
+// Width returns the ImageWidth attribute.
+func (m *Metadata) Width() (w int, ok bool) {
+	return m.intAttr(ImageWidth)
+}
+
+// Height returns the ImageHeight attribute.
+func (m *Metadata) Height() (h int, ok bool) {
+	return m.intAttr(ImageHeight)
+}
+
+func (m *Metadata) intAttr(key string) (int, bool) {
+	v, ok := m.Attr[key]
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(v)
+	return i, err == nil
+}
+
+func (m *Metadata) float64Attr(key string) (float64, bool) {
+	v, ok := m.Attr[key]
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil
+}
+
+// DisplayDimensions returns the image dimensions for display,
+// with ImageWidth and ImageHeight swapped if the Orientation
+// requires the image to be transposed.
+// It returns ok == false if either dimension is missing.
+func (m *Metadata) DisplayDimensions() (w, h int, ok bool) {
+	w, wok := m.Width()
+	h, hok := m.Height()
+	if !wok || !hok {
+		return 0, 0, false
+	}
+	if orient.IsTranspose(m.Orientation) {
+		w, h = h, w
+	}
+	return w, h, true
+}
+
 // ErrUnknownFormat is returned by Parse and ParseAt when the file format
 // is not understood by this package.
 var ErrUnknownFormat = errors.New("metadata: unknown content format")
@@ -121,8 +324,31 @@ var ErrUnknownFormat = errors.New("metadata: unknown content format")
 // was recognised but no metadata was found.
 var ErrNoMeta = errors.New("metadata: no metadata found")
 
+// ErrMetaTooLarge is returned by ParseWithOptions when the metadata
+// exceeds Options.MaxMetaBytes.
+var ErrMetaTooLarge = errors.New("metadata: metadata too large")
+
 const sniffLen = 256
 
+// Options specifies options for ParseWithOptions.
+type Options struct {
+	// MaxMetaBytes limits the total size of the metadata blocks
+	// (such as Exif and XMP data) read from a file.
+	// For TIFF files it limits the size of the file.
+	// Zero means no limit, except that only the first 64 MiB
+	// of TIFF files are read.
+	//
+	// ErrMetaTooLarge is returned, possibly along with the metadata
+	// read before reaching the limit, when it is exceeded.
+	MaxMetaBytes int
+
+	// Want lists the metadata formats to decode, such as "exif" for Exif
+	// or "xmp" for XMP data, or "jpeg" for the Adobe color transform
+	// of JPEG files. Other metadata blocks are skipped.
+	// Nil means all formats.
+	Want []string
+}
+
 // Parse parses metadata from r, and returns the metadata found
 // and the first error encountered.
 //
@@ -133,6 +359,11 @@ const sniffLen = 256
 //
 // If r is also an io.Seeker, then it is used to seek within r.
 func Parse(r io.Reader) (*Metadata, error) {
+	return ParseWithOptions(r, Options{})
+}
+
+// ParseWithOptions is like Parse, but uses the options in opt.
+func ParseWithOptions(r io.Reader, opt Options) (*Metadata, error) {
 	p := make([]byte, sniffLen)
 	n, err := io.ReadFull(r, p)
 	switch err {
@@ -144,7 +375,8 @@ func Parse(r io.Reader) (*Metadata, error) {
 		return nil, err
 	}
 
-	return parse(p[:n], prefixReader(p, r))
+	s := &parseState{Options: opt}
+	return s.parse(p[:n], prefixReader(p, r))
 }
 
 // ParseAt parses metadata from r, and returns the metadata found
@@ -158,12 +390,55 @@ func ParseAt(r io.ReaderAt) (*Metadata, error) {
 	return Parse(&atReadSeeker{0, r})
 }
 
-func parse(p []byte, r io.Reader) (*Metadata, error) {
+// parseState holds the options and state of parsing a file.
+type parseState struct {
+	Options
+
+	n int // metadata bytes read so far
+}
+
+// use records that n bytes of metadata has been read.
+// It returns ErrMetaTooLarge if the limit is exceeded.
+func (s *parseState) use(n int) error {
+	s.n += n
+	if s.MaxMetaBytes > 0 && s.n > s.MaxMetaBytes {
+		return ErrMetaTooLarge
+	}
+	return nil
+}
+
+// want reports if the metadata format named format should be decoded.
+func (s *parseState) want(format string) bool {
+	if s.Want == nil {
+		return true
+	}
+	for _, f := range s.Want {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *parseState) parse(p []byte, r io.Reader) (*Metadata, error) {
 	if isjpeg(p) {
-		return parseJpeg(r)
+		return s.parseJpeg(r)
+	}
+	if isheif(p) {
+		return s.parseHEIF(r)
+	}
+	if istiff(p) {
+		// also DNG, NEF, CR2 and other TIFF based raw formats
+		return s.parseTIFF(r)
+	}
+	if israw(p) {
+		return s.parseRAW(r)
 	}
 	if ismp4(p) {
-		return parseMP4(r)
+		return s.parseMP4(r)
+	}
+	if iswebp(p) {
+		return s.parseWebP(r)
 	}
 
 	return nil, ErrUnknownFormat
@@ -211,33 +486,93 @@ func Merge(v ...*Metadata) *Metadata {
 		for key, val := range m.Attr {
 			if _, ok := TimeAttrs[key]; ok {
 				r, ok := result.Attr[key]
-				if !ok || timeBetter(val, r) {
-					result.Set(key, val)
+				if ok && !timeBetter(val, r) {
+					continue
 				}
-			} else {
-				result.Set(key, val)
+			}
+			result.Set(key, val)
+			if src, ok := m.source[key]; ok {
+				if result.source == nil {
+					result.source = make(map[string]string)
+				}
+				result.source[key] = src
 			}
 		}
 	}
 	return result
 }
 
-func timeBetter(val, than string) bool {
-	const zoneScore = 2
+// Diff returns the attributes having different values in a and b.
+//
+// The values in the result are the value in a and b, respectively,
+// or nil if the attribute is missing. Values of valid time attributes
+// listed in TimeAttrs are time.Time values compared by instant,
+// other values are strings.
+func Diff(a, b *Metadata) map[string][2]interface{} {
+	d := make(map[string][2]interface{})
+	for k, va := range a.Attr {
+		vb, ok := b.Attr[k]
+		if !ok {
+			d[k] = [2]interface{}{diffValue(k, va), nil}
+			continue
+		}
+		if ta, tb, ok := diffTimes(k, va, vb); ok {
+			if !ta.Equal(tb) {
+				d[k] = [2]interface{}{ta, tb}
+			}
+		} else if va != vb {
+			d[k] = [2]interface{}{va, vb}
+		}
+	}
+	for k, vb := range b.Attr {
+		if _, ok := a.Attr[k]; !ok {
+			d[k] = [2]interface{}{nil, diffValue(k, vb)}
+		}
+	}
+	return d
+}
 
-	v := ParseTime(val)
-	vscore := v.Prec
-	if v.Prec > 3 && v.HasLoc {
-		vscore += zoneScore
+func diffValue(key, v string) interface{} {
+	if _, ok := TimeAttrs[key]; ok {
+		if t := ParseTime(v); t.Prec > 0 {
+			return t.Time
+		}
 	}
+	return v
+}
+
+// diffTimes returns the parsed times at a and b,
+// if key is a time attribute and both times are valid.
+func diffTimes(key, a, b string) (ta, tb time.Time, ok bool) {
+	if _, ok := TimeAttrs[key]; !ok {
+		return ta, tb, false
+	}
+	pa, pb := ParseTime(a), ParseTime(b)
+	if pa.Prec == 0 || pb.Prec == 0 {
+		return ta, tb, false
+	}
+	return pa.Time, pb.Time, true
+}
 
+// timeBetter reports if the time val is better than the time than.
+//
+// A time having both a time of day and an explicit zone is better
+// than one assumed to be in time.Local, otherwise the more precise
+// time is better. Invalid times are never better.
+func timeBetter(val, than string) bool {
+	v := ParseTime(val)
+	if v.Prec == 0 {
+		return false
+	}
 	t := ParseTime(than)
-	tscore := t.Prec
-	if t.Prec > 3 && t.HasLoc {
-		tscore += zoneScore
+
+	vzone := v.Prec > 3 && v.HasLoc
+	tzone := t.Prec > 3 && t.HasLoc
+	if vzone != tzone {
+		return vzone
 	}
 
-	return vscore > tscore
+	return v.Prec > t.Prec
 }
 
 func setOf(v ...string) map[string]struct{} {
@@ -254,8 +589,8 @@ var updateValue = map[string]updateFunc{
 	DateTimeOriginal: func(m *Metadata, v string) { updateTime(&m.DateTimeOriginal, v) },
 	DateTimeCreated:  func(m *Metadata, v string) { updateTime(&m.DateTimeCreated, v) },
 	GPSDateTime:      func(m *Metadata, v string) { updateTimeTime(&m.GPS.Time, v) },
-	GPSLatitude:      updateLatLong,
-	GPSLongitude:     updateLatLong,
+	GPSLatitude:      func(m *Metadata, v string) { updateLatLong(m, &m.GPS.Latitude, v) },
+	GPSLongitude:     func(m *Metadata, v string) { updateLatLong(m, &m.GPS.Longitude, v) },
 	Orientation:      func(m *Metadata, v string) { updateInt(&m.Orientation, v) },
 	Rating:           func(m *Metadata, v string) { updateInt(&m.Rating, v) },
 	Make:             func(m *Metadata, v string) { m.Make = v },
@@ -278,9 +613,14 @@ func updateInt(p *int, v string) {
 	}
 }
 
-func updateLatLong(m *Metadata, v string) {
-	var laterr, lonerr error
-	m.GPS.Latitude, laterr = strconv.ParseFloat(m.Get(GPSLatitude), 64)
-	m.GPS.Longitude, lonerr = strconv.ParseFloat(m.Get(GPSLongitude), 64)
+// updateLatLong updates a single coordinate at p.
+// GPS.Valid is set based on both coordinates in m.Attr,
+// so the order of updates is irrelevant.
+func updateLatLong(m *Metadata, p *float64, v string) {
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		*p = f
+	}
+	_, laterr := strconv.ParseFloat(m.Get(GPSLatitude), 64)
+	_, lonerr := strconv.ParseFloat(m.Get(GPSLongitude), 64)
 	m.GPS.Valid = laterr == nil && lonerr == nil
 }
@@ -5,9 +5,11 @@ import (
 	"io"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/tajtiattila/metadata"
 	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
 	xjpeg "github.com/tajtiattila/metadata/jpeg"
 	"github.com/tajtiattila/metadata/testutil"
 )
@@ -44,7 +46,10 @@ func testParse(t *testing.T, e testutil.FileInfo) {
 	defer f.Close()
 
 	m, err := metadata.Parse(f)
-	if err != nil {
+	if err != nil && m != nil {
+		// best effort: metadata found despite errors
+		t.Logf("metadata.Parse %s warning: %v", fn, err)
+	} else if err != nil {
 		if err == metadata.ErrUnknownFormat {
 			// format not (yet?) supported
 			return
@@ -57,6 +62,207 @@ func testParse(t *testing.T, e testutil.FileInfo) {
 	m.Get(metadata.DateTimeCreated)
 }
 
+func TestDisplayDimensions(t *testing.T) {
+	tests := []struct {
+		w, h, o string
+
+		dx, dy int
+		ok     bool
+	}{
+		{"640", "480", "", 640, 480, true},
+		{"640", "480", "1", 640, 480, true},
+		{"640", "480", "3", 640, 480, true},
+		{"640", "480", "5", 480, 640, true},
+		{"640", "480", "6", 480, 640, true},
+		{"640", "480", "8", 480, 640, true},
+		{"640", "", "6", 0, 0, false},
+		{"", "480", "1", 0, 0, false},
+	}
+	for _, tt := range tests {
+		m := new(metadata.Metadata)
+		if tt.w != "" {
+			m.Set(metadata.ImageWidth, tt.w)
+		}
+		if tt.h != "" {
+			m.Set(metadata.ImageHeight, tt.h)
+		}
+		if tt.o != "" {
+			m.Set(metadata.Orientation, tt.o)
+		}
+		dx, dy, ok := m.DisplayDimensions()
+		if dx != tt.dx || dy != tt.dy || ok != tt.ok {
+			t.Errorf("%sx%s orientation %q: got %d, %d, %v; want %d, %d, %v",
+				tt.w, tt.h, tt.o, dx, dy, ok, tt.dx, tt.dy, tt.ok)
+		}
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	m := new(metadata.Metadata)
+	if _, _, ok := m.GPSCoords(); ok {
+		t.Error("empty metadata has GPSCoords")
+	}
+	if _, ok := m.Created(); ok {
+		t.Error("empty metadata has Created")
+	}
+	if _, ok := m.Width(); ok {
+		t.Error("empty metadata has Width")
+	}
+
+	m.Set(metadata.GPSLatitude, "47.5")
+	m.Set(metadata.GPSLongitude, "19.25")
+	m.Set(metadata.GPSAltitude, "123.5")
+	m.Set(metadata.ImageWidth, "640")
+	m.Set(metadata.ImageHeight, "invalid")
+	m.Set(metadata.DateTimeCreated, "2017-04-02T08:00:00Z")
+
+	if lat, lon, ok := m.GPSCoords(); lat != 47.5 || lon != 19.25 || !ok {
+		t.Errorf("GPSCoords is %v, %v, %v", lat, lon, ok)
+	}
+	if alt, ok := m.Altitude(); alt != 123.5 || !ok {
+		t.Errorf("Altitude is %v, %v", alt, ok)
+	}
+	if w, ok := m.Width(); w != 640 || !ok {
+		t.Errorf("Width is %v, %v", w, ok)
+	}
+	if _, ok := m.Height(); ok {
+		t.Error("invalid Height accepted")
+	}
+
+	created := time.Date(2017, 4, 2, 8, 0, 0, 0, time.UTC)
+	if tm, ok := m.Created(); !tm.Equal(created) || !ok {
+		t.Errorf("Created is %v, %v; want %v", tm, ok, created)
+	}
+
+	// DateTimeOriginal takes precedence
+	m.Set(metadata.DateTimeOriginal, "2017-04-01T12:34:56Z")
+	orig := time.Date(2017, 4, 1, 12, 34, 56, 0, time.UTC)
+	if tm, ok := m.Created(); !tm.Equal(orig) || !ok {
+		t.Errorf("Created is %v, %v; want %v", tm, ok, orig)
+	}
+}
+
+func TestExifColorSpace(t *testing.T) {
+	tests := []struct {
+		cs      exif.Value
+		interop string
+		want    string
+	}{
+		{nil, "", ""},
+		{exif.Short{1}, "R98", "sRGB"},
+		{exif.Short{2}, "", "AdobeRGB"},
+		{exif.Short{0xffff}, "", "Uncalibrated"},
+		{exif.Short{0xffff}, "R03", "AdobeRGB"},
+	}
+	for _, tt := range tests {
+		x := exif.New(100, 100)
+		x.Set(exiftag.ColorSpace, tt.cs)
+		if tt.interop != "" {
+			x.Set(exiftag.InteroperabilityIndex, exif.Ascii(tt.interop))
+		}
+		m := metadata.FromExif(x)
+		got, ok := m.Attr[metadata.ColorSpace]
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("ColorSpace %v with %q: got %q (ok=%v), want %q",
+				tt.cs, tt.interop, got, ok, tt.want)
+		}
+		if got := m.Get(metadata.InteropIndex); got != tt.interop {
+			t.Errorf("InteropIndex is %q, want %q", got, tt.interop)
+		}
+	}
+}
+
+func TestMergeGPS(t *testing.T) {
+	lat := new(metadata.Metadata)
+	lat.Set(metadata.GPSLatitude, "47.5")
+	lon := new(metadata.Metadata)
+	lon.Set(metadata.GPSLongitude, "19.25")
+
+	for _, v := range [][]*metadata.Metadata{{lat, lon}, {lon, lat}} {
+		m := metadata.Merge(v...)
+		if !m.GPS.Valid {
+			t.Error("merged GPS invalid")
+		}
+		if m.GPS.Latitude != 47.5 || m.GPS.Longitude != 19.25 {
+			t.Errorf("merged GPS is %v, %v; want 47.5, 19.25",
+				m.GPS.Latitude, m.GPS.Longitude)
+		}
+	}
+
+	if lat.GPS.Valid || lon.GPS.Valid {
+		t.Error("partial GPS valid")
+	}
+
+	// a malformed update must not clobber the previous coordinate
+	m := metadata.Merge(lat, lon)
+	m.Set(metadata.GPSLatitude, "invalid")
+	if m.GPS.Valid || m.GPS.Latitude != 47.5 {
+		t.Errorf("after invalid update: valid=%v latitude=%v", m.GPS.Valid, m.GPS.Latitude)
+	}
+}
+
+func TestClone(t *testing.T) {
+	m := new(metadata.Metadata)
+	m.Set(metadata.Make, "TestMake")
+	m.Set(metadata.Orientation, "6")
+	m.Set(metadata.GPSLatitude, "47.5")
+	m.Set(metadata.GPSLongitude, "19.25")
+
+	c := m.Clone()
+	c.Set(metadata.Make, "Other")
+	c.Set(metadata.Orientation, "1")
+	c.Set(metadata.GPSLatitude, "-10")
+	c.Set(metadata.Rating, "5")
+
+	if m.Make != "TestMake" || m.Get(metadata.Make) != "TestMake" {
+		t.Errorf("original Make changed to %q", m.Get(metadata.Make))
+	}
+	if m.Orientation != 6 {
+		t.Errorf("original Orientation changed to %v", m.Orientation)
+	}
+	if m.GPS.Latitude != 47.5 {
+		t.Errorf("original latitude changed to %v", m.GPS.Latitude)
+	}
+	if _, ok := m.Attr[metadata.Rating]; ok {
+		t.Error("original has Rating")
+	}
+
+	if c.Make != "Other" || c.Orientation != 1 || c.GPS.Latitude != -10 || c.GPS.Longitude != 19.25 {
+		t.Errorf("clone is %+v", c)
+	}
+
+	// empty metadata
+	if c := new(metadata.Metadata).Clone(); c.Attr != nil {
+		t.Error("clone of empty metadata has Attr")
+	}
+}
+
+func TestSource(t *testing.T) {
+	m, err := metadata.Parse(bytes.NewReader(testWantJpeg(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(name string, m *metadata.Metadata, key, want string) {
+		got, ok := m.Source(key)
+		if got != want || ok != (want != "") {
+			t.Errorf("%s: source of %s is %q, %v; want %q", name, key, got, ok, want)
+		}
+	}
+
+	// Make is in both Exif and XMP, and XMP is merged last
+	check("parsed", m, metadata.Make, "xmp")
+	check("parsed", m, metadata.ImageWidth, "exif")
+	check("parsed", m, metadata.DateTimeOriginal, "xmp")
+	check("parsed", m, metadata.Rating, "")
+
+	c := m.Clone()
+	c.Set(metadata.Make, "Other")
+	check("clone", c, metadata.Make, "")
+	check("clone", c, metadata.ImageWidth, "exif")
+	check("original", m, metadata.Make, "xmp")
+}
+
 var jpegExifPfx = []byte("Exif\x00\x00")
 var jpegXMPPfx = []byte("http://ns.adobe.com/xap/1.0/\x00")
 
@@ -122,3 +328,56 @@ func dumpExifBytes(t *testing.T, p []byte) {
 
 func dumpXmpBytes(t *testing.T, p []byte) {
 }
+
+func TestDiff(t *testing.T) {
+	a, b := new(metadata.Metadata), new(metadata.Metadata)
+	a.Set(metadata.Make, "Make")
+	b.Set(metadata.Make, "Make")
+	a.Set(metadata.Model, "ModelA")
+	b.Set(metadata.Model, "ModelB")
+	a.Set(metadata.Title, "Title")
+	b.Set(metadata.Rating, "3")
+
+	// same instant in different zones
+	a.Set(metadata.DateTimeOriginal, "2017-04-01T12:34:56+02:00")
+	b.Set(metadata.DateTimeOriginal, "2017-04-01T10:34:56Z")
+	a.Set(metadata.DateTimeCreated, "2017-04-01T12:34:56Z")
+	b.Set(metadata.DateTimeCreated, "2017-04-01T12:34:57Z")
+
+	d := metadata.Diff(a, b)
+
+	created := func(sec int) time.Time {
+		return time.Date(2017, 4, 1, 12, 34, sec, 0, time.UTC)
+	}
+	want := map[string][2]interface{}{
+		metadata.Model:           {"ModelA", "ModelB"},
+		metadata.Title:           {"Title", nil},
+		metadata.Rating:          {nil, "3"},
+		metadata.DateTimeCreated: {created(56), created(57)},
+	}
+	if len(d) != len(want) {
+		t.Errorf("got %d differences, want %d: %v", len(d), len(want), d)
+	}
+	for k, w := range want {
+		g, ok := d[k]
+		if !ok {
+			t.Errorf("%s: missing from diff", k)
+			continue
+		}
+		for i := range g {
+			gt, gok := g[i].(time.Time)
+			wt, wok := w[i].(time.Time)
+			if gok && wok {
+				if !gt.Equal(wt) {
+					t.Errorf("%s: value %d is %v, want %v", k, i, gt, wt)
+				}
+			} else if g[i] != w[i] {
+				t.Errorf("%s: value %d is %v, want %v", k, i, g[i], w[i])
+			}
+		}
+	}
+
+	if d := metadata.Diff(a, a.Clone()); len(d) != 0 {
+		t.Errorf("got differences for clone: %v", d)
+	}
+}
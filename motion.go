@@ -0,0 +1,47 @@
+package metadata
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// ErrNoMotionPhoto is returned by MotionPhotoVideo if the file
+// is not a motion photo with an embedded MP4 video.
+var ErrNoMotionPhoto = errors.New("metadata: no motion photo video found")
+
+// MotionPhotoOffset returns the MotionPhotoOffset attribute,
+// the offset of the embedded video from the end of the file.
+func (m *Metadata) MotionPhotoOffset() (offset int64, ok bool) {
+	v, err := strconv.ParseInt(m.Get(MotionPhotoOffset), 10, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// MotionPhotoVideo returns the MP4 video embedded
+// in the motion photo r having size bytes.
+//
+// The location of the video is read from the XMP of r,
+// and ErrNoMotionPhoto is returned if it is missing,
+// or there is no MP4 file at the location.
+func MotionPhotoVideo(r io.ReaderAt, size int64) ([]byte, error) {
+	m, err := ParseAt(r)
+	if m == nil {
+		return nil, err
+	}
+	offset, ok := m.MotionPhotoOffset()
+	if !ok || offset > size {
+		return nil, ErrNoMotionPhoto
+	}
+
+	p := make([]byte, offset)
+	if _, err := r.ReadAt(p, size-offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !ismp4(p) {
+		return nil, ErrNoMotionPhoto
+	}
+	return p, nil
+}
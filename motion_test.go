@@ -0,0 +1,93 @@
+package metadata_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+)
+
+func TestMotionPhoto(t *testing.T) {
+	video := []byte("\x00\x00\x00\x14ftypmp42\x00\x00\x00\x00mp42\x00\x00\x00\x08free")
+
+	microVideoOffset := func(offset int) string {
+		return fmt.Sprintf(`<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about=''
+  xmlns:GCamera='http://ns.google.com/photos/1.0/camera/'
+  GCamera:MicroVideo='1'
+  GCamera:MicroVideoVersion='1'
+  GCamera:MicroVideoOffset='%d'/>
+</rdf:RDF>
+</x:xmpmeta>`, offset)
+	}
+	microVideo := microVideoOffset(len(video))
+
+	container := fmt.Sprintf(`<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about=''
+  xmlns:GCamera='http://ns.google.com/photos/1.0/camera/'
+  xmlns:Container='http://ns.google.com/photos/1.0/container/'
+  xmlns:Item='http://ns.google.com/photos/1.0/container/item/'
+  GCamera:MotionPhoto='1'>
+  <Container:Directory>
+   <rdf:Seq>
+    <rdf:li rdf:parseType='Resource'>
+     <Container:Item Item:Mime='image/jpeg' Item:Semantic='Primary' Item:Length='0' Item:Padding='0'/>
+    </rdf:li>
+    <rdf:li rdf:parseType='Resource'>
+     <Container:Item Item:Mime='video/mp4' Item:Semantic='MotionPhoto' Item:Length='%d'/>
+    </rdf:li>
+   </rdf:Seq>
+  </Container:Directory>
+ </rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>`, len(video))
+
+	motionPhoto := func(xmp string, video []byte) []byte {
+		var buf bytes.Buffer
+		buf.Write([]byte{0xff, 0xd8})
+		chunk := append(append([]byte(nil), jpegXMPPfx...), xmp...)
+		if err := xjpeg.WriteChunk(&buf, 0xe1, chunk); err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(testScanData)
+		buf.Write(video)
+		return buf.Bytes()
+	}
+
+	for name, xmp := range map[string]string{
+		"MicroVideo": microVideo,
+		"Container":  container,
+	} {
+		p := motionPhoto(xmp, video)
+
+		m, err := metadata.Parse(bytes.NewReader(p))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if ofs, ok := m.MotionPhotoOffset(); !ok || ofs != int64(len(video)) {
+			t.Errorf("%s: MotionPhotoOffset is %v, %v; want %v, true", name, ofs, ok, len(video))
+		}
+
+		got, err := metadata.MotionPhotoVideo(bytes.NewReader(p), int64(len(p)))
+		if err != nil {
+			t.Errorf("%s: MotionPhotoVideo: %v", name, err)
+		} else if !bytes.Equal(got, video) {
+			t.Errorf("%s: MotionPhotoVideo returned %q, want %q", name, got, video)
+		}
+	}
+
+	for name, p := range map[string][]byte{
+		"no offset":    motionPhoto(testXMP, video),
+		"not mp4":      motionPhoto(microVideo, bytes.Repeat([]byte{'x'}, len(video))),
+		"out of range": motionPhoto(microVideoOffset(1<<20), video),
+	} {
+		_, err := metadata.MotionPhotoVideo(bytes.NewReader(p), int64(len(p)))
+		if err != metadata.ErrNoMotionPhoto {
+			t.Errorf("%s: got error %v, want ErrNoMotionPhoto", name, err)
+		}
+	}
+}
@@ -2,14 +2,16 @@ package metadata
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/tajtiattila/metadata/mp4"
 )
 
 var mp4xmpUuid = []byte{0xbe, 0x7a, 0xcf, 0xcb, 0x97, 0xa9, 0x42, 0xe8, 0x9c, 0x71, 0x99, 0x94, 0x91, 0xe3, 0xaf, 0xac}
 
-func parseMP4(r io.Reader) (*Metadata, error) {
+func (s *parseState) parseMP4(r io.Reader) (*Metadata, error) {
 	f, err := mp4.Parse(r)
 	if err != nil {
 		return nil, err
@@ -18,11 +20,30 @@ func parseMP4(r io.Reader) (*Metadata, error) {
 	var meta []*Metadata
 
 	mvhd := new(Metadata)
-	mvhd.Set(DateTimeCreated, fmtTime(f.Header.DateCreated, false))
+	if !f.Header.DateCreated.IsZero() {
+		mvhd.Set(DateTimeCreated, fmtTime(f.Header.DateCreated, false))
+	}
+	if h := f.Header; h.TimeUnit != 0 && h.DurationInUnits != 0 {
+		d := float64(h.DurationInUnits) / float64(h.TimeUnit)
+		mvhd.Set(Duration, strconv.FormatFloat(d, 'f', -1, 64))
+	}
+	for _, t := range f.Tracks() {
+		if t.HandlerType == "vide" {
+			mvhd.Set(Orientation, fmt.Sprint(rotationOrientation(t.Header.Rotation())))
+			if fr := t.FrameRate(); fr > 0 {
+				mvhd.Set(FrameRate, strconv.FormatFloat(fr, 'f', -1, 64))
+			}
+			break
+		}
+	}
+	mvhd.setSource("mp4")
 	meta = append(meta, mvhd)
 
 	for _, b := range f.Child {
-		if b.Type == "uuid" && bytes.HasPrefix(b.Raw, mp4xmpUuid) {
+		if s.want("xmp") && b.Type == "uuid" && bytes.HasPrefix(b.Raw, mp4xmpUuid) {
+			if err = s.use(len(b.Raw)); err != nil {
+				break
+			}
 			var m *Metadata
 			m, err = FromXMPBytes(b.Raw[len(mp4xmpUuid):])
 			if m != nil {
@@ -32,3 +53,17 @@ func parseMP4(r io.Reader) (*Metadata, error) {
 	}
 	return Merge(meta...), err
 }
+
+// rotationOrientation returns the Exif orientation
+// for a clockwise video rotation in degrees.
+func rotationOrientation(deg int) int {
+	switch deg {
+	case 90:
+		return 6
+	case 180:
+		return 3
+	case 270:
+		return 8
+	}
+	return 1
+}
@@ -0,0 +1,84 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestHeaderSize(t *testing.T) {
+	tests := []struct {
+		contentlen int
+		want       int64
+	}{
+		{0, 8},
+		{100, 8},
+		{1<<32 - 9, 8},
+		{1<<32 - 8, 16},
+		{1 << 32, 16},
+	}
+	for _, tt := range tests {
+		if got := headerSize(tt.contentlen); got != tt.want {
+			t.Errorf("headerSize(%d) = %d, want %d", tt.contentlen, got, tt.want)
+		}
+		size := boxSize(tt.contentlen)
+		if ext := size >= 1<<32; ext != (tt.want == 16) {
+			t.Errorf("boxSize(%d) = %d inconsistent with header size %d",
+				tt.contentlen, size, tt.want)
+		}
+	}
+}
+
+func TestPackChildren(t *testing.T) {
+	// box with a 64-bit size header
+	ext := make([]byte, 16)
+	binary.BigEndian.PutUint32(ext, 1)
+	copy(ext[4:], "free")
+	binary.BigEndian.PutUint64(ext[8:], 16+4)
+	ext = append(ext, "free"...)
+
+	tkhd := testutil.Box("tkhd", make([]byte, 84))
+	trak := testutil.Box("trak", tkhd, testutil.Box("mdia", testutil.Box("hdlr", make([]byte, 24))))
+
+	tests := []struct {
+		name    string
+		content []byte
+		shrink  int64
+	}{
+		{"plain", bytes.Join([][]byte{testutil.Box("mvhd", make([]byte, 100)), trak}, nil), 0},
+		{"ext", bytes.Join([][]byte{testutil.Box("mvhd", make([]byte, 100)), ext, trak}, nil), 8},
+	}
+	for _, tt := range tests {
+		moov := Box{Type: "moov", Size: boxSize(len(tt.content)), Raw: tt.content}
+		if err := moov.unpackChildren(); err != nil {
+			t.Errorf("%s: unpack: %v", tt.name, err)
+			continue
+		}
+
+		orig := moov.Size
+		moov.packChildren()
+		if want := orig - tt.shrink; moov.Size != want {
+			t.Errorf("%s: packed size is %d, want %d", tt.name, moov.Size, want)
+		}
+		if n := int64(len(moov.Raw)); n != moov.Size {
+			t.Errorf("%s: packed %d bytes, box size is %d", tt.name, n, moov.Size)
+		}
+
+		// all headers should be 8 bytes
+		p := moov.Raw[8:]
+		for len(p) != 0 {
+			n := binary.BigEndian.Uint32(p)
+			if n < 8 || int(n) > len(p) {
+				t.Errorf("%s: invalid packed box size %d", tt.name, n)
+				break
+			}
+			p = p[n:]
+		}
+
+		if tt.shrink == 0 && !bytes.Equal(moov.Raw[8:], tt.content) {
+			t.Errorf("%s: packed content differs from source", tt.name)
+		}
+	}
+}
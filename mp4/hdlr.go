@@ -0,0 +1,82 @@
+package mp4
+
+import "bytes"
+
+// HDLR is a handler reference box,
+// specifying the media type of a track in mdia/hdlr.
+type HDLR struct {
+	Version byte
+	Flags   [3]byte
+
+	// Predefined is the QuickTime component type ("mhlr" or "dhlr"),
+	// it is zero in ISO files.
+	Predefined string
+
+	// HandlerType is the media type, such as
+	// "vide" (video), "soun" (audio), "hint", "subt" (subtitle) or "meta".
+	HandlerType string
+
+	Name string // human readable name of the track type
+}
+
+var ErrShortHDLR = formatError("HDLR too short")
+
+func DecodeHDLR(p []byte) (*HDLR, error) {
+	h := new(HDLR)
+
+	bp := newBoxParse(p)
+
+	var err error
+	h.Version, h.Flags, err = bp.versionFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	h.Predefined = bp.CC4()
+	h.HandlerType = bp.CC4()
+	bp.Skip(12)
+
+	if bp.Short() {
+		return nil, ErrShortHDLR
+	}
+
+	h.Name = hdlrName(bp.Rest())
+
+	return h, nil
+}
+
+// hdlrName decodes the name string of the handler.
+// It is a NUL-terminated string in ISO files,
+// but QuickTime uses a Pascal string instead.
+func hdlrName(p []byte) string {
+	if len(p) == 0 {
+		return ""
+	}
+	if n := int(p[0]); n != 0 && n == len(p)-1 {
+		return string(p[1:])
+	}
+	if i := bytes.IndexByte(p, 0); i >= 0 {
+		p = p[:i]
+	}
+	return string(p)
+}
+
+/* HDLR http://xhelmboyx.tripod.com/formats/mp4-layout.txt
+
+   * 8+ bytes media (stream) handler reference box
+       = long unsigned offset + long ASCII text string 'hdlr'
+     -> 1 byte version = byte unsigned value
+     -> 3 bytes flags = 24-bit hex flags (current = 0)
+
+     -> 4 bytes QUICKTIME type = long ASCII text string
+       - possible values are 'mhlr' for media or 'dhlr' for data
+       - MP4 uses zero (pre_defined)
+     -> 4 bytes subtype/media type = long ASCII text string
+       - possible values are 'vide', 'soun', 'hint', 'subt', 'meta' ...
+     -> 4 bytes QUICKTIME manufacturer reserved = long ASCII text string
+     -> 4 bytes QUICKTIME component reserved flags = long hex flags none = 0
+     -> 4 bytes QUICKTIME component reserved flags mask = long hex mask none = 0
+     -> 1 byte QUICKTIME component name string length
+       - MP4 uses a C (null-terminated) UTF-8 string instead
+     -> component type name ASCII string
+*/
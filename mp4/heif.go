@@ -0,0 +1,365 @@
+package mp4
+
+import (
+	"io"
+	"sort"
+)
+
+// HEIF is a High Efficiency Image File (ISO/IEC 23008-12),
+// or an AVIF file that uses the same structure.
+//
+// It uses the same box structure as MP4 files, but instead of
+// tracks, the content is stored in items listed in the meta box.
+type HEIF struct {
+	Box
+
+	Brand string // major brand from ftyp
+
+	Items []Item // items from meta/iinf and meta/iloc
+}
+
+// Item is an item within the meta box of a HEIF file.
+type Item struct {
+	ID   uint32
+	Type string // item type such as "Exif", "mime" or "hvc1"
+	Name string
+
+	// ContentType is the MIME type of "mime" items.
+	ContentType string
+
+	// ConstructionMethod specifies how Extents should be interpreted:
+	//   0: offsets within the file
+	//   1: offsets within the idat box of meta
+	//   2: reference to other items (unsupported)
+	ConstructionMethod int
+
+	// Extents are the parts of the item data.
+	// Offsets already include the base offset of the item.
+	Extents []Extent
+
+	Data []byte // Item data, if loaded
+}
+
+// Extent is a part of the data of an Item.
+type Extent struct {
+	Offset, Length int64
+}
+
+// ItemsOfType returns the items in h having item type typ.
+func (h *HEIF) ItemsOfType(typ string) []*Item {
+	var v []*Item
+	for i := range h.Items {
+		if h.Items[i].Type == typ {
+			v = append(v, &h.Items[i])
+		}
+	}
+	return v
+}
+
+// ParseHEIF parses a HEIF file from r.
+//
+// The data of metadata items (having item type "Exif" or "mime")
+// is loaded into their Data field.
+//
+// Item data within the file must be located after the meta box.
+// If r is a io.ReadSeeker then it is used
+// to seek forward within r when necessary.
+func ParseHEIF(r io.Reader) (*HEIF, error) {
+	p := parser{
+		r: r,
+		f: &File{
+			Box: Box{Type: "HEIF", Size: -1},
+		},
+	}
+	if err := p.parseUntil("meta"); err != nil {
+		return nil, err
+	}
+
+	h := &HEIF{Box: p.f.Box}
+
+	ftyp := h.Find("ftyp")
+	if ftyp == nil || len(ftyp.Raw) < 4 {
+		return nil, formatError("ftyp missing")
+	}
+	h.Brand = string(ftyp.Raw[:4])
+
+	meta := h.Find("meta")
+	if meta == nil {
+		return nil, formatError("meta missing")
+	}
+	if err := meta.unpackChildren(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	h.Items, err = decodeItems(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.loadItemData(meta, h.Items, isMetaItem); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+func isMetaItem(it *Item) bool {
+	return it.Type == "Exif" || it.Type == "mime"
+}
+
+// loadItemData loads the data of items for which want returns true.
+func (p *parser) loadItemData(meta *Box, items []Item, want func(it *Item) bool) error {
+	var idat []byte
+	if b := meta.Find("idat"); b != nil {
+		idat = b.Raw
+	}
+
+	type fileExtent struct {
+		Extent
+		data []byte // slot in item.Data
+		item *Item
+	}
+	var fx []fileExtent
+
+	for i := range items {
+		it := &items[i]
+		if !want(it) || it.ConstructionMethod > 1 {
+			continue
+		}
+
+		// zero length extends to the end of the source
+		extents := make([]Extent, len(it.Extents))
+		copy(extents, it.Extents)
+		for j := range extents {
+			e := &extents[j]
+			if e.Length != 0 {
+				continue
+			}
+			if it.ConstructionMethod != 1 {
+				return formatError("%s item %d extent to end of file unsupported", it.Type, it.ID)
+			}
+			if e.Offset >= 0 && e.Offset <= int64(len(idat)) {
+				e.Length = int64(len(idat)) - e.Offset
+			}
+		}
+
+		var n int64
+		for _, e := range extents {
+			n += e.Length
+		}
+		if n > maxParseSize {
+			return formatError("%s item %d too long", it.Type, it.ID)
+		}
+
+		// extents are concatenated in iloc order
+		it.Data = make([]byte, int(n))
+		pos := 0
+		for _, e := range extents {
+			slot := it.Data[pos : pos+int(e.Length)]
+			pos += int(e.Length)
+
+			switch it.ConstructionMethod {
+			case 0:
+				fx = append(fx, fileExtent{e, slot, it})
+			case 1:
+				if e.Offset < 0 || int64(len(idat)) < e.Offset+e.Length {
+					return formatError("%s item %d outside idat", it.Type, it.ID)
+				}
+				copy(slot, idat[e.Offset:e.Offset+e.Length])
+			}
+		}
+	}
+
+	// read extents in file order
+	sort.SliceStable(fx, func(i, j int) bool {
+		return fx[i].Offset < fx[j].Offset
+	})
+
+	for _, e := range fx {
+		if e.Offset < p.off {
+			return formatError("%s item %d data before offset %d", e.item.Type, e.item.ID, p.off)
+		}
+		if err := p.skip(e.Offset - p.off); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(p.r, e.data)
+		p.off += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeItems decodes items from the iinf and iloc boxes of meta.
+func decodeItems(meta *Box) ([]Item, error) {
+	iinf := meta.Find("iinf")
+	if iinf == nil {
+		return nil, formatError("meta/iinf missing")
+	}
+	items, err := decodeIINF(iinf.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	iloc := meta.Find("iloc")
+	if iloc == nil {
+		return nil, formatError("meta/iloc missing")
+	}
+	locs, err := decodeILOC(iloc.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := make(map[uint32]int)
+	for i, it := range items {
+		idx[it.ID] = i
+	}
+	for _, l := range locs {
+		i, ok := idx[l.ID]
+		if !ok {
+			continue
+		}
+		items[i].ConstructionMethod = l.ConstructionMethod
+		items[i].Extents = l.Extents
+	}
+	return items, nil
+}
+
+// decodeIINF decodes the item information box.
+func decodeIINF(p []byte) ([]Item, error) {
+	bp := newBoxParse(p)
+
+	ver := bp.next(4)[0]
+	var n uint32
+	if ver == 0 {
+		n = uint32(bp.Uint16())
+	} else {
+		n = bp.Uint32()
+	}
+	if bp.Short() {
+		return nil, formatError("iinf too short")
+	}
+
+	var items []Item
+	for i := uint32(0); i < n; i++ {
+		raw := bp.Rest()
+		if len(raw) < 8 {
+			return nil, formatError("iinf too short")
+		}
+		size := int(mp4bo.Uint32(raw))
+		if size < 8 || len(raw) < size {
+			return nil, formatError("iinf entry size %d invalid", size)
+		}
+		if string(raw[4:8]) == "infe" {
+			it, err := decodeINFE(raw[8:size])
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, it)
+		}
+		bp.Skip(size)
+	}
+	return items, nil
+}
+
+// decodeINFE decodes an item info entry.
+func decodeINFE(p []byte) (it Item, err error) {
+	bp := newBoxParse(p)
+
+	ver := bp.next(4)[0]
+	switch ver {
+	case 0, 1:
+		it.ID = uint32(bp.Uint16())
+		bp.Skip(2) // protection index
+		it.Name = bp.CString()
+		it.ContentType = bp.CString()
+	case 2, 3:
+		if ver == 2 {
+			it.ID = uint32(bp.Uint16())
+		} else {
+			it.ID = bp.Uint32()
+		}
+		bp.Skip(2) // protection index
+		it.Type = bp.CC4()
+		it.Name = bp.CString()
+		if it.Type == "mime" {
+			it.ContentType = bp.CString()
+		}
+	default:
+		return it, formatError("unknown infe version %d", ver)
+	}
+
+	if bp.Short() {
+		return it, formatError("infe too short")
+	}
+	return it, nil
+}
+
+// decodeILOC decodes the item location box.
+func decodeILOC(p []byte) ([]Item, error) {
+	bp := newBoxParse(p)
+
+	ver := bp.next(4)[0]
+	if ver > 2 {
+		return nil, formatError("unknown iloc version %d", ver)
+	}
+
+	sizes := bp.next(2)
+	offsetSize := int(sizes[0] >> 4)
+	lengthSize := int(sizes[0] & 0xf)
+	baseOffsetSize := int(sizes[1] >> 4)
+	var indexSize int
+	if ver == 1 || ver == 2 {
+		indexSize = int(sizes[1] & 0xf)
+	}
+	for _, n := range []int{offsetSize, lengthSize, baseOffsetSize, indexSize} {
+		if n != 0 && n != 4 && n != 8 {
+			return nil, formatError("invalid iloc field size %d", n)
+		}
+	}
+
+	var n uint32
+	if ver < 2 {
+		n = uint32(bp.Uint16())
+	} else {
+		n = bp.Uint32()
+	}
+
+	var items []Item
+	for i := uint32(0); i < n && !bp.Short(); i++ {
+		var it Item
+		if ver < 2 {
+			it.ID = uint32(bp.Uint16())
+		} else {
+			it.ID = bp.Uint32()
+		}
+		if ver == 1 || ver == 2 {
+			it.ConstructionMethod = int(bp.Uint16() & 0xf)
+		}
+		bp.Skip(2) // data reference index
+		base := bp.UintN(baseOffsetSize)
+
+		nx := int(bp.Uint16())
+		for j := 0; j < nx && !bp.Short(); j++ {
+			bp.UintN(indexSize)
+			off := bp.UintN(offsetSize)
+			length := bp.UintN(lengthSize)
+			if base+off < base || base+off >= 1<<62 || length >= 1<<62 {
+				return nil, formatError("iloc item %d extent overflow", it.ID)
+			}
+			it.Extents = append(it.Extents, Extent{
+				Offset: int64(base + off),
+				Length: int64(length),
+			})
+		}
+		items = append(items, it)
+	}
+
+	if bp.Short() {
+		return nil, formatError("iloc too short")
+	}
+	return items, nil
+}
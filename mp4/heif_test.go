@@ -0,0 +1,179 @@
+package mp4_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/tajtiattila/metadata/mp4"
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestParseHEIF(t *testing.T) {
+	exif := []byte("\x00\x00\x00\x06Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08")
+	for ver := 0; ver <= 2; ver++ {
+		for _, idat := range []bool{false, true} {
+			src := heifFile(ver, idat, exif)
+			h, err := mp4.ParseHEIF(bytes.NewReader(src))
+			if err != nil {
+				t.Errorf("iloc v%d idat=%v: %v", ver, idat, err)
+				continue
+			}
+			if h.Brand != "heic" {
+				t.Errorf("iloc v%d idat=%v: got brand %q", ver, idat, h.Brand)
+			}
+			items := h.ItemsOfType("Exif")
+			if len(items) != 1 {
+				t.Errorf("iloc v%d idat=%v: got %d Exif items", ver, idat, len(items))
+				continue
+			}
+			if got := items[0].Data; !bytes.Equal(got, exif) {
+				t.Errorf("iloc v%d idat=%v: got Exif %q, want %q", ver, idat, got, exif)
+			}
+		}
+	}
+}
+
+// heifFile creates a HEIF file with an image and an Exif item.
+// The location of the Exif item data is recorded with iloc
+// version ver, and the data is stored in idat or mdat.
+func heifFile(ver int, idat bool, exif []byte) []byte {
+	ftyp := testutil.Box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+
+	iinf := testutil.Box("iinf", []byte{0, 0, 0, 0}, testutil.U16(2),
+		testutil.Box("infe", []byte{2, 0, 0, 0}, testutil.U16(1), testutil.U16(0), []byte("hvc1\x00")),
+		testutil.Box("infe", []byte{2, 0, 0, 0}, testutil.U16(2), testutil.U16(0), []byte("Exif\x00")),
+	)
+
+	image := []byte("image data")
+
+	mkmeta := func(mdatOffset int) []byte {
+		ilocEntry := func(id, method, offset, length int) []byte {
+			var p []byte
+			if ver < 2 {
+				p = append(p, testutil.U16(id)...)
+			} else {
+				p = append(p, testutil.U32(id)...)
+			}
+			if ver > 0 {
+				p = append(p, testutil.U16(method)...)
+			}
+			p = append(p, testutil.U16(0)...) // data reference index
+			p = append(p, testutil.U32(0)...) // base offset
+			p = append(p, testutil.U16(1)...) // extent count
+			p = append(p, testutil.U32(offset)...)
+			return append(p, testutil.U32(length)...)
+		}
+
+		var count []byte
+		if ver < 2 {
+			count = testutil.U16(2)
+		} else {
+			count = testutil.U32(2)
+		}
+
+		exifMethod, exifOffset := 0, mdatOffset+len(image)
+		var idatBox []byte
+		if idat && ver > 0 {
+			exifMethod, exifOffset = 1, 0
+			idatBox = testutil.Box("idat", exif)
+		}
+
+		iloc := testutil.Box("iloc", []byte{byte(ver), 0, 0, 0},
+			[]byte{0x44, 0x40}, count,
+			ilocEntry(1, 0, mdatOffset, len(image)),
+			ilocEntry(2, exifMethod, exifOffset, len(exif)))
+
+		hdlr := testutil.Box("hdlr", []byte{0, 0, 0, 0}, testutil.U32(0), []byte("pict"), make([]byte, 13))
+		return testutil.Box("meta", []byte{0, 0, 0, 0}, hdlr, iinf, iloc, idatBox)
+	}
+
+	// calculate mdat offset using a dummy meta
+	n := len(ftyp) + len(mkmeta(0)) + 8
+	mdat := testutil.Box("mdat", image, exif)
+	return bytes.Join([][]byte{ftyp, mkmeta(n), mdat}, nil)
+}
+
+func TestParseHEIFExtents(t *testing.T) {
+	exif := []byte("\x00\x00\x00\x06Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08")
+	head, tail := exif[:10], exif[10:]
+
+	// extents stored in reverse order in mdat
+	src := heifExtentFile(0, func(mdatOffset int) [][2]int {
+		return [][2]int{
+			{mdatOffset + len(tail), len(head)},
+			{mdatOffset, len(tail)},
+		}
+	}, nil, append(append([]byte{}, tail...), head...))
+	if got := heifExifData(t, "mdat", src); !bytes.Equal(got, exif) {
+		t.Errorf("mdat: got Exif %q, want %q", got, exif)
+	}
+
+	// zero length extent to end of idat
+	src = heifExtentFile(1, func(int) [][2]int {
+		return [][2]int{{0, len(head)}, {len(head), 0}}
+	}, exif, nil)
+	if got := heifExifData(t, "idat", src); !bytes.Equal(got, exif) {
+		t.Errorf("idat: got Exif %q, want %q", got, exif)
+	}
+
+	// zero length extent to end of file
+	src = heifExtentFile(0, func(mdatOffset int) [][2]int {
+		return [][2]int{{mdatOffset, 0}}
+	}, nil, exif)
+	if _, err := mp4.ParseHEIF(bytes.NewReader(src)); !errors.Is(err, mp4.ErrFormat) {
+		t.Errorf("end of file: got error %v, want one matching ErrFormat", err)
+	}
+}
+
+func heifExifData(t *testing.T, name string, src []byte) []byte {
+	h, err := mp4.ParseHEIF(bytes.NewReader(src))
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return nil
+	}
+	items := h.ItemsOfType("Exif")
+	if len(items) != 1 {
+		t.Errorf("%s: got %d Exif items", name, len(items))
+		return nil
+	}
+	return items[0].Data
+}
+
+// heifExtentFile creates a HEIF file with an Exif item
+// stored in the extents returned by extents, using construction
+// method 0 (file) or 1 (idat).
+func heifExtentFile(method int, extents func(mdatOffset int) [][2]int, idat, mdat []byte) []byte {
+	ftyp := testutil.Box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+
+	iinf := testutil.Box("iinf", []byte{0, 0, 0, 0}, testutil.U16(1),
+		testutil.Box("infe", []byte{2, 0, 0, 0}, testutil.U16(1), testutil.U16(0), []byte("Exif\x00")),
+	)
+
+	var idatBox []byte
+	if idat != nil {
+		idatBox = testutil.Box("idat", idat)
+	}
+
+	mkmeta := func(mdatOffset int) []byte {
+		ex := extents(mdatOffset)
+		entry := [][]byte{
+			testutil.U16(1),      // item ID
+			testutil.U16(method), // construction method
+			testutil.U16(0),      // data reference index
+			testutil.U32(0),      // base offset
+			testutil.U16(len(ex)),
+		}
+		for _, e := range ex {
+			entry = append(entry, testutil.U32(e[0]), testutil.U32(e[1]))
+		}
+		iloc := testutil.Box("iloc", []byte{1, 0, 0, 0},
+			[]byte{0x44, 0x40}, testutil.U16(1), bytes.Join(entry, nil))
+
+		hdlr := testutil.Box("hdlr", []byte{0, 0, 0, 0}, testutil.U32(0), []byte("pict"), make([]byte, 13))
+		return testutil.Box("meta", []byte{0, 0, 0, 0}, hdlr, iinf, iloc, idatBox)
+	}
+
+	n := len(ftyp) + len(mkmeta(0)) + 8
+	return bytes.Join([][]byte{ftyp, mkmeta(n), testutil.Box("mdat", mdat)}, nil)
+}
@@ -0,0 +1,105 @@
+package mp4
+
+import (
+	"time"
+)
+
+// MDHD is a media header box in trak/mdia/mdhd,
+// specifying the time scale and duration of a track's media.
+type MDHD struct {
+	Version      byte
+	Flags        [3]byte
+	DateCreated  time.Time // zero if not set
+	DateModified time.Time // zero if not set
+
+	TimeUnit        uint32 // time unit per second of the media
+	DurationInUnits uint64 // media length (in media time units)
+
+	// LanguageCode is the packed ISO-639-2/T language code, see Language.
+	LanguageCode uint16
+}
+
+var ErrShortMDHD = formatError("MDHD too short")
+
+func DecodeMDHD(p []byte) (*MDHD, error) {
+	m := new(MDHD)
+
+	bp := newBoxParse(p)
+
+	var err error
+	m.Version, m.Flags, err = bp.versionFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	m.DateCreated = bp.Date()
+	m.DateModified = bp.Date()
+	m.TimeUnit = bp.Uint32()
+	m.DurationInUnits = bp.UintVar()
+	m.LanguageCode = bp.Uint16()
+
+	if bp.Short() {
+		return nil, ErrShortMDHD
+	}
+
+	return m, nil
+}
+
+// Duration returns the media duration, or zero if the time unit is not set.
+func (m *MDHD) Duration() time.Duration {
+	if m.TimeUnit == 0 {
+		return 0
+	}
+	// split seconds to avoid overflow with long durations
+	u := uint64(m.TimeUnit)
+	sec, frac := m.DurationInUnits/u, m.DurationInUnits%u
+	return time.Duration(sec)*time.Second + time.Duration(frac)*time.Second/time.Duration(u)
+}
+
+// Language returns the ISO-639-2/T language code of the media,
+// such as "eng" or "und" (undetermined).
+//
+// The packed code holds three letters of 5 bits each
+// as offsets from 0x60. Language returns an empty string
+// if the packed code is invalid.
+func (m *MDHD) Language() string {
+	var p [3]byte
+	for i := range p {
+		c := byte(m.LanguageCode>>uint(10-5*i)) & 0x1f
+		if c == 0 || c > 26 {
+			return ""
+		}
+		p[i] = c + 0x60
+	}
+	return string(p[:])
+}
+
+/* MDHD http://xhelmboyx.tripod.com/formats/mp4-layout.txt
+
+   * 8+ bytes media header box
+       = long unsigned offset + long ASCII text string 'mdhd'
+     -> 1 byte version = byte unsigned value
+       - if version is 1 then date and duration values are 8 bytes in length
+     -> 3 bytes flags = 24-bit hex flags (current = 0)
+
+     -> 4 bytes created mac UTC date
+         = long unsigned value in seconds since beginning 1904 to 2040
+     -> 4 bytes modified mac UTC date
+         = long unsigned value in seconds since beginning 1904 to 2040
+     OR
+     -> 8 bytes created mac UTC date
+         = 64-bit unsigned value in seconds since beginning 1904
+     -> 8 bytes modified mac UTC date
+         = 64-bit unsigned value in seconds since beginning 1904
+
+     -> 4 bytes time scale = long unsigned time unit per second (default = 600)
+
+     -> 4 bytes duration = long unsigned time length (in time units)
+     OR
+     -> 8 bytes duration = 64-bit unsigned time length (in time units)
+
+     -> 2 bytes decimal language code
+         = short unsigned ISO-639-2/T language code
+       - the first bit is zero, then three 5-bit letters offset from 0x60
+     -> 2 bytes QUICKTIME quality = short integer playback quality value (normal = 0)
+*/
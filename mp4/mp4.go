@@ -3,27 +3,63 @@ package mp4
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"time"
 )
 
 type File struct {
 	Box
 
 	Header *MVHD // movid header
+
+	// src is used to copy box content not loaded in WriteTo
+	src     io.ReadSeeker
+	srcBase int64
+}
+
+// ParseOptions specifies optional behavior of ParseWithOptions.
+type ParseOptions struct {
+	// UserData enables loading udta boxes wherever they appear,
+	// including top-level udta and moof boxes of fragmented files
+	// that may be located after mdat.
+	//
+	// The children of udta, moof and traf boxes are unpacked,
+	// and the udta boxes may be retrieved using File.UserData.
+	// Top-level udta and moof boxes larger than the
+	// parse size limit are skipped.
+	UserData bool
 }
 
 // Parse parses an MP4 file from r.
 // If r is a io.ReadSeeker then it is used
-// to seek forward within r when necessary.
+// to seek forward within r when necessary,
+// and to copy box content not loaded in WriteTo.
 func Parse(r io.Reader) (*File, error) {
+	return ParseWithOptions(r, ParseOptions{})
+}
+
+// ParseWithOptions parses an MP4 file from r like Parse
+// using the options in opt.
+//
+// Top-level boxes are scanned until EOF. Boxes not needed,
+// such as mdat, are skipped using Seek if r is an io.Seeker,
+// otherwise they are read and discarded.
+func ParseWithOptions(r io.Reader, opt ParseOptions) (*File, error) {
 	p := parser{
-		r: r,
+		r:   r,
+		opt: opt,
 		f: &File{
 			Box: Box{Type: "MP4", Size: -1},
 		},
 	}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		if base, err := rs.Seek(0, 1); err == nil {
+			p.f.src, p.f.srcBase = rs, base
+		}
+	}
 	if err := p.Parse(); err != nil {
 		return nil, err
 	}
@@ -51,6 +87,12 @@ func Parse(r io.Reader) (*File, error) {
 	}
 	f.Header = h
 
+	if opt.UserData {
+		for i := range f.Child {
+			f.Child[i].unpackUserData()
+		}
+	}
+
 	// calc file size
 	for _, b := range f.Child {
 		f.Size += b.Size
@@ -109,6 +151,58 @@ func (f *File) AddUuid(data []byte) {
 	}
 }
 
+// UserData returns the loaded udta boxes in f, such as moov/udta,
+// moov/trak/udta, or top-level udta boxes in the order of appearance.
+//
+// Only udta boxes within moov are loaded unless
+// f was parsed with ParseOptions.UserData enabled.
+func (f *File) UserData() []*Box {
+	var v []*Box
+	var walk func(b *Box)
+	walk = func(b *Box) {
+		for i := range b.Child {
+			c := &b.Child[i]
+			if c.Type == "udta" {
+				if c.loaded() {
+					v = append(v, c)
+				}
+			} else {
+				walk(c)
+			}
+		}
+	}
+	walk(&f.Box)
+	return v
+}
+
+// Compact merges adjacent free boxes at the top level of f,
+// such as the ones left by AddUuid. If trim is true,
+// free space at the end of the file is removed.
+//
+// Other boxes are not moved, therefore offsets to mdat
+// remain valid.
+func (f *File) Compact(trim bool) {
+	var v []Box
+	for _, b := range f.Child {
+		if b.Type == "free" && b.Size != 0 && len(v) != 0 {
+			last := &v[len(v)-1]
+			if last.Type == "free" && last.Size != 0 {
+				*last = Box{
+					Offset: -1,
+					Size:   last.Size + b.Size,
+					Type:   "free",
+				}
+				continue
+			}
+		}
+		v = append(v, b)
+	}
+	if trim && len(v) != 0 && v[len(v)-1].Type == "free" {
+		v = v[:len(v)-1]
+	}
+	f.Child = v
+}
+
 // FrameSize returns the frame size of f.
 func (f *File) FrameSize() (width, height int, err error) {
 	moov := f.Find("moov")
@@ -129,8 +223,9 @@ func (f *File) FrameSize() (width, height int, err error) {
 		hd, err := DecodeTKHD(p.Raw)
 		if err != nil {
 			if firstErr == nil {
-				err = formatError("decode TKHD error %v", err)
+				firstErr = formatError("decode TKHD error %v", err)
 			}
+			continue
 		}
 		dx, dy := hd.FrameSize()
 		s := int64(dx) * int64(dy)
@@ -144,6 +239,95 @@ func (f *File) FrameSize() (width, height int, err error) {
 	return width, height, nil
 }
 
+// Duration returns the duration of f from the movie header.
+func (f *File) Duration() time.Duration {
+	return f.Header.Duration()
+}
+
+// TrackInfo describes a track within a File.
+type TrackInfo struct {
+	Header *TKHD // track header
+
+	// HandlerType is the handler type from mdia/hdlr,
+	// such as "vide" (video), "soun" (audio) or "subt" (subtitle).
+	HandlerType string
+
+	// Frame size of video tracks.
+	Width, Height int
+
+	Media *MDHD // media header, nil if missing
+
+	// SampleCount is the number of samples, such as video frames,
+	// and SampleDuration is their total duration in media time units
+	// from the stts box. Both are zero if stts is missing.
+	SampleCount, SampleDuration uint64
+}
+
+// FrameRate returns the average number of samples per second,
+// that is the frame rate for video tracks.
+// The media duration from the media header is used
+// if stts specifies no sample duration.
+// FrameRate returns zero if the rate can't be calculated.
+func (t TrackInfo) FrameRate() float64 {
+	if t.Media == nil || t.Media.TimeUnit == 0 || t.SampleCount == 0 {
+		return 0
+	}
+	d := t.SampleDuration
+	if d == 0 {
+		d = t.Media.DurationInUnits
+	}
+	if d == 0 {
+		return 0
+	}
+	return float64(t.SampleCount) * float64(t.Media.TimeUnit) / float64(d)
+}
+
+// Tracks returns information about the tracks in f.
+// Tracks without a valid track header are omitted.
+func (f *File) Tracks() []TrackInfo {
+	moov := f.Find("moov")
+	if moov == nil {
+		return nil
+	}
+
+	var v []TrackInfo
+	for i := range moov.Child {
+		b := &moov.Child[i]
+		if b.Type != "trak" {
+			continue
+		}
+		tkhd := b.Find("tkhd")
+		if tkhd == nil {
+			continue
+		}
+		hd, err := DecodeTKHD(tkhd.Raw)
+		if err != nil {
+			continue
+		}
+		t := TrackInfo{Header: hd}
+		if hdlr := b.Find("mdia", "hdlr"); hdlr != nil {
+			if h, err := DecodeHDLR(hdlr.Raw); err == nil {
+				t.HandlerType = h.HandlerType
+			}
+		}
+		if t.HandlerType == "vide" {
+			t.Width, t.Height = hd.FrameSize()
+		}
+		if mdhd := b.Find("mdia", "mdhd"); mdhd != nil {
+			if m, err := DecodeMDHD(mdhd.Raw); err == nil {
+				t.Media = m
+			}
+		}
+		if stts := b.Find("mdia", "minf", "stbl", "stts"); stts != nil {
+			if s, err := DecodeSTTS(stts.Raw); err == nil {
+				t.SampleCount, t.SampleDuration = s.Samples()
+			}
+		}
+		v = append(v, t)
+	}
+	return v
+}
+
 func (f *File) replace(idx int, newBox Box) bool {
 	oldBox := f.Child[idx]
 
@@ -255,19 +439,98 @@ func (b *Box) Find(typ ...string) *Box {
 	return nil
 }
 
-var parentBoxes = setOf("moov", "trak", "mdia", "minf", "stbl")
+// Walk calls fn for each box within b in depth-first order,
+// with path holding the box types from b to the box visited,
+// such as ["moov", "trak", "tkhd"]. Walk stops if fn returns false.
+//
+// Only the children already unpacked are visited.
+// The path slice is reused between calls and must not be retained.
+//
+// Boxes may be modified through the pointer passed to fn.
+// The Raw content of boxes with children is not used when packing
+// or writing the file, so edits should be made to the Raw content
+// of leaf boxes. Parent sizes are recalculated when the parents
+// are packed, such as by WriteTo.
+func (b *Box) Walk(fn func(path []string, b *Box) bool) {
+	b.walk(nil, fn)
+}
+
+func (b *Box) walk(path []string, fn func(path []string, b *Box) bool) bool {
+	for i := range b.Child {
+		c := &b.Child[i]
+		p := append(path, c.Type)
+		if !fn(p, c) || !c.walk(p, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+var parentBoxes = setOf("moov", "trak", "mdia", "minf", "stbl", "meta", "iprp", "ipco")
+
+// fullParentBoxes have version and flags before their children,
+// see childOffset.
+var fullParentBoxes = setOf("meta")
+
+// childOffset returns the offset of the first child box in b.Raw.
+//
+// The meta box is a full box in ISO files, but QuickTime moov/meta
+// boxes have no version and flags, and start with their hdlr child.
+func (b *Box) childOffset() int {
+	if _, ok := fullParentBoxes[b.Type]; !ok {
+		return 0
+	}
+	if len(b.Raw) >= 8 && string(b.Raw[4:8]) == "hdlr" {
+		return 0
+	}
+	return 4
+}
 
 func (b *Box) unpackChildren() error {
 	if _, ok := parentBoxes[b.Type]; !ok {
 		return nil
 	}
 
-	for off := 0; off < len(b.Raw); {
+	if err := b.unpackRaw(); err != nil {
+		return err
+	}
+
+	for i := range b.Child {
+		c := &b.Child[i]
+		if err := c.unpackChildren(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// userDataParentBoxes are unpacked with ParseOptions.UserData.
+var userDataParentBoxes = setOf("udta", "moof", "traf")
+
+// unpackUserData unpacks udta, moof and traf boxes within b.
+//
+// Boxes that fail to unpack are left as is, because the content
+// of udta is not always a list of boxes in older files.
+func (b *Box) unpackUserData() {
+	if _, ok := userDataParentBoxes[b.Type]; ok && b.Child == nil {
+		if err := b.unpackRaw(); err != nil {
+			b.Child = nil
+			return
+		}
+	}
+	for i := range b.Child {
+		b.Child[i].unpackUserData()
+	}
+}
+
+// unpackRaw unpacks the immediate children of b from b.Raw.
+func (b *Box) unpackRaw() error {
+	for off := b.childOffset(); off < len(b.Raw); {
 		if len(b.Raw[off:]) < 8 {
 			return formatError("%s unpack", b.Type)
 		}
 		c := Box{
-			Offset: b.Offset + int64(off),
+			Offset: b.Offset + b.HeaderSize() + int64(off),
 			Size:   int64(binary.BigEndian.Uint32(b.Raw[off:])),
 			Type:   string(b.Raw[off+4 : off+8]),
 		}
@@ -285,25 +548,18 @@ func (b *Box) unpackChildren() error {
 			c.Size = int64(len(b.Raw)-off) + b.HeaderSize()
 		}
 		if c.Size < c.HeaderSize() {
-			return fmt.Errorf("box size %d < %d invalid", c.Size, c.HeaderSize())
+			return formatError("box size %d < %d invalid", c.Size, c.HeaderSize())
 		}
 
 		off += 8
 		datalen := c.ContentSize()
 		if int64(len(b.Raw)) < int64(off)+datalen {
-			return formatError("%s/%s unpack EOF", b.Type, c.Type, c.Size)
+			return formatError("%s/%s unpack EOF", b.Type, c.Type)
 		}
 		c.Raw = b.Raw[off : off+int(datalen)]
 		b.Child = append(b.Child, c)
 		off += int(datalen)
 	}
-
-	for i := range b.Child {
-		c := &b.Child[i]
-		if err := c.unpackChildren(); err != nil {
-			return err
-		}
-	}
 	return nil
 }
 
@@ -322,13 +578,18 @@ func (b *Box) packedSize() int64 {
 		return boxSize(len(b.Raw))
 	}
 
-	var n int64
+	n := int64(b.childOffset())
 	for _, c := range b.Child {
 		n += c.packedSize()
 	}
 	return boxSize(int(n))
 }
 
+// packBox writes b into p at off, and returns the offset after b.
+//
+// The 8-byte header is used if the box size fits in 32 bits,
+// otherwise the 16-byte header with a 64-bit size, consistent with boxSize.
+// The header size of b in the original file is not retained.
 func packBox(b *Box, p []byte, off int) (noff int) {
 	// write cc4
 	copy(p[off+4:off+8], b.Type)
@@ -336,12 +597,12 @@ func packBox(b *Box, p []byte, off int) (noff int) {
 	// write size
 	size := b.packedSize()
 	if size < 1<<32 {
+		binary.BigEndian.PutUint32(p[off:], uint32(size))
+		off += 8
+	} else {
 		binary.BigEndian.PutUint32(p[off:], 1)
 		binary.BigEndian.PutUint64(p[off+8:], uint64(size))
 		off += 16
-	} else {
-		binary.BigEndian.PutUint32(p[off:], uint32(size))
-		off += 8
 	}
 
 	// write raw content if no children
@@ -350,7 +611,12 @@ func packBox(b *Box, p []byte, off int) (noff int) {
 		return off + len(b.Raw)
 	}
 
-	// write children
+	// write version and flags of full boxes, then children
+	start := b.childOffset()
+	if len(b.Raw) >= start {
+		copy(p[off:], b.Raw[:start])
+	}
+	off += start
 	for i := range b.Child {
 		off = packBox(&b.Child[i], p, off)
 	}
@@ -366,8 +632,9 @@ func setOf(v ...string) map[string]struct{} {
 }
 
 type parser struct {
-	r io.Reader
-	f *File
+	r   io.Reader
+	opt ParseOptions
+	f   *File
 
 	off int64 // offset in r
 
@@ -377,6 +644,12 @@ type parser struct {
 const maxParseSize = 1 << 20
 
 func (p *parser) Parse() error {
+	return p.parseUntil("")
+}
+
+// parseUntil parses top-level boxes until EOF,
+// or until a box of type cc4 has been read.
+func (p *parser) parseUntil(cc4 string) error {
 	for {
 		b, err := p.readAtomHeader()
 		if err != nil {
@@ -397,7 +670,11 @@ func (p *parser) Parse() error {
 			return p.finish(b)
 		}
 		contentSize := b.ContentSize()
-		if wantBox(b.Type) {
+		want := wantBox(b.Type)
+		if !want && p.wantUserData(b.Type) {
+			want = contentSize <= maxParseSize
+		}
+		if want {
 			if contentSize > maxParseSize {
 				return formatError("%s too long", b.Type)
 			}
@@ -412,12 +689,14 @@ func (p *parser) Parse() error {
 			}
 		}
 		p.f.Child = append(p.f.Child, b)
+		if b.Type == cc4 {
+			return nil
+		}
 	}
-	return nil
 }
 
 func (p *parser) finish(b Box) error {
-	if !wantBox(b.Type) {
+	if !wantBox(b.Type) && !p.wantUserData(b.Type) {
 		// unneeded box goes till EOF
 		p.f.Child = append(p.f.Child, b)
 		return nil
@@ -425,6 +704,12 @@ func (p *parser) finish(b Box) error {
 	var err error
 	b.Raw, err = ioutil.ReadAll(io.LimitReader(p.r, maxParseSize+1))
 	if len(b.Raw) > maxParseSize {
+		if !wantBox(b.Type) {
+			// optional user data, skip
+			b.Raw = nil
+			p.f.Child = append(p.f.Child, b)
+			return nil
+		}
 		return formatError("%s too long", b.Type)
 	}
 	if err != nil {
@@ -463,10 +748,18 @@ func wantBox(cc4 string) bool {
 		return true
 	case "uuid":
 		return true
+	case "meta":
+		return true
 	}
 	return false
 }
 
+// wantUserData reports whether top-level boxes of type cc4
+// should be loaded for ParseOptions.UserData.
+func (p *parser) wantUserData(cc4 string) bool {
+	return p.opt.UserData && (cc4 == "udta" || cc4 == "moof")
+}
+
 // read next atom header
 func (p *parser) readAtomHeader() (b Box, err error) {
 	x := make([]byte, 8)
@@ -491,7 +784,7 @@ func (p *parser) readAtomHeader() (b Box, err error) {
 		b.Size = int64(binary.BigEndian.Uint64(x))
 	}
 	if b.Size != 0 && b.Size < b.HeaderSize() {
-		return Box{}, fmt.Errorf("box size %d < %d invalid", b.Size, b.HeaderSize())
+		return Box{}, formatError("box size %d < %d invalid", b.Size, b.HeaderSize())
 	}
 
 	return b, nil
@@ -517,6 +810,20 @@ func boxSizePossible(size, space int64) bool {
 	return space-size >= 8
 }
 
+// ErrFormat is matched by errors.Is for errors caused by
+// invalid or unsupported data, such as missing or corrupt boxes.
+var ErrFormat = errors.New("mp4: invalid format")
+
+// formatErr is an error for invalid data.
+// The message is kept as is, but it matches ErrFormat.
+type formatErr struct {
+	msg string
+}
+
+func (e *formatErr) Error() string { return e.msg }
+
+func (e *formatErr) Is(target error) bool { return target == ErrFormat }
+
 func formatError(f string, args ...interface{}) error {
-	return fmt.Errorf(f, args...)
+	return &formatErr{fmt.Sprintf(f, args...)}
 }
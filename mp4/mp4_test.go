@@ -1,7 +1,11 @@
 package mp4_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/tajtiattila/metadata/mp4"
@@ -66,3 +70,188 @@ func testParse(t *testing.T, e testutil.FileInfo) {
 		t.Errorf("got size %vx%v, want %vx%v", sx, sy, ex, ey)
 	}
 }
+
+func TestParseUserData(t *testing.T) {
+	xyz := func(s string) []byte {
+		return testutil.Box("udta", testutil.Box("\xa9xyz", testutil.U16(len(s)), testutil.U16(0), []byte(s)))
+	}
+	mvhd := testutil.Box("mvhd", make([]byte, 12), testutil.U32(600), testutil.U32(6000), make([]byte, 80))
+	src := bytes.Join([][]byte{
+		testutil.Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		testutil.Box("moov", mvhd, trak(1, 640, 480, "vide"), xyz("moov")),
+		testutil.Box("mdat", []byte("data")),
+		xyz("+47.4979+019.0402/"),
+		testutil.Box("moof", testutil.Box("mfhd", make([]byte, 8)),
+			testutil.Box("traf", testutil.Box("tfhd", make([]byte, 8)), xyz("traf"))),
+	}, nil)
+
+	readers := map[string]func() io.Reader{
+		"seeker": func() io.Reader { return bytes.NewReader(src) },
+		"reader": func() io.Reader { return struct{ io.Reader }{bytes.NewReader(src)} },
+	}
+	for name, rf := range readers {
+		f, err := mp4.Parse(rf())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if n := len(f.UserData()); n != 1 {
+			t.Errorf("%s: got %d udta boxes by default, want 1", name, n)
+		}
+
+		f, err = mp4.ParseWithOptions(rf(), mp4.ParseOptions{UserData: true})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []string
+		for _, b := range f.UserData() {
+			if c := b.Find("\xa9xyz"); c != nil && len(c.Raw) > 4 {
+				got = append(got, string(c.Raw[4:]))
+			}
+		}
+		want := []string{"moov", "+47.4979+019.0402/", "traf"}
+		if len(got) != len(want) {
+			t.Errorf("%s: got user data %q, want %q", name, got, want)
+		} else {
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s: got user data %q, want %q", name, got, want)
+					break
+				}
+			}
+		}
+
+		if name != "seeker" {
+			continue
+		}
+		var buf bytes.Buffer
+		if _, err := f.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), src) {
+			t.Error("unmodified file with user data not written as is")
+		}
+	}
+}
+
+func TestBoxWalk(t *testing.T) {
+	f, err := mp4.Parse(bytes.NewReader(mp4File()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	f.Walk(func(path []string, b *mp4.Box) bool {
+		got = append(got, strings.Join(path, "/"))
+		return true
+	})
+	want := []string{
+		"ftyp",
+		"moov",
+		"moov/mvhd",
+		"moov/trak",
+		"moov/trak/tkhd",
+		"moov/trak/mdia",
+		"moov/trak/mdia/mdhd",
+		"moov/trak/mdia/hdlr",
+		"moov/trak/mdia/minf",
+		"moov/trak/mdia/minf/stbl",
+		"moov/trak/mdia/minf/stbl/stts",
+		"moov/trak",
+		"moov/trak/tkhd",
+		"moov/trak/mdia",
+		"moov/trak/mdia/hdlr",
+		"mdat",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Walk got\n%q\nwant\n%q", got, want)
+	}
+
+	// stop early, and modify the handler of the first track
+	var n int
+	f.Walk(func(path []string, b *mp4.Box) bool {
+		n++
+		if b.Type == "hdlr" {
+			copy(b.Raw[8:], "text")
+			return false
+		}
+		return true
+	})
+	if n != 8 {
+		t.Errorf("Walk stopped after %d boxes, want 8", n)
+	}
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	g, err := mp4.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := g.Tracks(); len(tr) != 2 || tr[0].HandlerType != "text" {
+		t.Errorf("modified handler not written: %+v", tr)
+	}
+}
+
+func TestParseMeta(t *testing.T) {
+	hdlr := testutil.Box("hdlr", make([]byte, 8), []byte("mdta"), make([]byte, 12), []byte("\x00"))
+	keys := testutil.Box("keys", make([]byte, 4), testutil.U32(0))
+	tests := []struct {
+		name string
+		meta []byte
+	}{
+		// QuickTime: no version and flags
+		{"quicktime", testutil.Box("meta", hdlr, keys)},
+		// ISO full box
+		{"iso", testutil.Box("meta", []byte{0, 0, 0, 0}, hdlr, keys)},
+	}
+	for _, tt := range tests {
+		mvhd := testutil.Box("mvhd", make([]byte, 12), testutil.U32(600), testutil.U32(6000), make([]byte, 80))
+		src := bytes.Join([][]byte{
+			testutil.Box("ftyp", []byte("qt  \x00\x00\x02\x00qt  ")),
+			testutil.Box("moov", mvhd, trak(1, 640, 480, "vide"), tt.meta),
+			testutil.Box("mdat", []byte("data")),
+		}, nil)
+
+		f, err := mp4.Parse(bytes.NewReader(src))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if f.Find("moov", "meta", "hdlr") == nil || f.Find("moov", "meta", "keys") == nil {
+			t.Errorf("%s: meta children missing", tt.name)
+		}
+
+		var buf bytes.Buffer
+		if _, err := f.WriteTo(&buf); err != nil {
+			t.Errorf("%s: WriteTo: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), src) {
+			t.Errorf("%s: meta not written as is", tt.name)
+		}
+	}
+}
+
+func TestErrFormat(t *testing.T) {
+	ftyp := testutil.Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41"))
+	tests := map[string][]byte{
+		"no moov":     bytes.Join([][]byte{ftyp, testutil.Box("mdat", []byte("data"))}, nil),
+		"no ftyp":     testutil.Box("mdat", []byte("data")),
+		"short mvhd":  bytes.Join([][]byte{ftyp, testutil.Box("moov", testutil.Box("mvhd", make([]byte, 12)))}, nil),
+		"invalid box": append(append([]byte(nil), ftyp...), 0, 0, 0, 4, 'm', 'o', 'o', 'v'),
+	}
+	for name, src := range tests {
+		_, err := mp4.Parse(bytes.NewReader(src))
+		if !errors.Is(err, mp4.ErrFormat) {
+			t.Errorf("%s: got error %v, want one matching ErrFormat", name, err)
+		}
+	}
+
+	if !errors.Is(mp4.ErrShortMVHD, mp4.ErrFormat) {
+		t.Error("ErrShortMVHD does not match ErrFormat")
+	}
+	if errors.Is(io.ErrUnexpectedEOF, mp4.ErrFormat) {
+		t.Error("io.ErrUnexpectedEOF matches ErrFormat")
+	}
+}
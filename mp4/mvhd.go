@@ -7,8 +7,8 @@ import (
 type MVHD struct {
 	Version      byte
 	Flags        [3]byte
-	DateCreated  time.Time
-	DateModified time.Time
+	DateCreated  time.Time // zero if not set
+	DateModified time.Time // zero if not set
 
 	TimeUnit        uint32 // time unit per second (default = 600)
 	DurationInUnits uint64 // time length (in time units)
@@ -0,0 +1,72 @@
+package mp4_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata/mp4"
+)
+
+func TestDecodeMVHDDate(t *testing.T) {
+	date := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	wrapped := time.Date(2041, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		raw     []byte
+		created time.Time
+	}{
+		// version 0, 32-bit dates
+		{
+			append([]byte{0, 0, 0, 0,
+				0xe0, 0xb0, 0xad, 0xc0, // created
+				0xe0, 0xb0, 0xad, 0xc0, // modified
+				0, 0, 0x02, 0x58, // time unit
+				0, 0, 0x17, 0x70, // duration
+			}, make([]byte, 80)...),
+			date,
+		},
+		// version 1, 64-bit dates
+		{
+			append([]byte{1, 0, 0, 0,
+				0, 0, 0, 0, 0xe0, 0xb0, 0xad, 0xc0,
+				0, 0, 0, 0, 0xe0, 0xb0, 0xad, 0xc0,
+				0, 0, 0x02, 0x58,
+				0, 0, 0, 0, 0, 0, 0x17, 0x70,
+			}, make([]byte, 80)...),
+			date,
+		},
+		// version 0, wrapped after 2040
+		{
+			append([]byte{0, 0, 0, 0,
+				0x02, 0x00, 0x7c, 0x80,
+				0x02, 0x00, 0x7c, 0x80,
+				0, 0, 0x02, 0x58,
+				0, 0, 0x17, 0x70,
+			}, make([]byte, 80)...),
+			wrapped,
+		},
+		// unset
+		{
+			append([]byte{0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0x02, 0x58,
+				0, 0, 0x17, 0x70,
+			}, make([]byte, 80)...),
+			time.Time{},
+		},
+	}
+	for i, tt := range tests {
+		m, err := mp4.DecodeMVHD(tt.raw)
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if !m.DateCreated.Equal(tt.created) || !m.DateModified.Equal(tt.created) {
+			t.Errorf("test %d: got dates %v, %v; want %v", i, m.DateCreated, m.DateModified, tt.created)
+		}
+		if m.Duration() != 10*time.Second {
+			t.Errorf("test %d: got duration %v", i, m.Duration())
+		}
+	}
+}
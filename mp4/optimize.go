@@ -6,7 +6,7 @@ import (
 )
 
 func (f *File) Optimize() error {
-	sort.Stable(topLevelBoxSort(f.Child))
+	sortTopLevel(f.Child)
 
 	moov := f.Box.Find("moov")
 	if moov == nil {
@@ -33,11 +33,10 @@ func (f *File) Optimize() error {
 	// calc final moov size
 	baselen, noffs := analyseMoov(moov)
 	use64bit := false
-	if s32 := lenxmoov + baselen + 4*int64(noffs); s32 >= 1<<32 {
+	moov.Size = boxSize(int(baselen) + 4*noffs)
+	if lenxmoov+moov.Size >= 1<<32 {
 		use64bit = true
-		moov.Size = lenxmoov + baselen + 8*int64(noffs)
-	} else {
-		moov.Size = s32
+		moov.Size = boxSize(int(baselen) + 8*noffs)
 	}
 
 	// calc new mdat offsets
@@ -61,13 +60,36 @@ func (f *File) Optimize() error {
 	return nil
 }
 
-type topLevelBoxSort []Box
+// sortTopLevel sorts top-level boxes using boxIdx,
+// keeping the order of boxes having the same index.
+//
+// A wide box immediately before mdat is kept there, because
+// QuickTime uses it to reserve space for a 64-bit mdat header.
+func sortTopLevel(boxes []Box) {
+	s := topLevelBoxSort{boxes, make([]int, len(boxes))}
+	for i := range boxes {
+		s.idx[i] = boxIdx(boxes[i].Type)
+		if boxes[i].Type == "wide" && i+1 < len(boxes) && boxes[i+1].Type == "mdat" {
+			s.idx[i] = boxIdx("mdat")
+		}
+	}
+	sort.Stable(s)
+}
+
+type topLevelBoxSort struct {
+	b   []Box
+	idx []int
+}
 
-func (s topLevelBoxSort) Len() int      { return len(s) }
-func (s topLevelBoxSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s topLevelBoxSort) Len() int { return len(s.b) }
+
+func (s topLevelBoxSort) Swap(i, j int) {
+	s.b[i], s.b[j] = s.b[j], s.b[i]
+	s.idx[i], s.idx[j] = s.idx[j], s.idx[i]
+}
 
 func (s topLevelBoxSort) Less(i, j int) bool {
-	return boxIdx(s[i].Type) < boxIdx(s[j].Type)
+	return s.idx[i] < s.idx[j]
 }
 
 func boxIdx(cc4 string) int {
@@ -82,29 +104,42 @@ func boxIdx(cc4 string) int {
 		return 3
 	case "mdat":
 		return 4
+	case "free", "skip", "wide":
+		// free space goes last
+		return 5
 	}
 }
 
+// analyseMoov returns the content length of moov without the
+// chunk offsets, and the number of chunk offsets in moov.
+//
+// The chunk offset boxes replaced by shiftMoovOffsets are
+// counted with their header, version, flags and entry count.
 func analyseMoov(moov *Box) (baselen int64, noffsets int) {
-	for _, c := range moov.Child {
-		baselen += c.Size
-		if c.Type == "trak" {
-			stbl := c.Find("mdia", "minf", "stbl")
-			if stbl == nil {
-				continue
-			}
-			stco, co64 := stbl.Find("stco"), stbl.Find("co64")
-			if n, _ := checkOffsetBlock(stco); n != 0 {
-				noffsets += n
-				if co64 != nil {
-					// has both co64
-					baselen -= co64.Size
-				}
-			} else {
-				if n, _ := checkOffsetBlock(co64); n != 0 {
-					noffsets += n
-				}
-			}
+	for i := range moov.Child {
+		c := &moov.Child[i]
+		baselen += c.packedSize()
+		if c.Type != "trak" {
+			continue
+		}
+		stbl := c.Find("mdia", "minf", "stbl")
+		if stbl == nil {
+			continue
+		}
+		src, other := stbl.Find("stco"), stbl.Find("co64")
+		n, _ := checkOffsetBlock(src)
+		if n == 0 {
+			src, other = other, src
+			n, _ = checkOffsetBlock(src)
+		}
+		if n == 0 {
+			continue
+		}
+		noffsets += n
+		baselen -= src.packedSize() - boxSize(8)
+		if other != nil {
+			// both stco and co64 are present
+			baselen -= other.packedSize()
 		}
 	}
 	return baselen, noffsets
@@ -125,8 +160,10 @@ func shiftMoovOffsets(moov *Box, oo, no []int64, use64bit bool) {
 
 			// read old offsets
 			stco, co64 := stbl.Find("stco"), stbl.Find("co64")
+			src := stco
 			n, xf := checkOffsetBlock(stco)
 			if n == 0 {
+				src = co64
 				n, xf = checkOffsetBlock(co64)
 			}
 			if n == 0 {
@@ -156,18 +193,19 @@ func shiftMoovOffsets(moov *Box, oo, no []int64, use64bit bool) {
 			if use64bit {
 				cc4 = "co64"
 				p = make([]byte, 8+8*len(offsets))
-				binary.BigEndian.PutUint32(p[4:], uint32(len(offsets)))
 				for i, off := range offsets {
 					binary.BigEndian.PutUint64(p[8+i*8:], uint64(off))
 				}
 			} else {
 				cc4 = "stco"
-				p = make([]byte, 4+4*len(offsets))
-				binary.BigEndian.PutUint32(p, uint32(len(offsets)))
+				p = make([]byte, 8+4*len(offsets))
 				for i, off := range offsets {
-					binary.BigEndian.PutUint32(p[4+i*4:], uint32(off))
+					binary.BigEndian.PutUint32(p[8+i*4:], uint32(off))
 				}
 			}
+			// keep version and flags
+			copy(p[:4], src.Raw)
+			binary.BigEndian.PutUint32(p[4:], uint32(len(offsets)))
 			newBox := Box{
 				Offset: -1,
 				Size:   boxSize(len(p)),
@@ -189,33 +227,40 @@ func shiftMoovOffsets(moov *Box, oo, no []int64, use64bit bool) {
 	}
 }
 
+// checkOffsetBlock checks the chunk offset box b (stco or co64).
+//
+// Both boxes start with version and flags (4 bytes) and
+// the entry count (4 bytes) followed by the 32-bit (stco)
+// or 64-bit (co64) chunk offsets.
+//
+// It returns the number of offsets and a function to extract them,
+// or 0 and nil if b is nil or malformed.
 func checkOffsetBlock(b *Box) (noffsets int, extract func(i int) int64) {
 	if b == nil {
 		return 0, nil
 	}
+	var esize int
 	switch b.Type {
 	case "stco":
-		if len(b.Raw) < 4 {
-			return 0, nil
-		}
-		n := int(binary.BigEndian.Uint32(b.Raw))
-		if 4+n*4 <= len(b.Raw) {
-			return 0, nil
-		}
-		return n, func(i int) int64 {
-			return int64(binary.BigEndian.Uint32(b.Raw[4+i*4:]))
-		}
+		esize = 4
 	case "co64":
-		if len(b.Raw) < 8 {
-			return 0, nil
-		}
-		n := int(binary.BigEndian.Uint32(b.Raw[4:]))
-		if 8+n*8 <= len(b.Raw) {
-			return 0, nil
-		}
-		return n, func(i int) int64 {
-			return int64(binary.BigEndian.Uint64(b.Raw[8+i*8:]))
+		esize = 8
+	default:
+		return 0, nil
+	}
+	if len(b.Raw) < 8 {
+		return 0, nil
+	}
+	n := int64(binary.BigEndian.Uint32(b.Raw[4:]))
+	if n > int64(len(b.Raw)-8)/int64(esize) {
+		return 0, nil
+	}
+	if esize == 4 {
+		return int(n), func(i int) int64 {
+			return int64(binary.BigEndian.Uint32(b.Raw[8+i*4:]))
 		}
 	}
-	return 0, nil
+	return int(n), func(i int) int64 {
+		return int64(binary.BigEndian.Uint64(b.Raw[8+i*8:]))
+	}
 }
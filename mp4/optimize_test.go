@@ -0,0 +1,99 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func offsetBox(cc4 string, vf []byte, n int, offsets ...int64) *Box {
+	esize := 4
+	if cc4 == "co64" {
+		esize = 8
+	}
+	p := make([]byte, 8+esize*len(offsets))
+	copy(p, vf)
+	binary.BigEndian.PutUint32(p[4:], uint32(n))
+	for i, o := range offsets {
+		if esize == 4 {
+			binary.BigEndian.PutUint32(p[8+i*4:], uint32(o))
+		} else {
+			binary.BigEndian.PutUint64(p[8+i*8:], uint64(o))
+		}
+	}
+	return &Box{Type: cc4, Size: boxSize(len(p)), Raw: p}
+}
+
+func TestCheckOffsetBlock(t *testing.T) {
+	vf := []byte{0, 0, 0, 0}
+	tests := []struct {
+		b    *Box
+		want []int64
+	}{
+		{nil, nil},
+		{offsetBox("stco", vf, 2, 120, 150), []int64{120, 150}},
+		{offsetBox("co64", vf, 2, 120, 1<<33), []int64{120, 1 << 33}},
+		{offsetBox("stco", vf, 0), nil},
+
+		// malformed: count exceeds data
+		{offsetBox("stco", vf, 3, 120, 150), nil},
+		{offsetBox("co64", vf, 1<<31, 120), nil},
+		{&Box{Type: "stco", Raw: []byte{0, 0, 0, 0, 0, 0}}, nil},
+	}
+	for i, tt := range tests {
+		n, xf := checkOffsetBlock(tt.b)
+		if n != len(tt.want) {
+			t.Errorf("test %d: got %d offsets, want %d", i, n, len(tt.want))
+			continue
+		}
+		for j, w := range tt.want {
+			if got := xf(j); got != w {
+				t.Errorf("test %d: offset %d is %d, want %d", i, j, got, w)
+			}
+		}
+	}
+}
+
+func TestShiftMoovOffsets(t *testing.T) {
+	vf := []byte{0, 0xa, 0xb, 0xc}
+	for _, use64bit := range []bool{false, true} {
+		stco := offsetBox("stco", vf, 3, 50, 120, 150)
+		moov := &Box{
+			Type: "moov",
+			Child: []Box{{Type: "trak", Child: []Box{
+				{Type: "mdia", Child: []Box{
+					{Type: "minf", Child: []Box{
+						{Type: "stbl", Child: []Box{*stco}},
+					}},
+				}},
+			}}},
+		}
+
+		shiftMoovOffsets(moov, []int64{100}, []int64{40}, use64bit)
+
+		stbl := moov.Find("trak", "mdia", "minf", "stbl")
+		if len(stbl.Child) != 1 {
+			t.Fatalf("got %d stbl children, want 1", len(stbl.Child))
+		}
+		b := &stbl.Child[0]
+		if want := map[bool]string{false: "stco", true: "co64"}[use64bit]; b.Type != want {
+			t.Errorf("got %s box, want %s", b.Type, want)
+		}
+		if string(b.Raw[:4]) != string(vf) {
+			t.Errorf("version/flags not preserved: got %x, want %x", b.Raw[:4], vf)
+		}
+		if b.Size != boxSize(len(b.Raw)) {
+			t.Errorf("box size %d invalid for %d bytes", b.Size, len(b.Raw))
+		}
+
+		n, xf := checkOffsetBlock(b)
+		want := []int64{50, 60, 90}
+		if n != len(want) {
+			t.Fatalf("got %d offsets, want %d", n, len(want))
+		}
+		for i, w := range want {
+			if got := xf(i); got != w {
+				t.Errorf("offset %d is %d, want %d", i, got, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package mp4
+
+// STTS is a decoding time to sample box in trak/mdia/minf/stbl/stts.
+type STTS struct {
+	Version byte
+	Flags   [3]byte
+
+	Entries []TimeToSample
+}
+
+// TimeToSample is an entry of STTS, specifying
+// Count consecutive samples each lasting Delta media time units.
+type TimeToSample struct {
+	Count uint32
+	Delta uint32
+}
+
+var ErrShortSTTS = formatError("STTS too short")
+
+func DecodeSTTS(p []byte) (*STTS, error) {
+	s := new(STTS)
+
+	bp := newBoxParse(p)
+
+	var err error
+	s.Version, s.Flags, err = bp.versionFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	n := bp.Uint32()
+	if bp.Short() || uint64(n)*8 > uint64(len(bp.Rest())) {
+		return nil, ErrShortSTTS
+	}
+
+	s.Entries = make([]TimeToSample, n)
+	for i := range s.Entries {
+		s.Entries[i].Count = bp.Uint32()
+		s.Entries[i].Delta = bp.Uint32()
+	}
+	return s, nil
+}
+
+// Samples returns the total number of samples
+// and their total duration in media time units.
+func (s *STTS) Samples() (count, duration uint64) {
+	for _, e := range s.Entries {
+		count += uint64(e.Count)
+		duration += uint64(e.Count) * uint64(e.Delta)
+	}
+	return count, duration
+}
+
+/* STTS http://xhelmboyx.tripod.com/formats/mp4-layout.txt
+
+   * 8+ bytes decoding time to sample box
+       = long unsigned offset + long ASCII text string 'stts'
+     -> 1 byte version = byte unsigned value (current = 0)
+     -> 3 bytes flags = 24-bit hex flags (current = 0)
+
+     -> 4 bytes number of times = long unsigned total
+
+     -> 4 bytes sample count = long unsigned number of consecutive samples
+     -> 4 bytes sample duration = long unsigned time length (in media time units)
+       - the count and duration pair repeats for each time
+*/
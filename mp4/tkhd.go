@@ -1,16 +1,25 @@
 package mp4
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type TKHD struct {
 	Version      byte
 	Flags        [3]byte
-	DateCreated  time.Time
-	DateModified time.Time
+	DateCreated  time.Time // zero if not set
+	DateModified time.Time // zero if not set
 
 	TrackId         uint32
 	DurationInUnits uint64 // time length (in time units; see MVHD)
 
+	// Matrix is the video transformation matrix,
+	// with values {a, b, u, c, d, v, x, y, w}.
+	// Values are 16.16 fixed point numbers, except for u, v and w
+	// that are 2.30 fixed point numbers. See Rotation.
+	Matrix [9]int32
+
 	Width, Height uint32 // fixed point, see FrameSize
 }
 
@@ -30,9 +39,12 @@ func DecodeTKHD(p []byte) (*TKHD, error) {
 	h.DateCreated = bp.Date()
 	h.DateModified = bp.Date()
 	h.TrackId = bp.Uint32()
-	bp.Skip(8)
+	bp.Skip(4) // reserved
 	h.DurationInUnits = bp.UintVar()
-	bp.Skip(48)
+	bp.Skip(16) // reserved, layer, alternate group, volume, reserved
+	for i := range h.Matrix {
+		h.Matrix[i] = int32(bp.Uint32())
+	}
 	h.Width = bp.Uint32()
 	h.Height = bp.Uint32()
 
@@ -47,6 +59,23 @@ func (t *TKHD) FrameSize() (w, h int) {
 	return int(t.Width >> 16), int(t.Height >> 16)
 }
 
+// Rotation returns the clockwise rotation of the video
+// in degrees, derived from the transformation matrix.
+// It returns one of 0, 90, 180 or 270.
+func (t *TKHD) Rotation() int {
+	a, b := float64(t.Matrix[0]), float64(t.Matrix[1])
+	if a == 0 && b == 0 {
+		// invalid matrix
+		return 0
+	}
+	deg := math.Atan2(b, a) * 180 / math.Pi
+	r := int(math.Floor(deg/90+0.5)) * 90
+	if r < 0 {
+		r += 360
+	}
+	return r % 360
+}
+
 /* TKHD http://xhelmboyx.tripod.com/formats/mp4-layout.txt
 
 * 8+ bytes track (element) box = long unsigned offset + long ASCII text string 'trak'
@@ -0,0 +1,225 @@
+package mp4_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata/mp4"
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestTracks(t *testing.T) {
+	f, err := mp4.Parse(bytes.NewReader(mp4File()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := f.Duration(); d != 10*time.Second {
+		t.Errorf("got duration %v, want 10s", d)
+	}
+
+	tracks := f.Tracks()
+	if len(tracks) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(tracks))
+	}
+
+	v, a := tracks[0], tracks[1]
+	if v.HandlerType != "vide" || v.Width != 640 || v.Height != 480 {
+		t.Errorf("video track is %s %dx%d, want vide 640x480", v.HandlerType, v.Width, v.Height)
+	}
+	if v.Header.TrackId != 1 || v.Header.DurationInUnits != 6000 {
+		t.Errorf("video track header is %+v", v.Header)
+	}
+	if a.HandlerType != "soun" || a.Width != 0 || a.Height != 0 {
+		t.Errorf("audio track is %s %dx%d, want soun 0x0", a.HandlerType, a.Width, a.Height)
+	}
+	if a.Header.TrackId != 2 {
+		t.Errorf("audio track id is %d, want 2", a.Header.TrackId)
+	}
+
+	if v.Media == nil || v.Media.TimeUnit != 30000 || v.Media.Duration() != 10*time.Second || v.Media.Language() != "und" {
+		t.Errorf("video media header is %+v", v.Media)
+	}
+	if v.SampleCount != 300 || v.SampleDuration != 300000 {
+		t.Errorf("video track has %d samples of total duration %d, want 300 and 300000",
+			v.SampleCount, v.SampleDuration)
+	}
+	if fr := v.FrameRate(); fr != 30 {
+		t.Errorf("video frame rate is %v, want 30", fr)
+	}
+	if fr := a.FrameRate(); fr != 0 {
+		t.Errorf("audio frame rate without stts is %v, want 0", fr)
+	}
+}
+
+func TestFrameRate(t *testing.T) {
+	mdhd := &mp4.MDHD{TimeUnit: 30000, DurationInUnits: 300000}
+	tests := []struct {
+		t    mp4.TrackInfo
+		want float64
+	}{
+		// variable frame rate yields the average
+		{mp4.TrackInfo{Media: mdhd, SampleCount: 300, SampleDuration: 150*1001 + 150*999}, 30},
+		// no sample durations in stts
+		{mp4.TrackInfo{Media: mdhd, SampleCount: 250}, 25},
+		{mp4.TrackInfo{Media: mdhd}, 0},
+		{mp4.TrackInfo{SampleCount: 300, SampleDuration: 300000}, 0},
+	}
+	for i, tt := range tests {
+		if got := tt.t.FrameRate(); got != tt.want {
+			t.Errorf("%d: got frame rate %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeMDHD(t *testing.T) {
+	// version 1 with 64-bit dates and duration, language "hun"
+	lang := ('h'-0x60)<<10 | ('u'-0x60)<<5 | ('n' - 0x60)
+	src := bytes.Join([][]byte{
+		{1, 0, 0, 0}, make([]byte, 16), testutil.U32(1000), testutil.U32(1), testutil.U32(0), testutil.U16(int(lang)), testutil.U16(0),
+	}, nil)
+	m, err := mp4.DecodeMDHD(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TimeUnit != 1000 || m.DurationInUnits != 1<<32 || m.Language() != "hun" {
+		t.Errorf("got %+v", m)
+	}
+
+	// a week in 90 kHz units overflows time.Duration if multiplied first
+	week := &mp4.MDHD{TimeUnit: 90000, DurationInUnits: 7*86400*90000 + 45000}
+	if got, want := week.Duration(), 7*24*time.Hour+500*time.Millisecond; got != want {
+		t.Errorf("duration is %v, want %v", got, want)
+	}
+
+	for code, want := range map[uint16]string{0: "", 0x7fff: "", 0x55c4: "und"} {
+		if got := (&mp4.MDHD{LanguageCode: code}).Language(); got != want {
+			t.Errorf("language %#x is %q, want %q", code, got, want)
+		}
+	}
+
+	if _, err := mp4.DecodeMDHD(src[:len(src)-4]); err == nil {
+		t.Error("short mdhd decoded without error")
+	}
+}
+
+func TestDecodeSTTS(t *testing.T) {
+	src := bytes.Join([][]byte{make([]byte, 4), testutil.U32(2), testutil.U32(10), testutil.U32(100), testutil.U32(5), testutil.U32(200)}, nil)
+	s, err := mp4.DecodeSTTS(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, d := s.Samples(); n != 15 || d != 2000 {
+		t.Errorf("got %d samples of duration %d, want 15 and 2000", n, d)
+	}
+
+	if _, err := mp4.DecodeSTTS(src[:len(src)-1]); err == nil {
+		t.Error("short stts decoded without error")
+	}
+}
+
+// mp4File returns a minimal MP4 with a video and an audio track
+// of 10 seconds in 600 time units per second.
+func mp4File() []byte {
+	ftyp := testutil.Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41"))
+	mvhd := testutil.Box("mvhd", make([]byte, 12), testutil.U32(600), testutil.U32(6000), make([]byte, 80))
+	moov := testutil.Box("moov", mvhd,
+		trak(1, 640, 480, "vide"),
+		trak(2, 0, 0, "soun"),
+	)
+	mdat := testutil.Box("mdat", []byte("data"))
+	return bytes.Join([][]byte{ftyp, moov, mdat}, nil)
+}
+
+func trak(id, dx, dy int, handler string) []byte {
+	tkhd := testutil.Box("tkhd",
+		[]byte{0, 0, 0, 3}, // version and flags
+		make([]byte, 8),    // dates
+		testutil.U32(id),
+		make([]byte, 4),    // reserved
+		testutil.U32(6000), // duration
+		make([]byte, 52),
+		testutil.U32(dx<<16), testutil.U32(dy<<16))
+	hdlr := testutil.Box("hdlr", make([]byte, 8), []byte(handler), make([]byte, 12), []byte("Handler\x00"))
+	if handler != "vide" {
+		return testutil.Box("trak", tkhd, testutil.Box("mdia", hdlr))
+	}
+
+	// 300 frames at 30 fps, language "und"
+	und := ('u'-0x60)<<10 | ('n'-0x60)<<5 | ('d' - 0x60)
+	mdhd := testutil.Box("mdhd", make([]byte, 12), testutil.U32(30000), testutil.U32(300000), testutil.U16(int(und)), testutil.U16(0))
+	stts := testutil.Box("stts", make([]byte, 4), testutil.U32(1), testutil.U32(300), testutil.U32(1000))
+	minf := testutil.Box("minf", testutil.Box("stbl", stts))
+	return testutil.Box("trak", tkhd, testutil.Box("mdia", mdhd, hdlr, minf))
+}
+
+func TestDecodeHDLR(t *testing.T) {
+	tests := []struct {
+		src           []byte
+		pre, typ, nam string
+	}{
+		// ISO: NUL-terminated name
+		{bytes.Join([][]byte{make([]byte, 8), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00")}, nil),
+			"\x00\x00\x00\x00", "vide", "VideoHandler"},
+		// ISO: no name
+		{bytes.Join([][]byte{make([]byte, 8), []byte("soun"), make([]byte, 12)}, nil),
+			"\x00\x00\x00\x00", "soun", ""},
+		// QuickTime: Pascal string
+		{bytes.Join([][]byte{make([]byte, 4), []byte("mhlr"), []byte("soun"), make([]byte, 12), []byte("\x0eSound Handler!")}, nil),
+			"mhlr", "soun", "Sound Handler!"},
+	}
+	for _, tt := range tests {
+		h, err := mp4.DecodeHDLR(tt.src)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if h.Predefined != tt.pre || h.HandlerType != tt.typ || h.Name != tt.nam {
+			t.Errorf("got %q %q %q, want %q %q %q",
+				h.Predefined, h.HandlerType, h.Name, tt.pre, tt.typ, tt.nam)
+		}
+	}
+
+	if _, err := mp4.DecodeHDLR(make([]byte, 10)); err == nil {
+		t.Error("short hdlr decoded without error")
+	}
+}
+
+func TestTKHDRotation(t *testing.T) {
+	const one = 1 << 16
+	tests := []struct {
+		abcd [4]int32
+		want int
+	}{
+		{[4]int32{one, 0, 0, one}, 0},
+		{[4]int32{0, one, -one, 0}, 90},
+		{[4]int32{-one, 0, 0, -one}, 180},
+		{[4]int32{0, -one, one, 0}, 270},
+		{[4]int32{0, 0, 0, 0}, 0},
+	}
+	for _, tt := range tests {
+		m := [9]int32{tt.abcd[0], tt.abcd[1], 0, tt.abcd[2], tt.abcd[3], 0, 0, 0, 1 << 30}
+		var mp []byte
+		for _, v := range m {
+			mp = append(mp, testutil.U32(int(v))...)
+		}
+		src := bytes.Join([][]byte{
+			make([]byte, 12), testutil.U32(1), make([]byte, 8),
+			make([]byte, 16), mp, testutil.U32(640 << 16), testutil.U32(480 << 16),
+		}, nil)
+		hd, err := mp4.DecodeTKHD(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hd.Matrix != m {
+			t.Errorf("got matrix %v, want %v", hd.Matrix, m)
+		}
+		if got := hd.Rotation(); got != tt.want {
+			t.Errorf("matrix %v: got rotation %d, want %d", tt.abcd, got, tt.want)
+		}
+		if w, h := hd.FrameSize(); w != 640 || h != 480 {
+			t.Errorf("got frame size %dx%d, want 640x480", w, h)
+		}
+	}
+}
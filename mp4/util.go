@@ -1,7 +1,9 @@
 package mp4
 
 import (
+	"bytes"
 	"encoding/binary"
+	"math"
 	"time"
 )
 
@@ -62,14 +64,77 @@ func (p *boxParse) Rest() []byte {
 	return p.data[p.i:]
 }
 
+func (p *boxParse) Uint16() uint16 {
+	return mp4bo.Uint16(p.next(2))
+}
+
 func (p *boxParse) Uint32() uint32 {
 	return mp4bo.Uint32(p.next(4))
 }
 
-var macUTCepoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+// UintN reads an unsigned value of n bytes,
+// where n is one of 0, 2, 4 or 8.
+func (p *boxParse) UintN(n int) uint64 {
+	switch n {
+	case 0:
+		return 0
+	case 2:
+		return uint64(p.Uint16())
+	case 4:
+		return uint64(p.Uint32())
+	case 8:
+		return mp4bo.Uint64(p.next(8))
+	}
+	panic("invalid integer size")
+}
+
+// CC4 reads a four character code.
+func (p *boxParse) CC4() string {
+	return string(p.next(4))
+}
+
+// CString reads a NUL-terminated string.
+// The string may be missing the NUL at the end of the data.
+func (p *boxParse) CString() string {
+	if p.short {
+		return ""
+	}
+	rest := p.data[p.i:]
+	i := bytes.IndexByte(rest, 0)
+	if i < 0 {
+		p.i = len(p.data)
+		return string(rest)
+	}
+	p.i += i + 1
+	return string(rest[:i])
+}
+
+// macEpochOffset is the number of seconds between
+// the Mac epoch (1904-01-01 UTC) and the Unix epoch.
+const macEpochOffset = 2082844800
 
+// Date reads a date in seconds since 1904-01-01 UTC.
+// A zero value means the date is not set, and yields the zero time.Time.
 func (p *boxParse) Date() time.Time {
-	return macUTCepoch.Add(time.Duration(p.UintVar()) * time.Second)
+	return macDate(p.UintVar(), p.big)
+}
+
+// macDate returns the time for v seconds since 1904-01-01 UTC.
+//
+// 32-bit dates overflow in 2040. Writers using 32-bit dates after that
+// wrap around, therefore 32-bit dates before the Unix epoch,
+// that predate MP4 files anyway, are assumed to have wrapped.
+func macDate(v uint64, is64bit bool) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	if !is64bit && v < macEpochOffset {
+		v += 1 << 32
+	}
+	if v > math.MaxInt64 {
+		return time.Time{}
+	}
+	return time.Unix(int64(v)-macEpochOffset, 0).UTC()
 }
 
 func (p *boxParse) UintVar() uint64 {
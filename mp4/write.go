@@ -0,0 +1,82 @@
+package mp4
+
+import (
+	"io"
+)
+
+// WriteTo writes f to w, serializing the top-level boxes
+// in their current order.
+//
+// Boxes with content in memory are written from memory,
+// packing their child boxes if they have been unpacked.
+// The content of other boxes, such as mdat, is copied
+// from the source of f. It is an error if f was parsed
+// from a reader that is not an io.ReadSeeker, and f
+// has such boxes. In this case nothing is written to w.
+func (f *File) WriteTo(w io.Writer) (n int64, err error) {
+	for i := range f.Child {
+		b := &f.Child[i]
+		if !b.loaded() && f.src == nil {
+			return 0, formatError("%s content not loaded", b.Type)
+		}
+	}
+
+	cw := &countWriter{w: w}
+	for i := range f.Child {
+		if err := f.writeBox(cw, &f.Child[i]); err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// loaded reports if the content of b is in memory.
+func (b *Box) loaded() bool {
+	switch {
+	case b.Child != nil, b.Raw != nil:
+		return true
+	case b.Type == "free" || b.Type == "skip":
+		// content is irrelevant
+		return b.Size != 0
+	}
+	return b.ContentSize() == 0
+}
+
+func (f *File) writeBox(w io.Writer, b *Box) error {
+	if b.loaded() {
+		if b.Child == nil && b.Raw == nil {
+			// free space
+			b = &Box{Type: b.Type, Raw: make([]byte, b.Size-headerSize(int(b.Size-8)))}
+		}
+		p := make([]byte, int(b.packedSize()))
+		packBox(b, p, 0)
+		_, err := w.Write(p)
+		return err
+	}
+
+	// copy header and content from source
+	if _, err := f.src.Seek(f.srcBase+b.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	if b.Size == 0 {
+		// box extends to EOF
+		_, err := io.Copy(w, f.src)
+		return err
+	}
+	_, err := io.CopyN(w, f.src, b.Size)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,210 @@
+package mp4_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/tajtiattila/metadata/mp4"
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestWriteTo(t *testing.T) {
+	src := mp4File()
+
+	f, err := mp4.Parse(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := f.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", n, buf.Len())
+	}
+	if !bytes.Equal(buf.Bytes(), src) {
+		t.Error("unmodified file not written as is")
+	}
+
+	uuid := append(bytes.Repeat([]byte{0xab}, 16), "<xmp/>"...)
+	f.AddUuid(uuid)
+
+	buf.Reset()
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := mp4.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := g.Find("uuid"); b == nil || !bytes.Equal(b.Raw, uuid) {
+		t.Error("uuid box missing")
+	}
+	if !bytes.Contains(buf.Bytes(), testutil.Box("mdat", []byte("data"))) {
+		t.Error("mdat missing")
+	}
+	if len(g.Tracks()) != 2 {
+		t.Error("tracks missing")
+	}
+}
+
+func TestWriteToNonSeekable(t *testing.T) {
+	f, err := mp4.Parse(struct{ io.Reader }{bytes.NewReader(mp4File())})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err == nil {
+		t.Error("WriteTo succeeded without mdat content")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteTo wrote %d bytes on error", buf.Len())
+	}
+}
+
+func TestCompact(t *testing.T) {
+	free := func(size int64) mp4.Box {
+		return mp4.Box{Offset: -1, Size: size, Type: "free"}
+	}
+	box := func(typ string) mp4.Box {
+		return mp4.Box{Offset: -1, Size: 16, Type: typ, Raw: make([]byte, 8)}
+	}
+
+	tests := []struct {
+		trim      bool
+		src, want []mp4.Box
+	}{
+		{
+			false,
+			[]mp4.Box{box("ftyp"), free(8), free(16), box("moov"), free(8), box("mdat"), free(8), free(24)},
+			[]mp4.Box{box("ftyp"), free(24), box("moov"), free(8), box("mdat"), free(32)},
+		},
+		{
+			true,
+			[]mp4.Box{box("ftyp"), free(8), free(16), box("moov"), free(8), box("mdat"), free(8), free(24)},
+			[]mp4.Box{box("ftyp"), free(24), box("moov"), free(8), box("mdat")},
+		},
+		{
+			// free box till EOF is not merged
+			false,
+			[]mp4.Box{box("ftyp"), free(8), free(0)},
+			[]mp4.Box{box("ftyp"), free(8), free(0)},
+		},
+	}
+	for i, tt := range tests {
+		f := &mp4.File{Box: mp4.Box{Type: "MP4", Child: tt.src}}
+		f.Compact(tt.trim)
+		if !reflect.DeepEqual(f.Child, tt.want) {
+			t.Errorf("test %d: got %v, want %v", i, f.Child, tt.want)
+		}
+	}
+
+	// mdat offset is not changed after AddUuid
+	src := mp4File()
+	f, err := mp4.Parse(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		f.AddUuid(append(bytes.Repeat([]byte{0xab}, 16), bytes.Repeat([]byte("x"), 100-i*40)...))
+	}
+	var before bytes.Buffer
+	if _, err := f.WriteTo(&before); err != nil {
+		t.Fatal(err)
+	}
+	f.Compact(false)
+	var after bytes.Buffer
+	if _, err := f.WriteTo(&after); err != nil {
+		t.Fatal(err)
+	}
+	mdat := testutil.Box("mdat", []byte("data"))
+	if i, j := bytes.Index(before.Bytes(), mdat), bytes.Index(after.Bytes(), mdat); i != j || i < 0 {
+		t.Errorf("mdat offset changed from %d to %d", i, j)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	stco := func(offsets ...int) []byte {
+		p := append(make([]byte, 4), testutil.U32(len(offsets))...)
+		for _, o := range offsets {
+			p = append(p, testutil.U32(o)...)
+		}
+		return testutil.Box("stco", p)
+	}
+	moov := func(offsets ...int) []byte {
+		mvhd := testutil.Box("mvhd", make([]byte, 12), testutil.U32(600), testutil.U32(6000), make([]byte, 80))
+		stbl := testutil.Box("stbl", stco(offsets...))
+		return testutil.Box("moov", mvhd, testutil.Box("trak", testutil.Box("mdia", testutil.Box("minf", stbl))))
+	}
+	ftyp := testutil.Box("ftyp", []byte("qt  \x00\x00\x02\x00qt  "))
+	wide := testutil.Box("wide")
+	free := testutil.Box("free", make([]byte, 8))
+	mdat := testutil.Box("mdat", []byte("data"))
+
+	tests := []struct {
+		name  string
+		boxes [][]byte
+		want  []string
+	}{
+		{
+			// typical QuickTime layout
+			"wide",
+			[][]byte{ftyp, wide, mdat, moov(len(ftyp) + len(wide) + 8)},
+			[]string{"ftyp", "moov", "wide", "mdat"},
+		},
+		{
+			"free",
+			[][]byte{ftyp, free, mdat, free, moov(len(ftyp) + len(free) + 8)},
+			[]string{"ftyp", "moov", "mdat", "free", "free"},
+		},
+		{
+			// wide not before mdat is free space
+			"wide after mdat",
+			[][]byte{ftyp, mdat, wide, moov(len(ftyp) + 8)},
+			[]string{"ftyp", "moov", "mdat", "wide"},
+		},
+	}
+	for _, tt := range tests {
+		f, err := mp4.Parse(bytes.NewReader(bytes.Join(tt.boxes, nil)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Optimize(); err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if _, err := f.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		p := buf.Bytes()
+
+		g, err := mp4.Parse(bytes.NewReader(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, b := range g.Child {
+			got = append(got, b.Type)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got boxes %v, want %v", tt.name, got, tt.want)
+		}
+
+		b := g.Find("moov", "trak", "mdia", "minf", "stbl", "stco")
+		if b == nil || len(b.Raw) != 12 {
+			t.Errorf("%s: invalid stco", tt.name)
+			continue
+		}
+		off := int(b.Raw[8])<<24 | int(b.Raw[9])<<16 | int(b.Raw[10])<<8 | int(b.Raw[11])
+		if off+4 > len(p) || string(p[off:off+4]) != "data" {
+			t.Errorf("%s: chunk offset %d does not point to mdat data", tt.name, off)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package metadata
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestRotationOrientation(t *testing.T) {
+	for deg, want := range map[int]int{0: 1, 90: 6, 180: 3, 270: 8} {
+		if got := rotationOrientation(deg); got != want {
+			t.Errorf("rotation %d: got orientation %d, want %d", deg, got, want)
+		}
+	}
+}
+
+func TestParseMP4Video(t *testing.T) {
+	// 2.5 seconds of video at 29.97 fps:
+	// 75 frames of 1001 units in 30000 units per second
+	const one = 1 << 16
+	tkhd := testutil.Box("tkhd", []byte{0, 0, 0, 3}, make([]byte, 8), testutil.U32(1), make([]byte, 4), testutil.U32(1500),
+		make([]byte, 16), testutil.U32(one), make([]byte, 12), testutil.U32(one), make([]byte, 12), testutil.U32(1<<30),
+		testutil.U32(640<<16), testutil.U32(480<<16))
+	mdhd := testutil.Box("mdhd", make([]byte, 12), testutil.U32(30000), testutil.U32(75075), make([]byte, 4))
+	hdlr := testutil.Box("hdlr", make([]byte, 8), []byte("vide"), make([]byte, 12), []byte("Video\x00"))
+	stts := testutil.Box("stts", make([]byte, 4), testutil.U32(1), testutil.U32(75), testutil.U32(1001))
+	trak := testutil.Box("trak", tkhd, testutil.Box("mdia", mdhd, hdlr, testutil.Box("minf", testutil.Box("stbl", stts))))
+
+	mvhd := testutil.Box("mvhd", make([]byte, 12), testutil.U32(600), testutil.U32(1500), make([]byte, 80))
+	p := bytes.Join([][]byte{
+		testutil.Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		testutil.Box("moov", mvhd, trak),
+		testutil.Box("mdat", []byte("data")),
+	}, nil)
+
+	m, err := Parse(bytes.NewReader(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Get(Duration); got != "2.5" {
+		t.Errorf("Duration is %q, want 2.5", got)
+	}
+	if got := m.Get(FrameRate); got != "29.97002997002997" {
+		t.Errorf("FrameRate is %q, want 29.97002997002997", got)
+	}
+	if src, ok := m.Source(Duration); !ok || src != "mp4" {
+		t.Errorf("source of Duration is %q, %v; want mp4", src, ok)
+	}
+	if m.Orientation != 1 {
+		t.Errorf("Orientation is %d, want 1", m.Orientation)
+	}
+}
@@ -0,0 +1,186 @@
+package metadata
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+	"github.com/tajtiattila/metadata/orient"
+	"github.com/tajtiattila/metadata/xmp"
+)
+
+// normalizeQuality is the JPEG quality used
+// to re-encode images in NormalizeOrientation.
+const normalizeQuality = 95
+
+// NormalizeOrientation copies the JPEG image in src to dst
+// with its Exif orientation applied to the pixels.
+//
+// The image is decoded, rotated or flipped using orient.Orient,
+// and re-encoded. The Exif orientation is reset to 1, and
+// the pixel dimensions and the thumbnail are updated accordingly.
+// Other Exif tags such as GPS and dates, XMP and other
+// metadata segments are preserved.
+//
+// Images without an Exif orientation or having orientation 1
+// are copied unmodified.
+//
+// ErrUnknownFormat is returned if src is not a JPEG file.
+func NormalizeOrientation(dst io.Writer, src io.Reader) error {
+	p, err := ioutil.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	if !isjpeg(p) {
+		return ErrUnknownFormat
+	}
+
+	segs, err := jpegHeaderSegments(p)
+	if err != nil {
+		return err
+	}
+
+	exifIdx, xmpIdx := -1, -1
+	for i, seg := range segs {
+		switch {
+		case exifIdx < 0 && isSegment(seg, 0xe1, jpegExifPfx):
+			exifIdx = i
+		case xmpIdx < 0 && isSegment(seg, 0xe1, jpegXMPPfx):
+			xmpIdx = i
+		}
+	}
+
+	var o int
+	var x *exif.Exif
+	if exifIdx >= 0 {
+		x, err = exif.DecodeBytes(segs[exifIdx][4+len(jpegExifPfx):])
+		if x == nil {
+			return err
+		}
+		o, _ = x.Orientation()
+	}
+	if o < 2 || o > 8 {
+		_, err := dst.Write(p)
+		return err
+	}
+
+	im, err := jpeg.Decode(bytes.NewReader(p))
+	if err != nil {
+		return err
+	}
+	im = orient.Orient(im, o)
+
+	enc := new(bytes.Buffer)
+	if err := jpeg.Encode(enc, im, &jpeg.Options{Quality: normalizeQuality}); err != nil {
+		return err
+	}
+
+	normalizeExif(x, o, im.Bounds())
+	xp, err := x.EncodeBytes()
+	if err != nil {
+		return err
+	}
+	segs[exifIdx], err = jpegSegment(0xe1, jpegExifPfx, xp)
+	if err != nil {
+		return err
+	}
+
+	if xmpIdx >= 0 {
+		xm, err := xmp.Decode(bytes.NewReader(segs[xmpIdx][4+len(jpegXMPPfx):]))
+		if err != nil {
+			return err
+		}
+		updateXMP(xm, &Metadata{Attr: map[string]string{Orientation: "1"}})
+
+		buf := new(bytes.Buffer)
+		if err := xm.Encode(buf); err != nil {
+			return err
+		}
+		segs[xmpIdx], err = jpegSegment(0xe1, jpegXMPPfx, buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := dst.Write([]byte{0xff, 0xd8}); err != nil {
+		return err
+	}
+	for _, seg := range segs {
+		if _, err := dst.Write(seg); err != nil {
+			return err
+		}
+	}
+
+	// image/jpeg writes no APPn segments,
+	// so everything after SOI is image data
+	_, err = dst.Write(enc.Bytes()[2:])
+	return err
+}
+
+// normalizeExif resets the orientation of x after the
+// image was oriented by o, having the bounds r.
+func normalizeExif(x *exif.Exif, o int, r image.Rectangle) {
+	x.SetOrientation(1)
+
+	if orient.IsTranspose(o) {
+		if x.Tag(exiftag.PixelXDimension) != nil {
+			x.Set(exiftag.PixelXDimension, exif.Long{uint32(r.Dx())})
+		}
+		if x.Tag(exiftag.PixelYDimension) != nil {
+			x.Set(exiftag.PixelYDimension, exif.Long{uint32(r.Dy())})
+		}
+	}
+
+	if len(x.Thumb) == 0 {
+		return
+	}
+	thumb, _, err := x.ThumbImage()
+	if err == nil {
+		err = x.SetThumbImage(orient.Orient(thumb, o))
+	}
+	if err != nil {
+		// drop thumbnail that can't be oriented
+		x.IFD1, x.Thumb = nil, nil
+	}
+}
+
+// jpegHeaderSegments returns the APPn and COM segments
+// before the start of scan in the JPEG p.
+//
+// Adobe (APP14) segments are omitted, because they
+// describe the color transform of the original image data.
+func jpegHeaderSegments(p []byte) ([][]byte, error) {
+	j, err := xjpeg.NewScanner(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+
+	var segs [][]byte
+	for j.Next() {
+		m := j.Marker()
+		keep := j.StartChunk() && (m >= 0xe0 && m <= 0xef && m != 0xee || m == 0xfe)
+		seg, err := j.ReadSegment()
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			segs = append(segs, seg)
+		}
+	}
+	if err := j.Err(); err != nil {
+		return nil, err
+	}
+	return segs, nil
+}
+
+// isSegment reports whether the raw JPEG segment seg
+// has marker with its payload starting with prefix.
+func isSegment(seg []byte, marker byte, prefix []byte) bool {
+	return len(seg) >= 4 && seg[0] == 0xff && seg[1] == marker &&
+		bytes.HasPrefix(seg[4:], prefix)
+}
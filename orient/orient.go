@@ -3,6 +3,7 @@
 package orient
 
 import (
+	"encoding/binary"
 	"image"
 	"image/draw"
 )
@@ -11,45 +12,64 @@ import (
 // the Exif orientation value.
 //
 // It performes the following operation based on o:
-//   2: flip horizontal
-//   3: rotate 180°
-//   4: flip vertical
-//   5: transpose
-//   6: rotate 90°
-//   7: transverse (transpose and rotate 180°)
-//   8: rotate 270°
+//
+//	2: flip horizontal
+//	3: rotate 180°
+//	4: flip vertical
+//	5: transpose
+//	6: rotate 90°
+//	7: transverse (transpose and rotate 180°)
+//	8: rotate 270°
 //
 // It will return either a new image for the values
 // of o above, or im istelf otherwise.
+//
+// The new image has the same type as im if it is
+// an *image.RGBA, *image.NRGBA, *image.CMYK or *image.Gray,
+// and an *image.RGBA otherwise.
 func Orient(im image.Image, o int) image.Image {
 	if o < 2 || o > 8 {
 		return im
 	}
 
-	var dst *image.RGBA
+	var dst image.Image
+	var buf pixbuf
 	if o >= 5 {
-		dst = transpose(im)
+		dst, buf = transpose(im)
 		o -= 4
 	} else {
-		dst = asRGBA(im)
+		dst, buf = clone(im)
 	}
 
 	switch o {
 	case 2:
-		flipHorz(dst)
-		return dst
+		flipHorz(buf)
 	case 3:
-		flipHorz(dst)
-		flipVert(dst)
-		return dst
+		flipHorz(buf)
+		flipVert(buf)
 	case 4:
-		flipVert(dst)
-		return dst
+		flipVert(buf)
 	}
 
 	return dst
 }
 
+// Unorient applies the inverse of Orient(im, o) to im.
+// It converts an image as it should be displayed back
+// to how it is stored with the Exif orientation o.
+//
+// All operations of Orient are their own inverses,
+// except for rotations by 90° and 270°.
+func Unorient(im image.Image, o int) image.Image {
+	switch o {
+	case 6:
+		o = 8
+	case 8:
+		o = 6
+	}
+	return Orient(im, o)
+}
+
 // IsTranspose reports if the Exif orientation o
 // requires a transpose operation that swaps
 // the x and y dimensions of the image.
@@ -63,6 +83,75 @@ func IsTranspose(o int) bool {
 	return o >= 5
 }
 
+// pixbuf is the pixel data of an image,
+// stored with a fixed number of bytes per pixel.
+type pixbuf struct {
+	pix    []uint8
+	stride int
+	rect   image.Rectangle
+	bpp    int // bytes per pixel
+}
+
+func (b pixbuf) offset(x, y int) int {
+	return (y-b.rect.Min.Y)*b.stride + (x-b.rect.Min.X)*b.bpp
+}
+
+// pixbufOf returns the pixel data of im
+// if it is one of the supported image types.
+func pixbufOf(im image.Image) (pixbuf, bool) {
+	switch m := im.(type) {
+	case *image.RGBA:
+		return pixbuf{m.Pix, m.Stride, m.Rect, 4}, true
+	case *image.NRGBA:
+		return pixbuf{m.Pix, m.Stride, m.Rect, 4}, true
+	case *image.CMYK:
+		return pixbuf{m.Pix, m.Stride, m.Rect, 4}, true
+	case *image.Gray:
+		return pixbuf{m.Pix, m.Stride, m.Rect, 1}, true
+	}
+	return pixbuf{}, false
+}
+
+// newLike returns a new image with bounds r
+// having the same type as im, if it is supported by pixbufOf.
+func newLike(im image.Image, r image.Rectangle) (image.Image, bool) {
+	switch im.(type) {
+	case *image.RGBA:
+		return image.NewRGBA(r), true
+	case *image.NRGBA:
+		return image.NewNRGBA(r), true
+	case *image.CMYK:
+		return image.NewCMYK(r), true
+	case *image.Gray:
+		return image.NewGray(r), true
+	}
+	return nil, false
+}
+
+// clone returns a copy of src with its bounds starting at 0, 0.
+// The result is an *image.RGBA unless the type of src is
+// supported by pixbufOf.
+func clone(src image.Image) (image.Image, pixbuf) {
+	sb, ok := pixbufOf(src)
+	if !ok {
+		dst := asRGBA(src)
+		db, _ := pixbufOf(dst)
+		return dst, db
+	}
+
+	sz := sb.rect.Size()
+	dst, _ := newLike(src, image.Rect(0, 0, sz.X, sz.Y))
+	db, _ := pixbufOf(dst)
+
+	w := sz.X * sb.bpp
+	for y := 0; y < sz.Y; y++ {
+		so := sb.offset(sb.rect.Min.X, sb.rect.Min.Y+y)
+		do := db.offset(0, y)
+		copy(db.pix[do:do+w], sb.pix[so:so+w])
+	}
+	return dst, db
+}
+
 func asRGBA(src image.Image) *image.RGBA {
 	db := src.Bounds().Canon()
 	db = db.Sub(db.Min)
@@ -71,47 +160,107 @@ func asRGBA(src image.Image) *image.RGBA {
 	return dst
 }
 
-func transpose(src image.Image) *image.RGBA {
+// transpose returns src transposed with its bounds starting at 0, 0.
+// The result is an *image.RGBA unless the type of src is
+// supported by pixbufOf. Other images are converted
+// to *image.RGBA using draw before transposing.
+func transpose(src image.Image) (image.Image, pixbuf) {
 	sz := src.Bounds().Size()
 	o := src.Bounds().Canon().Min
-	dst := image.NewRGBA(image.Rect(0, 0, sz.Y, sz.X))
-	for y := 0; y < sz.Y; y++ {
-		for x := 0; x < sz.X; x++ {
-			c := src.At(o.X+x, o.Y+y)
-			dst.Set(y, x, c)
+	dr := image.Rect(0, 0, sz.Y, sz.X)
+
+	sb, ok := pixbufOf(src)
+	if !ok {
+		src = asRGBA(src)
+		sb, _ = pixbufOf(src)
+		o = image.Point{}
+	}
+
+	dst, _ := newLike(src, dr)
+	db, _ := pixbufOf(dst)
+
+	// process the image in square blocks, so that
+	// both source and destination rows stay in cache
+	for y0 := 0; y0 < sz.Y; y0 += transposeBlock {
+		dy := sz.Y - y0
+		if dy > transposeBlock {
+			dy = transposeBlock
+		}
+		for x0 := 0; x0 < sz.X; x0 += transposeBlock {
+			dx := sz.X - x0
+			if dx > transposeBlock {
+				dx = transposeBlock
+			}
+			s := sb.pix[sb.offset(o.X+x0, o.Y+y0):]
+			d := db.pix[db.offset(y0, x0):]
+			if sb.bpp == 4 {
+				transposeBlock32(d, db.stride, s, sb.stride, dx, dy)
+			} else {
+				transposeBlock8(d, db.stride, s, sb.stride, dx, dy)
+			}
+		}
+	}
+	return dst, db
+}
+
+// transposeBlock is the size of blocks in pixels used by transpose.
+const transposeBlock = 64
+
+// transposeBlock32 transposes a block of dx×dy pixels
+// of 4 bytes each from src to dst.
+func transposeBlock32(dst []uint8, dstride int, src []uint8, sstride int, dx, dy int) {
+	for y := 0; y < dy; y++ {
+		s := src[y*sstride : y*sstride+dx*4]
+		di := y * 4
+		for x := 0; x < dx; x++ {
+			binary.LittleEndian.PutUint32(dst[di:], binary.LittleEndian.Uint32(s[4*x:]))
+			di += dstride
+		}
+	}
+}
+
+// transposeBlock8 transposes a block of dx×dy pixels
+// of 1 byte each from src to dst.
+func transposeBlock8(dst []uint8, dstride int, src []uint8, sstride int, dx, dy int) {
+	for y := 0; y < dy; y++ {
+		s := src[y*sstride : y*sstride+dx]
+		di := y
+		for x := 0; x < dx; x++ {
+			dst[di] = s[x]
+			di += dstride
 		}
 	}
-	return dst
 }
 
-func flipHorz(im *image.RGBA) {
-	w := im.Rect.Dx()
+func flipHorz(im pixbuf) {
+	n := im.bpp
+	w := im.rect.Dx()
 	nswap := w / 2
-	i0, i1 := 0, (w-1)*4
-	for y := im.Rect.Min.Y; y < im.Rect.Max.Y; y++ {
+	i0, i1 := 0, (w-1)*n
+	for y := im.rect.Min.Y; y < im.rect.Max.Y; y++ {
 		x0, x1 := i0, i1
 		for i := 0; i < nswap; i++ {
-			for j := 0; j < 4; j++ {
-				im.Pix[x0+j], im.Pix[x1+j] = im.Pix[x1+j], im.Pix[x0+j]
+			for j := 0; j < n; j++ {
+				im.pix[x0+j], im.pix[x1+j] = im.pix[x1+j], im.pix[x0+j]
 			}
-			x0 += 4
-			x1 -= 4
+			x0 += n
+			x1 -= n
 		}
-		i0 += im.Stride
-		i1 += im.Stride
+		i0 += im.stride
+		i1 += im.stride
 	}
 }
 
-func flipVert(im *image.RGBA) {
-	w := 4 * im.Rect.Dx()
+func flipVert(im pixbuf) {
+	w := im.bpp * im.rect.Dx()
 	tmp := make([]uint8, w)
-	ny := im.Rect.Dy()
+	ny := im.rect.Dy()
 	nswap := ny / 2
 	for i := 0; i < nswap; i++ {
-		o0 := i * im.Stride
-		o1 := (ny - 1 - i) * im.Stride
-		copy(tmp, im.Pix[o0:o0+w])
-		copy(im.Pix[o0:o0+w], im.Pix[o1:o1+w])
-		copy(im.Pix[o1:o1+w], tmp)
+		o0 := i * im.stride
+		o1 := (ny - 1 - i) * im.stride
+		copy(tmp, im.pix[o0:o0+w])
+		copy(im.pix[o0:o0+w], im.pix[o1:o1+w])
+		copy(im.pix[o1:o1+w], tmp)
 	}
 }
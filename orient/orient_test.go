@@ -33,6 +33,12 @@ func TestOrient(t *testing.T) {
 				return image.NewCMYK(r)
 			},
 		},
+		{
+			name: "NRGBA",
+			newImage: func(r image.Rectangle) draw.Image {
+				return image.NewNRGBA(r)
+			},
+		},
 	}
 
 	for _, f := range formats {
@@ -43,16 +49,53 @@ func TestOrient(t *testing.T) {
 			if err := sameImage(got, want); err != nil {
 				t.Errorf("%s orientation %d: %v", f.name, o, err)
 			}
+			if gt, wt := fmt.Sprintf("%T", got), fmt.Sprintf("%T", src); gt != wt {
+				t.Errorf("%s orientation %d: got %s image", f.name, o, gt)
+			}
 
 			wantt := src.Bounds().Dx() == want.Bounds().Dy()
 			gott := IsTranspose(o)
 			if wantt != gott {
-				t.Errorf("IsTranspose(%d) reports %v, want %v", gott, wantt)
+				t.Errorf("IsTranspose(%d) reports %v, want %v", o, gott, wantt)
 			}
 		}
 	}
 }
 
+func TestUnorient(t *testing.T) {
+	newImage := func(r image.Rectangle) draw.Image {
+		return image.NewRGBA(r)
+	}
+	upright := getPix(t, 1, newImage)
+	for o := 1; o <= 8; o++ {
+		src := getPix(t, o, newImage)
+		if err := sameImage(Unorient(Orient(src, o), o), src); err != nil {
+			t.Errorf("round trip of orientation %d: %v", o, err)
+		}
+		if err := sameImage(Unorient(upright, o), src); err != nil {
+			t.Errorf("Unorient %d: %v", o, err)
+		}
+	}
+}
+
+func TestOrientSubImage(t *testing.T) {
+	for o := 1; o <= 8; o++ {
+		src := getPix(t, o, func(r image.Rectangle) draw.Image {
+			return image.NewGray(r)
+		}).(*image.Gray)
+
+		// embed src in a larger image
+		sr := src.Bounds()
+		big := image.NewGray(sr.Inset(-7))
+		draw.Draw(big, sr, src, sr.Min, draw.Src)
+		sub := big.SubImage(sr)
+
+		if err := sameImage(Orient(sub, o), Orient(src, o)); err != nil {
+			t.Errorf("subimage orientation %d: %v", o, err)
+		}
+	}
+}
+
 var pixmap = map[int][][]bool{
 	1: mkpix(`
 . . 8 8 8 8 8 8 . .
@@ -210,3 +253,43 @@ func sameColor(a, b color.Color) bool {
 	br, bg, bb, ba := b.RGBA()
 	return ar == br && ag == bg && ab == bb && aa == ba
 }
+
+func TestTransposeLarge(t *testing.T) {
+	// not a multiple of the block size
+	r := image.Rect(3, 5, 3+2*transposeBlock+7, 5+transposeBlock+13)
+	rgba := image.NewRGBA(r)
+	gray := image.NewGray(r)
+	for i := range rgba.Pix {
+		rgba.Pix[i] = uint8(i * 7)
+	}
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i * 13)
+	}
+
+	for _, src := range []image.Image{rgba, gray} {
+		dst, _ := transpose(src)
+		sz := r.Size()
+		if got := dst.Bounds(); got != image.Rect(0, 0, sz.Y, sz.X) {
+			t.Fatalf("%T: got bounds %v", src, got)
+		}
+	loop:
+		for y := 0; y < sz.Y; y++ {
+			for x := 0; x < sz.X; x++ {
+				sp, dp := src.At(r.Min.X+x, r.Min.Y+y), dst.At(y, x)
+				if !sameColor(sp, dp) {
+					t.Errorf("%T: pixel at %d,%d is %v, want %v", src, y, x, dp, sp)
+					break loop
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkTransposeRGBA(b *testing.B) {
+	im := image.NewRGBA(image.Rect(0, 0, 6000, 4000))
+	b.SetBytes(int64(len(im.Pix)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Orient(im, 6)
+	}
+}
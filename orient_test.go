@@ -0,0 +1,110 @@
+package metadata_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+)
+
+func TestNormalizeOrientation(t *testing.T) {
+	// left half red, right half blue
+	im := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 16 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			im.Set(x, y, c)
+		}
+	}
+	var raw bytes.Buffer
+	if err := jpeg.Encode(&raw, im, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tm := time.Date(2018, 5, 6, 7, 8, 9, 0, time.UTC)
+	x := exif.New(32, 16)
+	x.SetOrientation(6)
+	x.SetLatLong(47.5, 19.25)
+	x.SetTime(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal, tm)
+
+	var src bytes.Buffer
+	if err := exif.Copy(&src, bytes.NewReader(raw.Bytes()), x); err != nil {
+		t.Fatal(err)
+	}
+
+	var dst bytes.Buffer
+	if err := metadata.NormalizeOrientation(&dst, bytes.NewReader(src.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := jpeg.Decode(bytes.NewReader(dst.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sz := got.Bounds().Size(); sz != image.Pt(16, 32) {
+		t.Fatalf("got size %v, want 16x32", sz)
+	}
+
+	// rotated by 90° clockwise: red is on top
+	if r, _, b, _ := got.At(8, 4).RGBA(); r < b {
+		t.Error("top of image is not red")
+	}
+	if r, _, b, _ := got.At(8, 28).RGBA(); r > b {
+		t.Error("bottom of image is not blue")
+	}
+
+	gx, err := exif.Decode(bytes.NewReader(dst.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, ok := gx.Orientation(); !ok || o != 1 {
+		t.Errorf("orientation is %v, %v; want 1, true", o, ok)
+	}
+	dx, dy := gx.Tag(exiftag.PixelXDimension).Long(), gx.Tag(exiftag.PixelYDimension).Long()
+	if len(dx) != 1 || len(dy) != 1 || dx[0] != 16 || dy[0] != 32 {
+		t.Errorf("pixel dimensions are %v×%v, want 16×32", dx, dy)
+	}
+	if lat, long, ok := gx.LatLong(); !ok || lat != 47.5 || long != 19.25 {
+		t.Errorf("LatLong is %v, %v, %v; want 47.5, 19.25, true", lat, long, ok)
+	}
+	if gt, _, ok := gx.Time(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal); !ok || !gt.Equal(tm) {
+		t.Errorf("DateTimeOriginal is %v, %v; want %v", gt, ok, tm)
+	}
+}
+
+func TestNormalizeOrientationCopy(t *testing.T) {
+	var raw bytes.Buffer
+	if err := jpeg.Encode(&raw, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	x := exif.New(8, 8)
+	x.SetOrientation(1)
+	var withExif bytes.Buffer
+	if err := exif.Copy(&withExif, bytes.NewReader(raw.Bytes()), x); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, src := range map[string][]byte{
+		"no Exif":       raw.Bytes(),
+		"orientation 1": withExif.Bytes(),
+	} {
+		var dst bytes.Buffer
+		if err := metadata.NormalizeOrientation(&dst, bytes.NewReader(src)); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(dst.Bytes(), src) {
+			t.Errorf("%s: file not copied unmodified", name)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package metadata
+
+import (
+	"bytes"
+	"io"
+)
+
+// rawMagic lists the headers of TIFF based raw formats
+// that use a custom magic number instead of 42.
+var rawMagic = [][]byte{
+	[]byte("IIRO"),    // Olympus ORF
+	[]byte("IIRS"),    // Olympus ORF
+	[]byte("MMOR"),    // Olympus ORF
+	[]byte("IIU\x00"), // Panasonic RW2
+}
+
+// israw reports if p is the start of a camera raw file,
+// that is not a valid TIFF file.
+//
+// Raw formats having a valid TIFF header such as
+// DNG, NEF or CR2 are handled by parseTIFF.
+func israw(p []byte) bool {
+	for _, m := range rawMagic {
+		if bytes.HasPrefix(p, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRAW parses metadata in IFD0 and its Exif and GPS sub-IFDs
+// of a raw file with a TIFF-like header.
+func (s *parseState) parseRAW(r io.Reader) (*Metadata, error) {
+	hdr := make([]byte, 4)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+
+	// use the TIFF magic number so that exif.DecodeTIFF accepts it
+	if hdr[0] == 'M' {
+		hdr[2], hdr[3] = 0, 42
+	} else {
+		hdr[2], hdr[3] = 42, 0
+	}
+	return s.parseTIFF(io.MultiReader(bytes.NewReader(hdr), r))
+}
@@ -0,0 +1,58 @@
+package metadata_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+)
+
+func TestParseRAW(t *testing.T) {
+	tests := []struct {
+		bo    binary.ByteOrder
+		magic string
+	}{
+		{binary.LittleEndian, "IIRO"},
+		{binary.BigEndian, "MMOR"},
+		{binary.LittleEndian, "IIU\x00"},
+	}
+	for _, tt := range tests {
+		x := &exif.Exif{ByteOrder: tt.bo}
+		x.Set(exiftag.Make, exif.Ascii("Camera Co."))
+		x.Set(exiftag.Model, exif.Ascii("C1"))
+		x.Set(exiftag.Orientation, exif.Short{8})
+		x.SetTime(exiftag.DateTimeOriginal, exiftag.SubSecTimeOriginal,
+			time.Date(2017, 4, 1, 12, 34, 56, 0, time.Local))
+		x.SetLatLong(47.5, 19.25)
+
+		p, err := x.EncodeBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		copy(p, tt.magic)
+
+		m, err := metadata.Parse(bytes.NewReader(p))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.magic, err)
+		}
+
+		want := map[string]string{
+			metadata.Make:             "Camera Co.",
+			metadata.Model:            "C1",
+			metadata.Orientation:      "8",
+			metadata.DateTimeOriginal: "2017-04-01T12:34:56",
+		}
+		for k, v := range want {
+			if got := m.Get(k); got != v {
+				t.Errorf("%q: %s is %q, want %q", tt.magic, k, got, v)
+			}
+		}
+		if lat, lon, ok := m.GPSCoords(); lat != 47.5 || lon != 19.25 || !ok {
+			t.Errorf("%q: GPSCoords is %v, %v, %v", tt.magic, lat, lon, ok)
+		}
+	}
+}
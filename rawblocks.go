@@ -0,0 +1,124 @@
+package metadata
+
+import (
+	"bytes"
+	"io"
+
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+	"github.com/tajtiattila/metadata/mp4"
+	"github.com/tajtiattila/metadata/webp"
+)
+
+// RawBlock is a metadata block as found in a media file.
+type RawBlock struct {
+	// Name is the format of the block, such as "exif" or "xmp".
+	// Names match the ones used in Options.Want.
+	Name string
+
+	// Bytes holds the block undecoded, without the
+	// container specific prefix such as "Exif\x00\x00".
+	Bytes []byte
+}
+
+// RawBlocks returns the metadata blocks found in r
+// without decoding them.
+//
+// JPEG files yield their Exif and XMP APP1 segments and MP4 files
+// their XMP uuid boxes in their order of appearance.
+// WebP files yield their EXIF chunk followed by their XMP chunk.
+// ErrUnknownFormat is returned for other formats,
+// and ErrNoMeta if no metadata blocks were found.
+func RawBlocks(r io.Reader) ([]RawBlock, error) {
+	p := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, p)
+	switch err {
+	case io.ErrUnexpectedEOF, io.EOF, nil:
+		// pass
+	default:
+		return nil, err
+	}
+	p = p[:n]
+	r = prefixReader(p, r)
+
+	var blocks []RawBlock
+	switch {
+	case isjpeg(p):
+		blocks, err = jpegRawBlocks(r)
+	case iswebp(p):
+		blocks, err = webpRawBlocks(r)
+	case ismp4(p) && !isheif(p):
+		blocks, err = mp4RawBlocks(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	if err == nil && len(blocks) == 0 {
+		err = ErrNoMeta
+	}
+	return blocks, err
+}
+
+func jpegRawBlocks(r io.Reader) ([]RawBlock, error) {
+	j, err := xjpeg.NewScanner(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []RawBlock
+	for j.NextChunk() {
+		var name string
+		var trim int
+		switch {
+		case j.IsChunk(0xe1, jpegExifPfx):
+			name, trim = "exif", len(jpegExifPfx)
+		case j.IsChunk(0xe1, jpegXMPPfx):
+			name, trim = "xmp", len(jpegXMPPfx)
+		default:
+			continue
+		}
+
+		_, p, err := j.ReadChunk()
+		if err != nil {
+			return blocks, err
+		}
+		blocks = append(blocks, RawBlock{Name: name, Bytes: p[trim:]})
+	}
+
+	if err := j.Err(); err != nil && err != io.EOF {
+		return blocks, err
+	}
+	return blocks, nil
+}
+
+func webpRawBlocks(r io.Reader) ([]RawBlock, error) {
+	f, err := webp.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []RawBlock
+	for _, b := range []RawBlock{
+		{"exif", f.Exif},
+		{"xmp", f.XMP},
+	} {
+		if b.Bytes != nil {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+func mp4RawBlocks(r io.Reader) ([]RawBlock, error) {
+	f, err := mp4.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []RawBlock
+	for _, b := range f.Child {
+		if b.Type == "uuid" && bytes.HasPrefix(b.Raw, mp4xmpUuid) {
+			blocks = append(blocks, RawBlock{Name: "xmp", Bytes: b.Raw[len(mp4xmpUuid):]})
+		}
+	}
+	return blocks, nil
+}
@@ -0,0 +1,84 @@
+package metadata_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	xjpeg "github.com/tajtiattila/metadata/jpeg"
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestRawBlocks(t *testing.T) {
+	x := exif.New(100, 100)
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jpg bytes.Buffer
+	jpg.Write([]byte{0xff, 0xd8})
+	for _, chunk := range [][]byte{
+		append(append([]byte(nil), jpegExifPfx...), p...),
+		[]byte("Other\x00"),
+		append(append([]byte(nil), jpegXMPPfx...), testXMP...),
+	} {
+		if err := xjpeg.WriteChunk(&jpg, 0xe1, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jpg.Write(testScanData)
+
+	webp := testutil.WebP(
+		"VP8X", "\x2c\x00\x00\x00\x7f\x02\x00\xdf\x01\x00",
+		"ICCP", "profile",
+		"VP8 ", "\x50\x02\x00\x9d\x01\x2a\x80\x02\xe0\x01",
+		"EXIF", string(p),
+		"XMP ", testXMP)
+
+	xmpUuid := "\xbe\x7a\xcf\xcb\x97\xa9\x42\xe8\x9c\x71\x99\x94\x91\xe3\xaf\xac"
+	mp4 := bytes.Join([][]byte{
+		testutil.Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		testutil.Box("moov", testutil.Box("mvhd", make([]byte, 12), testutil.U32(600), testutil.U32(6000), make([]byte, 80))),
+		testutil.Box("uuid", []byte(xmpUuid), []byte(testXMP)),
+		testutil.Box("uuid", make([]byte, 16), []byte("other")),
+		testutil.Box("mdat", []byte("data")),
+	}, nil)
+
+	want := []metadata.RawBlock{
+		{Name: "exif", Bytes: p},
+		{Name: "xmp", Bytes: []byte(testXMP)},
+	}
+	tests := []struct {
+		name string
+		src  []byte
+		want []metadata.RawBlock
+	}{
+		{"jpeg", jpg.Bytes(), want},
+		{"webp", webp, want},
+		{"mp4", mp4, want[1:]},
+	}
+	for _, tt := range tests {
+		got, err := metadata.RawBlocks(bytes.NewReader(tt.src))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d blocks, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i].Name != tt.want[i].Name || !bytes.Equal(got[i].Bytes, tt.want[i].Bytes) {
+				t.Errorf("%s: block %d is %s (%d bytes), want %s (%d bytes)", tt.name, i,
+					got[i].Name, len(got[i].Bytes), tt.want[i].Name, len(tt.want[i].Bytes))
+			}
+		}
+	}
+
+	empty := append([]byte{0xff, 0xd8}, testScanData...)
+	if _, err := metadata.RawBlocks(bytes.NewReader(empty)); err != metadata.ErrNoMeta {
+		t.Errorf("got error %v for file without metadata, want ErrNoMeta", err)
+	}
+}
@@ -17,7 +17,7 @@ func prefixReader(pfx []byte, r io.Reader) io.Reader {
 		}
 		return &pfxReadSeeker{pfx, rs, 0}
 	}
-	return io.MultiReader(bytes.NewReader(pfx), rs)
+	return io.MultiReader(bytes.NewReader(pfx), r)
 }
 
 type pfxReadSeeker struct {
@@ -0,0 +1,49 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Box returns an ISO base media file format box used in MP4, HEIF
+// and AVIF files of type typ, having the concatenation of content.
+func Box(typ string, content ...[]byte) []byte {
+	p := bytes.Join(content, nil)
+	return append(append(U32(len(p)+8), typ...), p...)
+}
+
+// U16 returns v as a 16-bit big-endian value.
+func U16(v int) []byte {
+	p := make([]byte, 2)
+	binary.BigEndian.PutUint16(p, uint16(v))
+	return p
+}
+
+// U32 returns v as a 32-bit big-endian value.
+func U32(v int) []byte {
+	p := make([]byte, 4)
+	binary.BigEndian.PutUint32(p, uint32(v))
+	return p
+}
+
+// WebP returns a WebP file, a RIFF container of form type WEBP,
+// made of the chunks specified as fourcc and data pairs.
+func WebP(chunks ...string) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for i := 0; i+1 < len(chunks); i += 2 {
+		data := chunks[i+1]
+		body.WriteString(chunks[i])
+		binary.Write(&body, binary.LittleEndian, uint32(len(data)))
+		body.WriteString(data)
+		if len(data)%2 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(body.Len()))
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+)
+
+// maxTIFFBytes is the number of bytes read from TIFF files
+// that can't be read at offsets if Options.MaxMetaBytes is not set.
+const maxTIFFBytes = 64 << 20
+
+func istiff(p []byte) bool {
+	return bytes.HasPrefix(p, []byte("II*\x00")) || bytes.HasPrefix(p, []byte("MM\x00*"))
+}
+
+func (s *parseState) parseTIFF(r io.Reader) (*Metadata, error) {
+	if !s.want("exif") {
+		return nil, ErrNoMeta
+	}
+
+	var x *exif.Exif
+	var err error
+	if tr, ok := newTIFFReader(s, r); ok {
+		// IFDs may be after the image data in large files
+		x, err = exif.DecodeTIFFAt(tr, tr.size)
+	} else {
+		x, err = s.decodeTIFF(r)
+	}
+	if x == nil {
+		return nil, err
+	}
+
+	m := FromExif(x)
+	if m.Get(DateTimeCreated) == "" {
+		// TIFF files, such as scanned images, may only have DateTime
+		if t, islocal, ok := x.TimeWithOffset(exiftag.DateTime, exiftag.SubSecTime, exiftag.OffsetTime); ok {
+			d := new(Metadata)
+			d.Set(DateTimeCreated, fmtTime(t, islocal))
+			d.setSource("exif")
+			m = Merge(m, d)
+		}
+	}
+	if len(m.Attr) == 0 {
+		if err == nil {
+			err = ErrNoMeta
+		}
+		return nil, err
+	}
+	return m, err
+}
+
+// decodeTIFF reads r fully to decode the TIFF file in it,
+// because IFDs and tag values may be anywhere within the file.
+func (s *parseState) decodeTIFF(r io.Reader) (*exif.Exif, error) {
+	if s.MaxMetaBytes > 0 {
+		r = io.LimitReader(r, int64(s.MaxMetaBytes-s.n)+1)
+	} else {
+		r = io.LimitReader(r, maxTIFFBytes)
+	}
+	p, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.use(len(p)); err != nil {
+		return nil, err
+	}
+	return exif.DecodeTIFF(p)
+}
+
+// tiffReader reads a TIFF file at offsets, so that only
+// its header, IFDs and values are read and count as metadata.
+type tiffReader struct {
+	s  *parseState
+	rs io.ReadSeeker
+
+	start, size int64
+}
+
+// newTIFFReader returns a tiffReader for r positioned
+// at the start of a TIFF file, if r can seek within it.
+func newTIFFReader(s *parseState, r io.Reader) (*tiffReader, bool) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		return nil, false
+	}
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, false
+	}
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, false
+	}
+	return &tiffReader{s: s, rs: rs, start: start, size: end - start}, true
+}
+
+func (t *tiffReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if err := t.s.use(len(p)); err != nil {
+		return 0, err
+	}
+	if _, err := t.rs.Seek(t.start+off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(t.rs, p)
+}
@@ -0,0 +1,99 @@
+package metadata_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+)
+
+func TestParseTIFF(t *testing.T) {
+	for _, bo := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		x := &exif.Exif{ByteOrder: bo}
+		x.Set(exiftag.ImageWidth, exif.Long{640})
+		x.Set(exiftag.ImageLength, exif.Short{480})
+		x.Set(exiftag.Make, exif.Ascii("Scanner Co."))
+		x.Set(exiftag.Model, exif.Ascii("S1"))
+		x.Set(exiftag.DateTime, exif.Ascii("2017:03:04 11:12:13"))
+		x.Set(exiftag.Orientation, exif.Short{6})
+
+		// second page
+		if err := x.SetThumbImage(image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := x.EncodeBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := metadata.Parse(bytes.NewReader(p))
+		if err != nil {
+			t.Fatalf("%v: %v", bo, err)
+		}
+
+		want := map[string]string{
+			metadata.ImageWidth:      "640",
+			metadata.ImageHeight:     "480",
+			metadata.Make:            "Scanner Co.",
+			metadata.Model:           "S1",
+			metadata.DateTimeCreated: "2017-03-04T11:12:13",
+			metadata.Orientation:     "6",
+		}
+		for k, v := range want {
+			if got := m.Get(k); got != v {
+				t.Errorf("%v: %s is %q, want %q", bo, k, got, v)
+			}
+		}
+
+		x, err = exif.DecodeTIFF(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := metadata.FromExif(x).Get(metadata.DateTimeCreated); got != "" {
+			t.Errorf("%v: FromExif set DateTimeCreated %q from DateTime", bo, got)
+		}
+		if x.IFD1 != nil || x.Thumb != nil {
+			t.Errorf("%v: DecodeTIFF decoded IFD1", bo)
+		}
+	}
+}
+
+func TestParseTIFFTrailingIFD(t *testing.T) {
+	// IFD written after the image data
+	pixels := make([]byte, 1<<20)
+	makeValue := "Scanner Co.\x00"
+	ifd := 8 + len(pixels)
+	makeOffset := ifd + 2 + 2*12 + 4
+
+	bo := binary.LittleEndian
+	var buf bytes.Buffer
+	buf.WriteString("II*\x00")
+	binary.Write(&buf, bo, uint32(ifd))
+	buf.Write(pixels)
+	binary.Write(&buf, bo, uint16(2))
+	// ImageWidth
+	binary.Write(&buf, bo, []uint16{0x0100, exif.TypeLong})
+	binary.Write(&buf, bo, []uint32{1, 640})
+	// Make
+	binary.Write(&buf, bo, []uint16{0x010f, exif.TypeAscii})
+	binary.Write(&buf, bo, []uint32{uint32(len(makeValue)), uint32(makeOffset)})
+	binary.Write(&buf, bo, uint32(0))
+	buf.WriteString(makeValue)
+
+	m, err := metadata.ParseWithOptions(bytes.NewReader(buf.Bytes()),
+		metadata.Options{MaxMetaBytes: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Get(metadata.Make), "Scanner Co."; got != want {
+		t.Errorf("Make is %q, want %q", got, want)
+	}
+	if got, want := m.Get(metadata.ImageWidth), "640"; got != want {
+		t.Errorf("ImageWidth is %q, want %q", got, want)
+	}
+}
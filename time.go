@@ -13,10 +13,16 @@ import "time"
 //
 // Exif has a fixed standard time layout without a time zone.
 // Certain tools can write time zone information to Exif date fields,
-// but such Exif files are technically invalid.
+// but such Exif files are technically invalid. Exif 2.31 added
+// OffsetTime tags for the zone, which are used when present.
 //
 // XMP uses the time format understood by ParseTime, but may
 // omit elements from the end of the string, reducing precision.
+//
+// Times without a zone are wall clock times, the package stores them
+// in time.Local with HasLoc set to false. Use In to set the actual
+// zone of such times, and WallClock to get the wall clock time of
+// a time.Time in time.Local. GPS times are always UTC.
 type Time struct {
 	// Actual time value.
 	// Its location is always time.Local if HasLoc is false.
@@ -88,6 +94,21 @@ func (t Time) In(loc *time.Location) Time {
 	return t
 }
 
+// WallClock returns the time in time.Local having the same
+// Date() and Clock() as t, undoing the effect of Time.In.
+//
+// Wall clock times that do not exist in time.Local because
+// of a daylight saving time transition are normalized by time.Date.
+func WallClock(t time.Time) time.Time {
+	return wallClockIn(t, time.Local)
+}
+
+func wallClockIn(t time.Time, loc *time.Location) time.Time {
+	y, mon, d := t.Date()
+	h, min, sec := t.Clock()
+	return time.Date(y, mon, d, h, min, sec, t.Nanosecond(), loc)
+}
+
 var precLayout = []string{
 	"2006",
 	"2006-01",
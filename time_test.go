@@ -103,3 +103,82 @@ func testTimeIn(t *testing.T, y int, month time.Month, d, h, min, s int, l0, l1
 		t.Errorf("testTimeIn time differ got %v != src %v", got.Time, src.Time)
 	}
 }
+
+func TestWallClock(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bud, err := time.LoadLocation("Europe/Budapest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// times around the DST transition in Budapest
+	// on 2017-03-26 02:00 CET and in New York on 2017-03-12 02:00 EST
+	for _, src := range []time.Time{
+		time.Date(2017, time.March, 26, 1, 30, 0, 0, ny),
+		time.Date(2017, time.March, 26, 3, 30, 0, 0, ny),
+		time.Date(2017, time.March, 12, 1, 30, 0, 0, bud),
+		time.Date(2017, time.March, 12, 3, 30, 0, 0, bud),
+		time.Date(2017, time.October, 29, 2, 30, 0, 500, time.UTC),
+	} {
+		for _, loc := range []*time.Location{bud, ny} {
+			got := wallClockIn(src, loc)
+			if got.Location() != loc {
+				t.Errorf("wallClockIn(%v, %v) has location %v", src, loc, got.Location())
+			}
+			if !sameWallClock(got, src) {
+				t.Errorf("wallClockIn(%v, %v) is %v", src, loc, got)
+			}
+
+			// In is the inverse of WallClock
+			back := Time{Time: got, Prec: 7}.In(src.Location())
+			if !sameWallClock(back.Time, src) {
+				t.Errorf("wallClockIn(%v, %v) with In is %v", src, loc, back.Time)
+			}
+		}
+
+		if got := WallClock(src); got.Location() != time.Local || !sameWallClock(got, src) {
+			t.Errorf("WallClock(%v) is %v", src, got)
+		}
+	}
+}
+
+func sameWallClock(a, b time.Time) bool {
+	ay, amon, ad := a.Date()
+	by, bmon, bd := b.Date()
+	ah, amin, as := a.Clock()
+	bh, bmin, bs := b.Clock()
+	return ay == by && amon == bmon && ad == bd &&
+		ah == bh && amin == bmin && as == bs &&
+		a.Nanosecond() == b.Nanosecond()
+}
+
+func TestTimeBetter(t *testing.T) {
+	tests := []struct {
+		val, than string
+		want      bool
+	}{
+		// more precise
+		{"2017-04-01T12:34:56", "2017-04-01T12:34", true},
+		{"2017-04-01T12:34", "2017-04-01T12:34:56", false},
+		{"2017-04-01T12:34:56.5Z", "2017-04-01T12:34:56Z", true},
+
+		// zone over local
+		{"2017-04-01T10:34:56Z", "2017-04-01T12:34:56", true},
+		{"2017-04-01T12:34:56", "2017-04-01T10:34:56Z", false},
+		{"2017-04-01T12:34+02:00", "2017-04-01T12:34:56.789", true},
+		{"2017-04-01T12:34:56.789", "2017-04-01T12:34+02:00", false},
+
+		// local over invalid
+		{"2017-04-01T12:34:56", "", true},
+		{"", "2017-04-01T12:34:56", false},
+		{"invalid", "", false},
+	}
+	for _, tt := range tests {
+		if got := timeBetter(tt.val, tt.than); got != tt.want {
+			t.Errorf("timeBetter(%q, %q) is %v, want %v", tt.val, tt.than, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package metadata
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+
+	"github.com/tajtiattila/metadata/webp"
+)
+
+func iswebp(p []byte) bool {
+	return len(p) >= 12 && bytes.HasPrefix(p, []byte("RIFF")) && string(p[8:12]) == "WEBP"
+}
+
+// parseWebP parses Exif and XMP metadata from the chunks
+// of the WebP file in r. ImageWidth and ImageHeight are
+// set to the canvas size, that overrides the Exif values.
+func (s *parseState) parseWebP(r io.Reader) (*Metadata, error) {
+	f, err := webp.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta []*Metadata
+	var firstErr error
+	setErr := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, chunk := range []struct {
+		format string
+		p      []byte
+		decode func(p []byte) (*Metadata, error)
+	}{
+		{"exif", f.Exif, FromExifBytes},
+		{"xmp", f.XMP, FromXMPBytes},
+	} {
+		if chunk.p == nil || !s.want(chunk.format) {
+			continue
+		}
+		if err := s.use(len(chunk.p)); err != nil {
+			return nil, err
+		}
+		m, err := chunk.decode(chunk.p)
+		if err != nil {
+			setErr(err)
+		}
+		if m != nil {
+			meta = append(meta, m)
+		}
+	}
+
+	if f.Width > 0 && f.Height > 0 {
+		m := new(Metadata)
+		m.Set(ImageWidth, strconv.Itoa(f.Width))
+		m.Set(ImageHeight, strconv.Itoa(f.Height))
+		m.setSource("webp")
+		meta = append(meta, m)
+	}
+
+	if len(meta) == 0 {
+		if firstErr == nil {
+			firstErr = ErrNoMeta
+		}
+		return nil, firstErr
+	}
+
+	return Merge(meta...), firstErr
+}
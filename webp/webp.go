@@ -0,0 +1,194 @@
+// Package webp implements a parser for the RIFF container
+// of WebP images, to read their dimensions and metadata chunks.
+package webp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// ErrFormat is returned by Parse if the file is not a valid WebP file.
+var ErrFormat = errors.New("webp: invalid format")
+
+// maxChunkSize is the maximum size of chunks loaded by Parse.
+const maxChunkSize = 16 << 20
+
+// Flags are the feature flags of the VP8X chunk
+// of extended format WebP files.
+type Flags uint8
+
+const (
+	Animation Flags = 1 << 1 // file has animation frames
+	XMP       Flags = 1 << 2 // file has XMP metadata
+	Exif      Flags = 1 << 3 // file has Exif metadata
+	Alpha     Flags = 1 << 4 // images have alpha (transparency)
+	ICC       Flags = 1 << 5 // file has an ICC profile
+)
+
+// File holds information about a WebP file.
+type File struct {
+	// Extended is true if the file has a VP8X chunk.
+	Extended bool
+
+	// Flags are the features from the VP8X chunk, and are
+	// zero for simple (lossy or lossless) files.
+	Flags Flags
+
+	// Width and Height are the canvas size in pixels from the VP8X chunk,
+	// or the image size from the VP8 or VP8L bitstream of simple files.
+	Width, Height int
+
+	// Exif and XMP are the payloads of the EXIF and XMP chunks,
+	// or nil if missing.
+	Exif, XMP []byte
+}
+
+// Parse parses the WebP file in r.
+//
+// Chunks of image data and other chunks such as ICCP are skipped
+// and not loaded. Parse returns ErrFormat if the VP8X, EXIF or XMP
+// chunk is larger than 16 MiB.
+func Parse(r io.Reader) (*File, error) {
+	var hdr [12]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrFormat
+		}
+		return nil, err
+	}
+	if string(hdr[:4]) != "RIFF" || string(hdr[8:]) != "WEBP" {
+		return nil, ErrFormat
+	}
+
+	// bytes left in the RIFF container after the form type
+	left := int64(binary.LittleEndian.Uint32(hdr[4:])) - 4
+
+	f := new(File)
+	haveSize := false
+	for left >= 8 {
+		var ch [8]byte
+		if _, err := io.ReadFull(r, ch[:]); err != nil {
+			if err == io.EOF {
+				// truncated file
+				break
+			}
+			return nil, err
+		}
+		left -= 8
+
+		fourcc := string(ch[:4])
+		size := int64(binary.LittleEndian.Uint32(ch[4:]))
+		padded := size + size&1
+		if padded > left {
+			return nil, ErrFormat
+		}
+		left -= padded
+
+		var ptr *[]byte
+		var head int64 // bytes needed from image data chunks
+		switch fourcc {
+		case "VP8X":
+			ptr, f.Extended = new([]byte), true
+		case "VP8 ", "VP8L":
+			if !haveSize {
+				head = 10
+			}
+		case "EXIF":
+			ptr = &f.Exif
+		case "XMP ":
+			ptr = &f.XMP
+		}
+
+		if ptr == nil && head == 0 {
+			if err := skip(r, padded); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		n := size
+		if ptr == nil && head < n {
+			n = head
+		}
+		if n > maxChunkSize {
+			return nil, ErrFormat
+		}
+		p := make([]byte, n)
+		if _, err := io.ReadFull(r, p); err != nil {
+			return nil, err
+		}
+		if err := skip(r, padded-n); err != nil {
+			return nil, err
+		}
+
+		switch fourcc {
+		case "VP8X":
+			if len(p) < 10 {
+				return nil, ErrFormat
+			}
+			f.Flags = Flags(p[0])
+			f.Width, f.Height = 1+uint24(p[4:]), 1+uint24(p[7:])
+			haveSize = true
+		case "VP8 ":
+			f.Width, f.Height, haveSize = vp8Size(p)
+		case "VP8L":
+			f.Width, f.Height, haveSize = vp8lSize(p)
+		default:
+			*ptr = p
+		}
+	}
+
+	if f.Exif != nil {
+		// some writers include the JPEG APP1 prefix
+		f.Exif = bytes.TrimPrefix(f.Exif, []byte("Exif\x00\x00"))
+	}
+
+	return f, nil
+}
+
+// uint24 returns the 24-bit little-endian value in p.
+func uint24(p []byte) int {
+	return int(p[0]) | int(p[1])<<8 | int(p[2])<<16
+}
+
+// vp8Size returns the size of the lossy VP8 key frame in p.
+func vp8Size(p []byte) (dx, dy int, ok bool) {
+	// 3-byte frame tag, start code, then 14-bit width and height
+	if len(p) < 10 || p[0]&1 != 0 || !bytes.Equal(p[3:6], []byte{0x9d, 0x01, 0x2a}) {
+		return 0, 0, false
+	}
+	dx = int(binary.LittleEndian.Uint16(p[6:])) & 0x3fff
+	dy = int(binary.LittleEndian.Uint16(p[8:])) & 0x3fff
+	return dx, dy, true
+}
+
+// vp8lSize returns the size of the lossless VP8L image in p.
+func vp8lSize(p []byte) (dx, dy int, ok bool) {
+	// signature, then 14-bit width-1 and height-1
+	if len(p) < 5 || p[0] != 0x2f {
+		return 0, 0, false
+	}
+	v := binary.LittleEndian.Uint32(p[1:])
+	dx = 1 + int(v&0x3fff)
+	dy = 1 + int(v>>14&0x3fff)
+	return dx, dy, true
+}
+
+// skip skips n bytes in r, using Seek if r is an io.Seeker.
+func skip(r io.Reader, n int64) error {
+	if n == 0 {
+		return nil
+	}
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekCurrent)
+		return err
+	}
+	_, err := io.CopyN(ioutil.Discard, r, n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
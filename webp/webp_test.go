@@ -0,0 +1,79 @@
+package webp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+// smallest lossless WebP: 1x1 pixel with alpha
+var lossless1x1 = []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+func TestParse(t *testing.T) {
+	// VP8 key frame of 400x300 pixels
+	const vp8 = "\x50\x02\x00\x9d\x01\x2a\x90\x01\x2c\x01image data"
+
+	// canvas of 1000x750 pixels with animation, alpha, Exif and XMP
+	const vp8x = "\x1e\x00\x00\x00\xe7\x03\x00\xed\x02\x00"
+
+	// canvas of 16777216x1 pixels, the largest width
+	const vp8xMax = "\x00\x00\x00\x00\xff\xff\xff\x00\x00\x00"
+
+	tests := []struct {
+		name     string
+		data     []byte
+		flags    Flags
+		dx, dy   int
+		exif     string
+		xmp      string
+		extended bool
+	}{
+		{name: "lossless", data: lossless1x1, dx: 1, dy: 1},
+		{name: "lossy", data: testutil.WebP("VP8 ", vp8), dx: 400, dy: 300},
+		{
+			name: "extended",
+			data: testutil.WebP("VP8X", vp8x,
+				"ICCP", "icc",
+				"ANIM", string(make([]byte, 6)),
+				"ANMF", string(make([]byte, 31)),
+				"EXIF", "Exif\x00\x00MM\x00\x2a",
+				"XMP ", "<x:xmpmeta/>"),
+			flags: Animation | Alpha | Exif | XMP,
+			dx:    1000, dy: 750,
+			exif: "MM\x00\x2a", xmp: "<x:xmpmeta/>",
+			extended: true,
+		},
+		{name: "max", data: testutil.WebP("VP8X", vp8xMax, "VP8 ", vp8), dx: 1 << 24, dy: 1, extended: true},
+	}
+	for _, tt := range tests {
+		f, err := Parse(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if f.Flags != tt.flags || f.Extended != tt.extended {
+			t.Errorf("%s: flags are %#x (extended=%v), want %#x (extended=%v)",
+				tt.name, f.Flags, f.Extended, tt.flags, tt.extended)
+		}
+		if f.Width != tt.dx || f.Height != tt.dy {
+			t.Errorf("%s: size is %dx%d, want %dx%d", tt.name, f.Width, f.Height, tt.dx, tt.dy)
+		}
+		if string(f.Exif) != tt.exif || string(f.XMP) != tt.xmp {
+			t.Errorf("%s: got Exif %q, XMP %q", tt.name, f.Exif, f.XMP)
+		}
+	}
+
+	for _, p := range [][]byte{
+		nil,
+		[]byte("RIFF\x04\x00\x00\x00WAVE"),
+		testutil.WebP("VP8X", "short"),
+		lossless1x1[:24],
+		// EXIF chunk claiming close to 4 GiB
+		[]byte("RIFF\xff\xff\xff\xffWEBPEXIF\x00\x00\x00\xf0"),
+	} {
+		if _, err := Parse(bytes.NewReader(p)); err == nil {
+			t.Errorf("invalid file %q parsed", p)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package metadata_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tajtiattila/metadata"
+	"github.com/tajtiattila/metadata/exif"
+	"github.com/tajtiattila/metadata/exif/exiftag"
+	"github.com/tajtiattila/metadata/testutil"
+)
+
+func TestParseWebP(t *testing.T) {
+	x := exif.New(100, 100)
+	x.Set(exiftag.Make, exif.Ascii("ExifMake"))
+	x.Set(exiftag.Model, exif.Ascii("ExifModel"))
+	p, err := x.EncodeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// canvas of 640x480 pixels with Exif and XMP
+	src := testutil.WebP(
+		"VP8X", "\x0c\x00\x00\x00\x7f\x02\x00\xdf\x01\x00",
+		"VP8 ", "\x50\x02\x00\x9d\x01\x2a\x80\x02\xe0\x01",
+		"EXIF", string(p),
+		"XMP ", testXMP)
+
+	m, err := metadata.Parse(bytes.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		metadata.ImageWidth:  "640",
+		metadata.ImageHeight: "480",
+		metadata.Make:        "TestMake",
+		metadata.Model:       "ExifModel",
+	} {
+		if got := m.Get(key); got != want {
+			t.Errorf("%s is %q, want %q", key, got, want)
+		}
+	}
+}
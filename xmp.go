@@ -3,6 +3,9 @@ package metadata
 import (
 	"bytes"
 	"fmt"
+	"math"
+	"strconv"
+	"time"
 
 	"github.com/tajtiattila/metadata/xmp"
 )
@@ -23,26 +26,43 @@ func FromXMP(x *xmp.Meta) *Metadata {
 			m.Set(a.metaName, v)
 		}
 	}
+	m.setSource("xmp")
 	return m
 }
 
 var xmpAttr = []struct {
 	metaName string
 	getf     func(x *xmp.Meta) (string, bool)
+	setf     func(x *xmp.Meta, v string) // used in Copy
 }{
-	{DateTimeCreated, xmpString(xmp.CreateDate)},
-	{DateTimeOriginal, xmpString(xmp.DateTimeOriginal)},
-	{GPSDateTime, xmpString(xmp.GPSTimeStamp)},
+	{DateTimeCreated, xmpString(xmp.CreateDate), xmpSetString("xmp:CreateDate")},
+	{DateTimeOriginal, xmpString(xmp.DateTimeOriginal), xmpSetString("exif:DateTimeOriginal")},
+	{GPSDateTime, xmpString(xmp.GPSTimeStamp), xmpSetGPSTime},
 
-	{Rating, xmpInt(xmp.Rating)},
+	{Rating, xmpInt(xmp.Rating), xmpSetInt("xmp:Rating")},
 
-	{GPSLatitude, xmpFloat(xmp.GPSLatitude)},
-	{GPSLongitude, xmpFloat(xmp.GPSLongitude)},
+	{GPSLatitude, xmpFloat(xmp.GPSLatitude), xmpSetCoord("exif:GPSLatitude", 'N', 'S')},
+	{GPSLongitude, xmpFloat(xmp.GPSLongitude), xmpSetCoord("exif:GPSLongitude", 'E', 'W')},
+	{GPSAltitude, xmpFloat(xmp.GPSAltitude), xmpSetAltitude},
+	{GPSImgDirection, xmpFloat(xmp.GPSImgDirection), xmpSetRational("exif:GPSImgDirection")},
+	{GPSDOP, xmpFloat(xmp.GPSDOP), nil},
+	{GPSSatellites, xmpString(xmp.GPSSatellites), nil},
+	{GPSProcessingMethod, xmpString(xmp.GPSProcessingMethod), xmpSetString("exif:GPSProcessingMethod")},
+	{GPSAreaInformation, xmpString(xmp.GPSAreaInformation), xmpSetString("exif:GPSAreaInformation")},
 
-	{Orientation, xmpInt(xmp.Orientation)},
+	{Orientation, xmpInt(xmp.Orientation), xmpSetInt("exif:Orientation")},
 
-	{Make, xmpString(xmp.Make)},
-	{Model, xmpString(xmp.Model)},
+	{InteropIndex, xmpString(xmp.InteropIndex), nil},
+	{MotionPhotoOffset, xmpInt(xmp.MotionPhotoOffset), nil},
+
+	{Make, xmpString(xmp.Make), xmpSetString("tiff:Make")},
+	{Model, xmpString(xmp.Model), xmpSetString("tiff:Model")},
+	{Software, xmpString(xmp.CreatorTool), xmpSetString("xmp:CreatorTool")},
+
+	{Title, xmpString(xmp.Title), xmpSetLangAlt("dc:title")},
+	{Description, xmpString(xmp.Description), xmpSetLangAlt("dc:description")},
+	{ImageDescription, xmpString(xmp.ImageDescription), xmpSetLangAlt("tiff:ImageDescription")},
+	{UserComment, xmpString(xmp.UserComment), xmpSetLangAlt("exif:UserComment")},
 }
 
 func xmpString(a xmp.StringFunc) func(x *xmp.Meta) (string, bool) {
@@ -70,3 +90,80 @@ func xmpInt(a xmp.IntFunc) func(x *xmp.Meta) (string, bool) {
 		return "", false
 	}
 }
+
+func xmpSetString(name string) func(x *xmp.Meta, v string) {
+	return func(x *xmp.Meta, v string) {
+		x.SetString(name, v)
+	}
+}
+
+func xmpSetLangAlt(name string) func(x *xmp.Meta, v string) {
+	return func(x *xmp.Meta, v string) {
+		x.SetLangAlt(name, v)
+	}
+}
+
+func xmpSetInt(name string) func(x *xmp.Meta, v string) {
+	return func(x *xmp.Meta, v string) {
+		if i, err := strconv.Atoi(v); err == nil {
+			x.SetString(name, strconv.Itoa(i))
+		}
+	}
+}
+
+// xmpSetRational sets a non-negative rational value with 1/100 precision.
+func xmpSetRational(name string) func(x *xmp.Meta, v string) {
+	return func(x *xmp.Meta, v string) {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			x.SetString(name, fmt.Sprintf("%d/100", int64(f*100+0.5)))
+		}
+	}
+}
+
+// xmpSetCoord sets a GPS coordinate in the "DDD,MM.mmmmmmR" format
+func xmpSetCoord(name string, pos, neg byte) func(x *xmp.Meta, v string) {
+	return func(x *xmp.Meta, v string) {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return
+		}
+		ref := pos
+		if f < 0 {
+			ref, f = neg, -f
+		}
+		deg := math.Floor(f)
+		x.SetString(name, fmt.Sprintf("%d,%.6f%c", int(deg), (f-deg)*60, ref))
+	}
+}
+
+func xmpSetAltitude(x *xmp.Meta, v string) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return
+	}
+	ref := "0"
+	if f < 0 {
+		ref, f = "1", -f
+	}
+	x.SetString("exif:GPSAltitude", fmt.Sprintf("%d/1000", int64(f*1000+0.5)))
+	x.SetString("exif:GPSAltitudeRef", ref)
+}
+
+// xmpSetGPSTime sets the GPS date and time in the Exif form
+// written by cameras, with the time of day as hours, minutes
+// and seconds in exif:GPSTimeStamp and the date in exif:GPSDateStamp.
+// Values not in RFC 3339 format are written as is to exif:GPSTimeStamp.
+func xmpSetGPSTime(x *xmp.Meta, v string) {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		// date stamp of a previous time would be stale
+		x.SetString("exif:GPSTimeStamp", v)
+		x.Delete("exif:GPSDateStamp")
+		return
+	}
+	t = t.UTC()
+	sec := float64(t.Second()) + float64(t.Nanosecond())/1e9
+	x.SetString("exif:GPSTimeStamp", fmt.Sprintf("%d,%d,%s",
+		t.Hour(), t.Minute(), strconv.FormatFloat(sec, 'f', -1, 64)))
+	x.SetString("exif:GPSDateStamp", t.Format("2006:01:02"))
+}
@@ -0,0 +1,254 @@
+package xmp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+const (
+	metaNS = "adobe:ns:meta/"
+
+	packetID = "W5M0MpCehiHzreSzNTczkc9d"
+
+	// defaultPadding is the default of Meta.PacketPadding.
+	defaultPadding = 2048
+)
+
+// DecodeFile decodes XMP metadata from the file at path,
+// such as a standalone .xmp sidecar file.
+func DecodeFile(path string) (*Meta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// EncodeFile writes m into a standalone XMP packet at path.
+func (m *Meta) EncodeFile(path string) error {
+	var buf bytes.Buffer
+	if err := m.Encode(&buf); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0666)
+}
+
+// Encode writes m as an XMP packet to w.
+//
+// The packet includes the <?xpacket?> wrapper
+// and whitespace padding specified by m.PacketPadding,
+// so that it may be updated in place.
+func (m *Meta) Encode(w io.Writer) error {
+	e := &encoder{
+		w:      bufio.NewWriter(w),
+		prefix: make(map[string]string),
+	}
+	e.collectPrefixes(m)
+
+	e.printf("<?xpacket begin=\"\ufeff\" id=%q?>\n", packetID)
+	e.printf("<x:xmpmeta xmlns:x=%q>\n", metaNS)
+	e.printf(" <rdf:RDF xmlns:rdf=%q>\n", rdfNS)
+	for i := range m.Rdf.Desc {
+		e.desc(&m.Rdf.Desc[i])
+	}
+	e.printf(" </rdf:RDF>\n")
+	e.printf("</x:xmpmeta>\n")
+	e.pad(m.padding())
+	e.printf("<?xpacket end=\"w\"?>")
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (m *Meta) padding() int {
+	switch {
+	case m.PacketPadding == 0:
+		return defaultPadding
+	case m.PacketPadding < 0:
+		return 0
+	}
+	return m.PacketPadding
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+
+	// prefix maps namespaces to prefixes
+	prefix map[string]string
+}
+
+func (e *encoder) printf(format string, args ...interface{}) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, args...)
+	}
+}
+
+func (e *encoder) escape(p []byte) {
+	if e.err == nil {
+		e.err = xml.EscapeText(e.w, p)
+	}
+}
+
+func (e *encoder) pad(n int) {
+	const line = 100
+	for n > 0 {
+		l := line
+		if n < l {
+			l = n
+		}
+		e.printf("%*s\n", l-1, "")
+		n -= l
+	}
+}
+
+// collectPrefixes sets up namespace prefixes for m
+// using known prefixes and the ones declared in m.
+func (e *encoder) collectPrefixes(m *Meta) {
+	used := map[string]bool{
+		"x":   true,
+		"rdf": true,
+		"xml": true,
+	}
+	e.prefix[metaNS] = "x"
+	e.prefix[rdfNS] = "rdf"
+	e.prefix[xmlNS] = "xml"
+
+	for pfx, ns := range nsmap {
+		e.prefix[ns] = pfx
+		used[pfx] = true
+	}
+
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for _, a := range n.Attr {
+			if a.Name.Space == "xmlns" && !used[a.Name.Local] {
+				if _, ok := e.prefix[a.Value]; !ok {
+					e.prefix[a.Value] = a.Name.Local
+					used[a.Name.Local] = true
+				}
+			}
+		}
+		for i := range n.Node {
+			walk(&n.Node[i])
+		}
+	}
+	for i := range m.Rdf.Desc {
+		walk(&m.Rdf.Desc[i])
+	}
+
+	// generate prefixes for the rest
+	for i := range m.Rdf.Desc {
+		for _, ns := range namespaces(&m.Rdf.Desc[i]) {
+			if _, ok := e.prefix[ns]; ok {
+				continue
+			}
+			for j := 1; ; j++ {
+				pfx := fmt.Sprintf("ns%d", j)
+				if !used[pfx] {
+					e.prefix[ns] = pfx
+					used[pfx] = true
+					break
+				}
+			}
+		}
+	}
+}
+
+func (e *encoder) name(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return e.prefix[n.Space] + ":" + n.Local
+}
+
+// desc writes a description node along with its namespace declarations.
+func (e *encoder) desc(d *Node) {
+	e.printf("  <%s", e.name(d.XMLName))
+	e.attrs(d)
+	for _, ns := range namespaces(d) {
+		if ns == rdfNS || ns == xmlNS {
+			continue
+		}
+		e.printf("\n    xmlns:%s=%q", e.prefix[ns], ns)
+	}
+	e.content(d, "  ")
+}
+
+func (e *encoder) node(n *Node, indent string) {
+	e.printf("%s<%s", indent, e.name(n.XMLName))
+	e.attrs(n)
+	e.content(n, indent)
+}
+
+// content writes the content and the end tag of n.
+func (e *encoder) content(n *Node, indent string) {
+	if len(n.Node) == 0 {
+		if len(n.CharData) == 0 {
+			e.printf("/>\n")
+			return
+		}
+		e.printf(">")
+		e.escape(n.CharData)
+		e.printf("</%s>\n", e.name(n.XMLName))
+		return
+	}
+
+	// CharData of nodes with children is only whitespace
+	e.printf(">\n")
+	for i := range n.Node {
+		e.node(&n.Node[i], indent+" ")
+	}
+	e.printf("%s</%s>\n", indent, e.name(n.XMLName))
+}
+
+func (e *encoder) attrs(n *Node) {
+	for _, a := range n.Attr {
+		if isNSDecl(a) {
+			continue
+		}
+		e.printf(" %s=\"", e.name(a.Name))
+		e.escape([]byte(a.Value))
+		e.printf("\"")
+	}
+}
+
+func isNSDecl(a xml.Attr) bool {
+	return a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns")
+}
+
+// namespaces returns the sorted list of namespaces used within n.
+func namespaces(n *Node) []string {
+	m := make(map[string]struct{})
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.XMLName.Space != "" {
+			m[n.XMLName.Space] = struct{}{}
+		}
+		for _, a := range n.Attr {
+			if !isNSDecl(a) && a.Name.Space != "" {
+				m[a.Name.Space] = struct{}{}
+			}
+		}
+		for i := range n.Node {
+			walk(&n.Node[i])
+		}
+	}
+	walk(n)
+
+	v := make([]string, 0, len(m))
+	for ns := range m {
+		v = append(v, ns)
+	}
+	sort.Strings(v)
+	return v
+}
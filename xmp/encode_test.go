@@ -0,0 +1,209 @@
+package xmp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, src := range []string{sample, langAltSample} {
+		x, err := Decode(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := x.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		p := buf.Bytes()
+		if !bytes.HasPrefix(p, []byte("<?xpacket begin=")) ||
+			!bytes.HasSuffix(p, []byte("<?xpacket end=\"w\"?>")) {
+			t.Error("xpacket wrapper missing")
+		}
+		if !bytes.Contains(p, bytes.Repeat([]byte(" "), 99)) {
+			t.Error("padding missing")
+		}
+
+		y, err := Decode(bytes.NewReader(p))
+		if err != nil {
+			t.Fatalf("decode encoded: %v\n%s", err, p)
+		}
+
+		for _, f := range []StringFunc{Title, Description, CreateDate, DateTimeOriginal, Make, Model} {
+			xv, xok := x.String(f)
+			yv, yok := y.String(f)
+			if xv != yv || xok != yok {
+				t.Errorf("round trip mismatch: %q (%v) != %q (%v)", xv, xok, yv, yok)
+			}
+		}
+		for _, f := range []Float64Func{GPSLatitude, GPSLongitude, GPSAltitude} {
+			xv, xok := x.Float64(f)
+			yv, yok := y.Float64(f)
+			if xv != yv || xok != yok {
+				t.Errorf("round trip mismatch: %v (%v) != %v (%v)", xv, xok, yv, yok)
+			}
+		}
+	}
+}
+
+func TestPacketPadding(t *testing.T) {
+	x, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := make(map[int]int)
+	for _, pad := range []int{-1, 0, 1, 150, 4096} {
+		x.PacketPadding = pad
+
+		var buf bytes.Buffer
+		if err := x.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		size[pad] = buf.Len()
+
+		y, err := Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("padding %d: decode encoded: %v", pad, err)
+		}
+		if got, ok := y.String(Model); !ok || got != "Nexus 5" {
+			t.Errorf("padding %d: Model is %q (ok=%v)", pad, got, ok)
+		}
+	}
+
+	for pad, want := range map[int]int{0: defaultPadding, 1: 1, 150: 150, 4096: 4096} {
+		if got := size[pad] - size[-1]; got != want {
+			t.Errorf("padding %d: packet has %d bytes of padding, want %d", pad, got, want)
+		}
+	}
+}
+
+func TestEncodeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xmptest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	x, err := Decode(strings.NewReader(langAltSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetLangAlt("dc:title", "Sidecar & title")
+
+	path := filepath.Join(dir, "test.xmp")
+	if err := x.EncodeFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := DecodeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkString(t, y, "dc:title", Title, "Sidecar & title")
+	checkString(t, y, "dc:description", Description, "Erste Beschreibung")
+}
+
+func TestNew(t *testing.T) {
+	x := New()
+	x.SetString("xmp:CreateDate", "2017-04-01T12:34:56")
+	x.SetString("xmp:Rating", "5")
+	x.SetLangAlt("dc:title", "Title")
+
+	var buf bytes.Buffer
+	if err := x.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	y, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decode encoded: %v\n%s", err, buf.Bytes())
+	}
+
+	checkString(t, y, "xmp:CreateDate", CreateDate, "2017-04-01T12:34:56")
+	checkString(t, y, "dc:title", Title, "Title")
+	if r, ok := y.Int(Rating); !ok || r != 5 {
+		t.Errorf("Rating is %v, %v; want 5, true", r, ok)
+	}
+	if len(y.Rdf.Desc) != 2 {
+		t.Errorf("got %d descriptions, want 2", len(y.Rdf.Desc))
+	}
+}
+
+func TestSetStringKeepsOtherNodes(t *testing.T) {
+	x, err := Decode(strings.NewReader(sidecarSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ndesc := len(x.Rdf.Desc)
+
+	if r, ok := x.Int(Rating); !ok || r != 3 {
+		t.Errorf("attribute Rating is %v, %v; want 3, true", r, ok)
+	}
+
+	x.SetString("tiff:Make", "New make")
+	x.SetString("xmp:Rating", "4")
+	x.SetString("xmp:CreateDate", "2017-04-01T12:34:56")
+
+	if len(x.Rdf.Desc) != ndesc {
+		t.Errorf("got %d descriptions after SetString, want %d", len(x.Rdf.Desc), ndesc)
+	}
+
+	var buf bytes.Buffer
+	if err := x.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	y, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decode encoded: %v\n%s", err, buf.Bytes())
+	}
+
+	checkString(t, y, "tiff:Make", Make, "New make")
+	checkString(t, y, "tiff:Model", Model, "Model")
+	checkString(t, y, "xmp:CreateDate", CreateDate, "2017-04-01T12:34:56")
+	if r, ok := y.Int(Rating); !ok || r != 4 {
+		t.Errorf("Rating is %v, %v; want 4, true", r, ok)
+	}
+
+	if len(y.Rdf.Desc) != ndesc {
+		t.Errorf("got %d descriptions after round trip, want %d", len(y.Rdf.Desc), ndesc)
+	}
+	for _, name := range []xml.Name{
+		{Space: "http://ns.adobe.com/photoshop/1.0/", Local: "City"},
+		{Space: "http://iptc.org/std/Iptc4xmpCore/1.0/xmlns/", Local: "Location"},
+	} {
+		if findNode(y, name) == nil {
+			t.Errorf("%s lost", name.Local)
+		}
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("Rating")); n != 1 {
+		t.Errorf("Rating appears %d times, want 1", n)
+	}
+}
+
+const sidecarSample = `<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about=''
+  xmlns:xmp='http://ns.adobe.com/xap/1.0/'
+  xmp:Rating='3'/>
+ <rdf:Description rdf:about=''
+  xmlns:photoshop='http://ns.adobe.com/photoshop/1.0/'>
+  <photoshop:City>Budapest</photoshop:City>
+ </rdf:Description>
+ <rdf:Description rdf:about=''
+  xmlns:Iptc4xmpCore='http://iptc.org/std/Iptc4xmpCore/1.0/xmlns/'>
+  <Iptc4xmpCore:Location>Castle Hill</Iptc4xmpCore:Location>
+ </rdf:Description>
+ <rdf:Description rdf:about=''
+  xmlns:tiff='http://ns.adobe.com/tiff/1.0/'>
+  <tiff:Make>Make</tiff:Make>
+  <tiff:Model>Model</tiff:Model>
+ </rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>`
@@ -2,8 +2,10 @@ package xmp
 
 import (
 	"encoding/xml"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var nsmap = map[string]string{
@@ -11,24 +13,81 @@ var nsmap = map[string]string{
 	"tiff":   "http://ns.adobe.com/tiff/1.0/",
 	"exif":   "http://ns.adobe.com/exif/1.0/",
 	"exifex": "http://cipa.jp/exif/1.0/",
+	"dc":     "http://purl.org/dc/elements/1.1/",
+
+	// Google motion photos
+	"GCamera":   "http://ns.google.com/photos/1.0/camera/",
+	"Container": "http://ns.google.com/photos/1.0/container/",
+	"Item":      "http://ns.google.com/photos/1.0/container/item/",
 }
 
+const (
+	rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmlNS = "http://www.w3.org/XML/1998/namespace"
+)
+
+var (
+	rdfAlt         = xml.Name{Space: rdfNS, Local: "Alt"}
+	rdfSeq         = xml.Name{Space: rdfNS, Local: "Seq"}
+	rdfLi          = xml.Name{Space: rdfNS, Local: "li"}
+	rdfDescription = xml.Name{Space: rdfNS, Local: "Description"}
+	rdfAbout       = xml.Name{Space: rdfNS, Local: "about"}
+	xmlLang        = xml.Name{Space: xmlNS, Local: "lang"}
+)
+
+// DefaultLang is the language of the default item
+// in language alternative properties.
+const DefaultLang = "x-default"
+
 var (
 	CreateDate = tagString("xmp:CreateDate") // used for exif/DateTimeDigitized
 
-	Rating = tagInt("xmp:Rating")
+	// Rating is -1 for rejected, 0 for unrated
+	// or 1..5 for user ratings
+	Rating = tagRating("xmp:Rating")
 
 	DateTimeOriginal = tagString("exif:DateTimeOriginal")
 
 	GPSLatitude  = tagCoord("exif:GPSLatitude", 'N', 'S')
 	GPSLongitude = tagCoord("exif:GPSLongitude", 'E', 'W')
 
-	GPSTimeStamp = tagString("exif:GPSTimeStamp") // includes exif/GPSDateStamp
+	// GPSTimeStamp includes exif/GPSDateStamp, see tagGPSTime
+	GPSTimeStamp = tagGPSTime("exif:GPSTimeStamp", "exif:GPSDateStamp")
+
+	// GPSAltitude is signed using exif:GPSAltitudeRef (1: below sea level)
+	GPSAltitude = tagAltitude("exif:GPSAltitude", "exif:GPSAltitudeRef")
+
+	GPSImgDirection = tagRational("exif:GPSImgDirection")
+
+	GPSDOP        = tagRational("exif:GPSDOP")
+	GPSSatellites = tagString("exif:GPSSatellites")
+
+	GPSProcessingMethod = tagString("exif:GPSProcessingMethod")
+	GPSAreaInformation  = tagString("exif:GPSAreaInformation")
 
 	Orientation = tagInt("exif:Orientation")
 
+	InteropIndex = tagString("exifex:InteroperabilityIndex")
+
 	Make  = tagString("tiff:Make")
 	Model = tagString("tiff:Model")
+
+	// ordered lists (rdf:Seq) of integers, see SetSeq
+	ISOSpeedRatings = tagSeqInts("exif:ISOSpeedRatings")
+	BitsPerSample   = tagSeqInts("tiff:BitsPerSample")
+
+	CreatorTool = tagString("xmp:CreatorTool") // used for exif/Software
+
+	// MotionPhotoOffset is the offset of the video of a motion photo
+	// from the end of the file, from either GCamera:MicroVideoOffset
+	// or the MotionPhoto item of Container:Directory
+	MotionPhotoOffset = tagMotionPhotoOffset("GCamera:MicroVideoOffset", "Container:Directory")
+
+	// language alternatives, see SetLangAlt
+	Title            = tagLangAlt("dc:title")
+	Description      = tagLangAlt("dc:description")
+	ImageDescription = tagLangAlt("tiff:ImageDescription")
+	UserComment      = tagLangAlt("exif:UserComment")
 )
 
 type StringFunc func(m *Meta) (value string, ok bool)
@@ -37,6 +96,8 @@ type IntFunc func(m *Meta) (value int, ok bool)
 
 type Float64Func func(m *Meta) (value float64, ok bool)
 
+type IntsFunc func(m *Meta) (values []int, ok bool)
+
 func tagString(name string) StringFunc {
 	xn := xmlName(name)
 	return func(m *Meta) (string, bool) {
@@ -56,6 +117,221 @@ func tagInt(name string) IntFunc {
 	}
 }
 
+// tagSeqInts returns the items of an ordered list of integers.
+// Simple (non-list) properties are returned as a single item.
+func tagSeqInts(name string) IntsFunc {
+	xn := xmlName(name)
+	return func(m *Meta) ([]int, bool) {
+		v, ok := findSeq(m, xn)
+		if !ok {
+			return nil, false
+		}
+		values := make([]int, len(v))
+		for i, s := range v {
+			var err error
+			if values[i], err = strconv.Atoi(strings.TrimSpace(s)); err != nil {
+				return nil, false
+			}
+		}
+		return values, true
+	}
+}
+
+// tagRating returns a rating value. Integral values
+// formatted as floats such as "4.0" are accepted,
+// because some applications write ratings so.
+func tagRating(name string) IntFunc {
+	xn := xmlName(name)
+	return func(m *Meta) (int, bool) {
+		s, ok := findString(m, xn)
+		if !ok {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f != math.Trunc(f) || f < -1 || f > 5 {
+			return 0, false
+		}
+		return int(f), true
+	}
+}
+
+// tagGPSTime returns the GPS date and time.
+//
+// The XMP form is a single date with time of day, that is returned as is.
+// Some writers use the Exif form instead, with the time of day
+// as hours, minutes and seconds (such as "15,44,32.5") in name
+// and the date (such as "2014:07:11") in dateName. Such values
+// are combined and returned in RFC 3339 format in UTC.
+func tagGPSTime(name, dateName string) StringFunc {
+	xn, xdate := xmlName(name), xmlName(dateName)
+	return func(m *Meta) (string, bool) {
+		s, ok := findString(m, xn)
+		if !ok {
+			return "", false
+		}
+		hms := strings.Split(strings.TrimSpace(s), ",")
+		if len(hms) != 3 {
+			return s, true
+		}
+		d, ok := findString(m, xdate)
+		if !ok {
+			return "", false
+		}
+		date, err := time.Parse("2006:01:02", strings.Replace(strings.TrimSpace(d), "-", ":", -1))
+		if err != nil {
+			return "", false
+		}
+		var v [3]float64
+		for i, p := range hms {
+			if v[i], err = strconv.ParseFloat(p, 64); err != nil {
+				return "", false
+			}
+		}
+		tod := v[0]*3600 + v[1]*60 + v[2]
+		t := date.Add(time.Duration(tod * float64(time.Second)))
+		return t.Format(time.RFC3339Nano), true
+	}
+}
+
+// tagRational returns a rational value such as "181/1".
+// Plain decimal values are accepted as well.
+func tagRational(name string) Float64Func {
+	xn := xmlName(name)
+	return func(m *Meta) (float64, bool) {
+		s, ok := findString(m, xn)
+		if !ok {
+			return 0, false
+		}
+		return parseRational(s)
+	}
+}
+
+// tagAltitude returns an altitude value
+// having its sign stored in a separate ref node.
+func tagAltitude(name, refName string) Float64Func {
+	xn, xref := xmlName(name), xmlName(refName)
+	return func(m *Meta) (float64, bool) {
+		s, ok := findString(m, xn)
+		if !ok {
+			return 0, false
+		}
+		alt, ok := parseRational(s)
+		if !ok {
+			return 0, false
+		}
+		// permit missing ref, but use it for the sign if it exists.
+		if ref, ok := findString(m, xref); ok && strings.TrimSpace(ref) == "1" {
+			alt = -alt
+		}
+		return alt, true
+	}
+}
+
+// tagMotionPhotoOffset returns the offset of the video
+// from the end of a motion photo file.
+//
+// Older files record the offset in offsetName. Newer ones list
+// the media items appended to the primary image in dirName,
+// and the offset is the total length of the items starting with
+// the one having the MotionPhoto semantic.
+func tagMotionPhotoOffset(offsetName, dirName string) IntFunc {
+	xoffset, xdir := xmlName(offsetName), xmlName(dirName)
+	xitem := xmlName("Container:Item")
+	xsemantic, xlength, xpadding := xmlName("Item:Semantic"), xmlName("Item:Length"), xmlName("Item:Padding")
+	return func(m *Meta) (int, bool) {
+		if s, ok := findString(m, xoffset); ok {
+			i, err := strconv.Atoi(strings.TrimSpace(s))
+			return i, err == nil && i > 0
+		}
+
+		n := findNode(m, xdir)
+		if n == nil {
+			return 0, false
+		}
+		seq := n.child(rdfSeq)
+		if seq == nil {
+			return 0, false
+		}
+		offset, found := 0, false
+		for i := range seq.Node {
+			li := &seq.Node[i]
+			item := li.child(xitem)
+			if li.XMLName != rdfLi || item == nil {
+				continue
+			}
+			if item.prop(xsemantic) == "MotionPhoto" {
+				found = true
+			}
+			if !found {
+				continue
+			}
+			l, err := strconv.Atoi(item.prop(xlength))
+			if err != nil || l <= 0 {
+				return 0, false
+			}
+			p, _ := strconv.Atoi(item.prop(xpadding))
+			offset += l + p
+		}
+		return offset, found
+	}
+}
+
+func parseRational(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexByte(s, '/')
+	if i < 0 {
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	num, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	den, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil || den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// tagLangAlt returns the default value of a language alternative.
+// The first alternative is used if there is no x-default item,
+// and the content of simple (non-alternative) properties are
+// returned as-is.
+func tagLangAlt(name string) StringFunc {
+	xn := xmlName(name)
+	return func(m *Meta) (string, bool) {
+		n := findNode(m, xn)
+		if n == nil {
+			return "", false
+		}
+		alt := n.child(rdfAlt)
+		if alt == nil {
+			if len(n.Node) != 0 {
+				return "", false
+			}
+			return string(n.CharData), true
+		}
+		var first *Node
+		for i := range alt.Node {
+			li := &alt.Node[i]
+			if li.XMLName != rdfLi {
+				continue
+			}
+			if li.attr(xmlLang) == DefaultLang {
+				return string(li.CharData), true
+			}
+			if first == nil {
+				first = li
+			}
+		}
+		if first != nil {
+			return string(first.CharData), true
+		}
+		return "", false
+	}
+}
+
 func tagCoord(name string, pos, neg byte) Float64Func {
 	xn := xmlName(name)
 	return func(m *Meta) (value float64, ok bool) {
@@ -92,7 +368,32 @@ func xmlName(t string) xml.Name {
 	if !ok {
 		panic("invalid namespace")
 	}
-	return xml.Name{ns, parts[1]}
+	return xml.Name{Space: ns, Local: parts[1]}
+}
+
+// findSeq returns the items of the ordered list (rdf:Seq) property name.
+// Simple properties are returned as a single item.
+func findSeq(m *Meta, name xml.Name) (v []string, ok bool) {
+	n := findNode(m, name)
+	if n == nil {
+		if a := findAttr(m, name); a != nil {
+			return []string{a.Value}, true
+		}
+		return nil, false
+	}
+	seq := n.child(rdfSeq)
+	if seq == nil {
+		if len(n.Node) != 0 {
+			return nil, false
+		}
+		return []string{string(n.CharData)}, true
+	}
+	for _, li := range seq.Node {
+		if li.XMLName == rdfLi {
+			v = append(v, string(li.CharData))
+		}
+	}
+	return v, true
 }
 
 func findString(m *Meta, name xml.Name) (s string, ok bool) {
@@ -100,9 +401,26 @@ func findString(m *Meta, name xml.Name) (s string, ok bool) {
 	if n != nil {
 		return string(n.CharData), true
 	}
+	if a := findAttr(m, name); a != nil {
+		return a.Value, true
+	}
 	return "", false
 }
 
+// findAttr returns simple properties written
+// as attributes of rdf:Description.
+func findAttr(m *Meta, name xml.Name) *xml.Attr {
+	for i := range m.Rdf.Desc {
+		d := &m.Rdf.Desc[i]
+		for j := range d.Attr {
+			if d.Attr[j].Name == name {
+				return &d.Attr[j]
+			}
+		}
+	}
+	return nil
+}
+
 func findNode(m *Meta, name xml.Name) *Node {
 	for _, d := range m.Rdf.Desc {
 		for i := range d.Node {
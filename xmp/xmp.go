@@ -3,11 +3,19 @@ package xmp
 import (
 	"encoding/xml"
 	"io"
+	"strings"
 )
 
 type Meta struct {
 	XMLName xml.Name `xml:"adobe:ns:meta/ xmpmeta"`
 	Rdf     Rdf      `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+
+	// PacketPadding is the amount of whitespace written by Encode
+	// before the packet trailer, so that the packet may be edited
+	// in place. Zero means 2048 bytes, negative means no padding.
+	PacketPadding int `xml:"-"`
+
+	modified bool // see Modified
 }
 
 type Rdf struct {
@@ -16,8 +24,17 @@ type Rdf struct {
 
 type Node struct {
 	XMLName  xml.Name
-	Node     []Node `xml:",any"`
-	CharData []byte `xml:",chardata"`
+	Attr     []xml.Attr `xml:",any,attr"`
+	Node     []Node     `xml:",any"`
+	CharData []byte     `xml:",chardata"`
+}
+
+// New returns an empty Meta, that properties may be added to
+// using SetString, SetLangAlt and SetSeq.
+func New() *Meta {
+	return &Meta{
+		XMLName: xml.Name{Space: metaNS, Local: "xmpmeta"},
+	}
 }
 
 func Decode(r io.Reader) (*Meta, error) {
@@ -41,3 +58,261 @@ func (m *Meta) Int(f IntFunc) (value int, ok bool) {
 func (m *Meta) Float64(f Float64Func) (value float64, ok bool) {
 	return f(m)
 }
+
+func (m *Meta) Ints(f IntsFunc) (values []int, ok bool) {
+	return f(m)
+}
+
+// Modified reports whether properties of m were changed
+// using SetString, SetLangAlt or SetSeq, or removed
+// using Delete or DeleteAllGPS since m was created or decoded.
+//
+// Writers may use it to keep the original XMP packet verbatim
+// if m was not modified, because Encode does not preserve
+// the formatting and attribute order of the decoded packet.
+func (m *Meta) Modified() bool {
+	return m.modified
+}
+
+// SetString sets the value of the simple property name, such as "tiff:Make".
+// The property is created within m if it does not exist yet.
+//
+// Properties written as attributes of rdf:Description are updated in place.
+func (m *Meta) SetString(name, value string) {
+	xn := xmlName(name)
+	if n := findNode(m, xn); n != nil {
+		if len(n.Node) == 0 && string(n.CharData) == value {
+			return
+		}
+	} else if a := findAttr(m, xn); a != nil {
+		if a.Value != value {
+			a.Value = value
+			m.modified = true
+		}
+		return
+	}
+	m.modified = true
+	n := m.ensureNode(xn)
+	n.Node = nil
+	n.CharData = []byte(value)
+}
+
+// SetLangAlt sets the default (x-default) value of
+// the language alternative property name, such as "dc:title".
+//
+// Values for other languages are kept. The property is created
+// within m if it does not exist yet.
+func (m *Meta) SetLangAlt(name, value string) {
+	n := m.ensureNode(xmlName(name))
+
+	alt := n.child(rdfAlt)
+	if alt == nil {
+		m.modified = true
+		// replace simple or unknown content
+		n.CharData = nil
+		n.Node = []Node{{XMLName: rdfAlt}}
+		alt = &n.Node[0]
+	}
+
+	for i := range alt.Node {
+		li := &alt.Node[i]
+		if li.XMLName == rdfLi && li.attr(xmlLang) == DefaultLang {
+			if len(li.Node) == 0 && string(li.CharData) == value {
+				return
+			}
+			m.modified = true
+			li.Node = nil
+			li.CharData = []byte(value)
+			return
+		}
+	}
+
+	// x-default should be the first item
+	m.modified = true
+	li := Node{
+		XMLName:  rdfLi,
+		Attr:     []xml.Attr{{Name: xmlLang, Value: DefaultLang}},
+		CharData: []byte(value),
+	}
+	alt.Node = append([]Node{li}, alt.Node...)
+}
+
+// SetSeq sets the items of the ordered list (rdf:Seq) property name,
+// such as "exif:ISOSpeedRatings". Existing items are replaced.
+// The property is created within m if it does not exist yet.
+func (m *Meta) SetSeq(name string, values ...string) {
+	n := m.ensureNode(xmlName(name))
+	if len(n.Node) == 1 && n.Node[0].isSeq(values) {
+		return
+	}
+	m.modified = true
+
+	seq := Node{XMLName: rdfSeq}
+	for _, v := range values {
+		seq.Node = append(seq.Node, Node{XMLName: rdfLi, CharData: []byte(v)})
+	}
+	n.CharData = nil
+	n.Node = []Node{seq}
+}
+
+// Delete removes the property name, such as "exif:GPSDateStamp", from m.
+func (m *Meta) Delete(name string) {
+	xn := xmlName(name)
+	for i := range m.Rdf.Desc {
+		d := &m.Rdf.Desc[i]
+		if d.removeAttr(xn) {
+			m.modified = true
+		}
+		nodes := d.Node[:0]
+		for _, n := range d.Node {
+			if n.XMLName == xn {
+				m.modified = true
+			} else {
+				nodes = append(nodes, n)
+			}
+		}
+		d.Node = nodes
+	}
+}
+
+// DeleteAllGPS removes the GPS properties in the exif namespace,
+// such as exif:GPSLatitude and exif:GPSTimeStamp, from m.
+func (m *Meta) DeleteAllGPS() {
+	ns := nsmap["exif"]
+	isGPS := func(name xml.Name) bool {
+		return name.Space == ns && strings.HasPrefix(name.Local, "GPS")
+	}
+	for i := range m.Rdf.Desc {
+		d := &m.Rdf.Desc[i]
+
+		attr := d.Attr[:0]
+		for _, a := range d.Attr {
+			if isGPS(a.Name) {
+				m.modified = true
+			} else {
+				attr = append(attr, a)
+			}
+		}
+		d.Attr = attr
+
+		nodes := d.Node[:0]
+		for _, n := range d.Node {
+			if isGPS(n.XMLName) {
+				m.modified = true
+			} else {
+				nodes = append(nodes, n)
+			}
+		}
+		d.Node = nodes
+	}
+}
+
+// ensureNode returns the property node having the specified name.
+// A new node is created if necessary within the first
+// description having properties in the same namespace.
+//
+// If the property is written as an attribute of a description,
+// the attribute is replaced by the new node in the same description.
+func (m *Meta) ensureNode(name xml.Name) *Node {
+	if n := findNode(m, name); n != nil {
+		return n
+	}
+
+	var d *Node
+	for i := range m.Rdf.Desc {
+		if m.Rdf.Desc[i].removeAttr(name) {
+			d = &m.Rdf.Desc[i]
+			break
+		}
+	}
+
+	if d == nil {
+		for i := range m.Rdf.Desc {
+			if m.Rdf.Desc[i].hasProperty(name.Space) {
+				d = &m.Rdf.Desc[i]
+				break
+			}
+		}
+	}
+
+	if d == nil {
+		m.Rdf.Desc = append(m.Rdf.Desc, Node{
+			XMLName: rdfDescription,
+			Attr:    []xml.Attr{{Name: rdfAbout}},
+		})
+		d = &m.Rdf.Desc[len(m.Rdf.Desc)-1]
+	}
+
+	d.Node = append(d.Node, Node{XMLName: name})
+	return &d.Node[len(d.Node)-1]
+}
+
+// hasProperty reports whether the description d has
+// property nodes or attributes in namespace ns.
+func (d *Node) hasProperty(ns string) bool {
+	for _, n := range d.Node {
+		if n.XMLName.Space == ns {
+			return true
+		}
+	}
+	for _, a := range d.Attr {
+		if !isNSDecl(a) && a.Name.Space == ns {
+			return true
+		}
+	}
+	return false
+}
+
+// removeAttr removes the attribute of n having the specified name,
+// and reports whether it was present.
+func (n *Node) removeAttr(name xml.Name) bool {
+	for i, a := range n.Attr {
+		if a.Name == name {
+			n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// isSeq reports whether n is an rdf:Seq having the specified items.
+func (n *Node) isSeq(values []string) bool {
+	if n.XMLName != rdfSeq || len(n.Node) != len(values) {
+		return false
+	}
+	for i, li := range n.Node {
+		if li.XMLName != rdfLi || len(li.Node) != 0 || string(li.CharData) != values[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// child returns the first child node of n having the specified name.
+func (n *Node) child(name xml.Name) *Node {
+	for i := range n.Node {
+		if n.Node[i].XMLName == name {
+			return &n.Node[i]
+		}
+	}
+	return nil
+}
+
+// attr returns the value of the attribute of n having the specified name.
+func (n *Node) attr(name xml.Name) string {
+	for _, a := range n.Attr {
+		if a.Name == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// prop returns the simple property name of n,
+// written either as an attribute or a child node.
+func (n *Node) prop(name xml.Name) string {
+	if c := n.child(name); c != nil {
+		return string(c.CharData)
+	}
+	return n.attr(name)
+}
@@ -1,6 +1,9 @@
 package xmp
 
 import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -23,6 +26,129 @@ func TestDecode(t *testing.T) {
 	} else {
 		t.Logf("GPSLongitude=%f", lon)
 	}
+	if dir, ok := x.Float64(GPSImgDirection); !ok || dir != 181 {
+		t.Errorf("GPSImgDirection is %v (ok=%v), want 181", dir, ok)
+	}
+	if alt, ok := x.Float64(GPSAltitude); !ok || alt != 0 {
+		t.Errorf("GPSAltitude is %v (ok=%v), want 0", alt, ok)
+	}
+	if m, ok := x.String(GPSProcessingMethod); !ok || m != "ASCII" {
+		t.Errorf("GPSProcessingMethod is %q (ok=%v), want \"ASCII\"", m, ok)
+	}
+	if i, ok := x.String(InteropIndex); !ok || i != "R98" {
+		t.Errorf("InteropIndex is %q (ok=%v), want \"R98\"", i, ok)
+	}
+}
+
+func TestGPSAltitude(t *testing.T) {
+	tests := []struct {
+		alt, ref string
+		want     float64
+	}{
+		{"1234/10", "", 123.4},
+		{"1234/10", "0", 123.4},
+		{"1234/10", "1", -123.4},
+		{"25", "1", -25},
+	}
+	for _, tt := range tests {
+		src := `<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about='' xmlns:exif='http://ns.adobe.com/exif/1.0/'>
+  <exif:GPSAltitude>` + tt.alt + `</exif:GPSAltitude>
+ </rdf:Description>`
+		if tt.ref != "" {
+			// ref in a sibling description
+			src += `
+ <rdf:Description rdf:about='' xmlns:exif='http://ns.adobe.com/exif/1.0/'>
+  <exif:GPSAltitudeRef>` + tt.ref + `</exif:GPSAltitudeRef>
+ </rdf:Description>`
+		}
+		src += `
+</rdf:RDF>
+</x:xmpmeta>`
+
+		x, err := Decode(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := x.Float64(GPSAltitude)
+		if !ok || got != tt.want {
+			t.Errorf("altitude %q ref %q: got %v (ok=%v), want %v",
+				tt.alt, tt.ref, got, ok, tt.want)
+		}
+	}
+}
+
+func TestGPSTimeStamp(t *testing.T) {
+	tests := []struct {
+		time, date string
+		want       string
+	}{
+		{"2014-07-11T15:44:32Z", "", "2014-07-11T15:44:32Z"},
+		{"15,44,32", "2014:07:11", "2014-07-11T15:44:32Z"},
+		{"15,44,32.25", "2014-07-11", "2014-07-11T15:44:32.25Z"},
+		{"15,44.5,0", "2014:07:11", "2014-07-11T15:44:30Z"},
+	}
+	for _, tt := range tests {
+		src := `<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about='' xmlns:exif='http://ns.adobe.com/exif/1.0/'>
+  <exif:GPSTimeStamp>` + tt.time + `</exif:GPSTimeStamp>`
+		if tt.date != "" {
+			src += `
+  <exif:GPSDateStamp>` + tt.date + `</exif:GPSDateStamp>`
+		}
+		src += `
+ </rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>`
+
+		x, err := Decode(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := x.String(GPSTimeStamp)
+		if !ok || got != tt.want {
+			t.Errorf("time %q date %q: got %q (ok=%v), want %q",
+				tt.time, tt.date, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRating(t *testing.T) {
+	tests := []struct {
+		rating string
+		want   int
+		ok     bool
+	}{
+		{"4", 4, true},
+		{"4.0", 4, true},
+		{"-1", -1, true},
+		{"-1.0", -1, true},
+		{"0", 0, true},
+		{"4.5", 0, false},
+		{"6", 0, false},
+		{"garbage", 0, false},
+	}
+	for _, tt := range tests {
+		src := `<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about='' xmlns:xmp='http://ns.adobe.com/xap/1.0/'>
+  <xmp:Rating>` + tt.rating + `</xmp:Rating>
+ </rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>`
+
+		x, err := Decode(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := x.Int(Rating)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("rating %q: got %v (ok=%v), want %v (ok=%v)",
+				tt.rating, got, ok, tt.want, tt.ok)
+		}
+	}
 }
 
 const sample = `<?xpacket begin='` + "\ufeff" + `' id='W5M0MpCehiHzreSzNTczkc9d'?>
@@ -108,3 +234,211 @@ const sample = `<?xpacket begin='` + "\ufeff" + `' id='W5M0MpCehiHzreSzNTczkc9d'
 </rdf:RDF>
 </x:xmpmeta>
 <?xpacket end='w'?>`
+
+func TestSeq(t *testing.T) {
+	x, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInts(t, x, "exif:ISOSpeedRatings", ISOSpeedRatings, 100)
+	checkInts(t, x, "tiff:BitsPerSample", BitsPerSample, 8)
+
+	x.SetSeq("exif:ISOSpeedRatings", "200")
+	x.SetSeq("tiff:BitsPerSample", "8", "8", "8")
+
+	var buf bytes.Buffer
+	if err := x.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	y, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInts(t, y, "exif:ISOSpeedRatings", ISOSpeedRatings, 200)
+	checkInts(t, y, "tiff:BitsPerSample", BitsPerSample, 8, 8, 8)
+
+	// simple property instead of a list
+	z := New()
+	z.SetString("exif:ISOSpeedRatings", "400")
+	checkInts(t, z, "exif:ISOSpeedRatings", ISOSpeedRatings, 400)
+
+	z.SetString("exif:ISOSpeedRatings", "fast")
+	if v, ok := z.Ints(ISOSpeedRatings); ok {
+		t.Errorf("invalid exif:ISOSpeedRatings is %v", v)
+	}
+}
+
+func checkInts(t *testing.T, x *Meta, name string, f IntsFunc, want ...int) {
+	got, ok := x.Ints(f)
+	if !ok {
+		t.Errorf("%s missing", name)
+		return
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s is %v, want %v", name, got, want)
+	}
+}
+
+func TestDeleteAllGPS(t *testing.T) {
+	x, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// written as an attribute
+	x.Rdf.Desc[0].Attr = append(x.Rdf.Desc[0].Attr, xml.Attr{Name: xmlName("exif:GPSDOP"), Value: "2"})
+	if _, ok := x.Float64(GPSDOP); !ok {
+		t.Fatal("missing GPSDOP")
+	}
+
+	x.DeleteAllGPS()
+	if !x.Modified() {
+		t.Error("XMP not reported as modified after DeleteAllGPS")
+	}
+	for _, d := range x.Rdf.Desc {
+		for _, n := range d.Node {
+			if strings.HasPrefix(n.XMLName.Local, "GPS") {
+				t.Errorf("%s remains", n.XMLName.Local)
+			}
+		}
+		for _, a := range d.Attr {
+			if strings.HasPrefix(a.Name.Local, "GPS") {
+				t.Errorf("%s attribute remains", a.Name.Local)
+			}
+		}
+	}
+	checkString(t, x, "tiff:Make", Make, "LGE")
+}
+
+func TestDelete(t *testing.T) {
+	x, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Delete("tiff:Model")
+	if !x.Modified() {
+		t.Error("XMP not reported as modified after Delete")
+	}
+	if v, ok := x.String(Model); ok {
+		t.Errorf("tiff:Model remains with value %q", v)
+	}
+	checkString(t, x, "tiff:Make", Make, "LGE")
+
+	y, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	y.Delete("dc:title")
+	if y.Modified() {
+		t.Error("XMP reported as modified after deleting missing property")
+	}
+}
+
+func TestLangAlt(t *testing.T) {
+	x, err := Decode(strings.NewReader(langAltSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkString(t, x, "dc:title", Title, "Default title")
+	checkString(t, x, "dc:description", Description, "Erste Beschreibung")
+	if x.Modified() {
+		t.Error("decoded XMP reported as modified")
+	}
+
+	x.SetLangAlt("dc:title", "New title")
+	x.SetLangAlt("dc:description", "New description")
+	checkString(t, x, "dc:title", Title, "New title")
+	checkString(t, x, "dc:description", Description, "New description")
+	if !x.Modified() {
+		t.Error("XMP not reported as modified after SetLangAlt")
+	}
+
+	// other languages must be kept
+	for _, name := range []string{"dc:title", "dc:description"} {
+		n := findNode(x, xmlName(name))
+		alt := n.child(rdfAlt)
+		if alt == nil {
+			t.Fatalf("%s: rdf:Alt missing", name)
+		}
+		var langs []string
+		for _, li := range alt.Node {
+			langs = append(langs, li.attr(xmlLang))
+		}
+		if len(langs) != 2 || langs[0] != DefaultLang || langs[1] != "de" {
+			t.Errorf("%s: got languages %v, want [x-default de]", name, langs)
+		}
+	}
+}
+
+func TestSetLangAltNew(t *testing.T) {
+	x, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := x.String(Title); ok {
+		t.Fatal("unexpected dc:title in sample")
+	}
+
+	x.SetLangAlt("dc:title", "Title")
+	checkString(t, x, "dc:title", Title, "Title")
+
+	x.SetLangAlt("dc:title", "Other title")
+	checkString(t, x, "dc:title", Title, "Other title")
+
+	n := findNode(x, xmlName("dc:title"))
+	if alt := n.child(rdfAlt); alt == nil || len(alt.Node) != 1 {
+		t.Errorf("dc:title has invalid structure: %+v", n)
+	}
+}
+
+func TestSetUnchanged(t *testing.T) {
+	x, err := Decode(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetString("tiff:Make", "LGE")
+	x.SetSeq("exif:ISOSpeedRatings", "100")
+	if x.Modified() {
+		t.Error("XMP reported as modified after setting existing values")
+	}
+
+	y, err := Decode(strings.NewReader(langAltSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	y.SetLangAlt("dc:title", "Default title")
+	if y.Modified() {
+		t.Error("XMP reported as modified after setting existing dc:title")
+	}
+}
+
+func checkString(t *testing.T, x *Meta, name string, f StringFunc, want string) {
+	got, ok := x.String(f)
+	if !ok {
+		t.Errorf("%s missing", name)
+		return
+	}
+	if got != want {
+		t.Errorf("%s is %q, want %q", name, got, want)
+	}
+}
+
+const langAltSample = `<x:xmpmeta xmlns:x='adobe:ns:meta/'>
+<rdf:RDF xmlns:rdf='http://www.w3.org/1999/02/22-rdf-syntax-ns#'>
+ <rdf:Description rdf:about=''
+  xmlns:dc='http://purl.org/dc/elements/1.1/'>
+  <dc:title>
+   <rdf:Alt>
+    <rdf:li xml:lang='x-default'>Default title</rdf:li>
+    <rdf:li xml:lang='de'>Titel</rdf:li>
+   </rdf:Alt>
+  </dc:title>
+  <dc:description>
+   <rdf:Alt>
+    <rdf:li xml:lang='de'>Erste Beschreibung</rdf:li>
+   </rdf:Alt>
+  </dc:description>
+ </rdf:Description>
+</rdf:RDF>
+</x:xmpmeta>`